@@ -0,0 +1,187 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/urfave/cli/v3"
+
+	authutil "github.com/livekit/livekit-cli/pkg/auth"
+	"github.com/livekit/livekit-cli/pkg/util"
+)
+
+const (
+	agentLogsEndpoint = "/api/agents/logs"
+)
+
+var (
+	AgentCommands = []*cli.Command{
+		{
+			Name:  "agent",
+			Usage: "Inspect agents deployed to LiveKit Cloud",
+			Commands: []*cli.Command{
+				{
+					Name:      "logs",
+					Usage:     "Print recent logs from the workers of a deployed agent",
+					UsageText: "lk agent logs [OPTIONS] AGENT_NAME",
+					ArgsUsage: "AGENT_NAME",
+					Action:    showAgentLogs,
+					Flags: []cli.Flag{
+						&cli.BoolFlag{
+							Name:    "follow",
+							Aliases: []string{"f"},
+							Usage:   "Keep streaming new log lines until interrupted",
+						},
+						&cli.DurationFlag{
+							Name:  "since",
+							Usage: "Only show logs newer than a relative `TIME`, e.g. \"10m\", \"1h\"",
+						},
+						&cli.IntFlag{
+							Name:  "tail",
+							Usage: "`NUMBER` of most recent lines to show initially",
+							Value: 100,
+						},
+						&cli.DurationFlag{
+							Name:  "poll-interval",
+							Usage: "`TIME` between requests for new lines when following",
+							Value: 2 * time.Second,
+						},
+						&cli.StringFlag{
+							Name:        "server-url",
+							Value:       cloudAPIServerURL,
+							Destination: &serverURL,
+							Hidden:      true,
+						},
+						jsonFlag,
+					},
+				},
+			},
+		},
+	}
+)
+
+type AgentLogEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Level     string    `json:"level"`
+	WorkerID  string    `json:"worker_id,omitempty"`
+	Message   string    `json:"message"`
+}
+
+func showAgentLogs(ctx context.Context, cmd *cli.Command) error {
+	agentName, err := extractArg(cmd)
+	if err != nil {
+		return err
+	}
+	token, err := requireToken(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	var since time.Time
+	if d := cmd.Duration("since"); d > 0 {
+		since = time.Now().Add(-d)
+	}
+	tail := int(cmd.Int("tail"))
+
+	for {
+		polled := time.Now()
+		entries, err := fetchAgentLogs(ctx, token, agentName, since, tail)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			printAgentLogEntry(cmd, entry)
+			if entry.Timestamp.After(since) {
+				since = entry.Timestamp
+			}
+		}
+		if !cmd.Bool("follow") {
+			return nil
+		}
+		// without any entry yet, only ask for those logged after this poll
+		// rather than the whole history
+		if since.IsZero() {
+			since = polled
+		}
+		// only limit the initial backlog
+		tail = 0
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(cmd.Duration("poll-interval")):
+		}
+	}
+}
+
+func fetchAgentLogs(ctx context.Context, token, agentName string, since time.Time, tail int) ([]AgentLogEntry, error) {
+	reqURL, err := url.Parse(serverURL + agentLogsEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Add("agent_name", agentName)
+	if !since.IsZero() {
+		params.Add("since", since.Format(time.RFC3339Nano))
+	}
+	if tail > 0 {
+		params.Add("tail", strconv.Itoa(tail))
+	}
+	reqURL.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = authutil.NewHeaderWithToken(token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("agent not found: %s", agentName)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status)
+	}
+
+	var entries []AgentLogEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func printAgentLogEntry(cmd *cli.Command, entry AgentLogEntry) {
+	if cmd.Bool("json") {
+		b, _ := json.Marshal(entry)
+		fmt.Println(string(b))
+		return
+	}
+	level := util.Theme.Focused.Title.Render(fmt.Sprintf("%-5s", entry.Level))
+	fmt.Printf("%s %s %s\n", entry.Timestamp.Format(time.RFC3339), level, entry.Message)
+}
@@ -18,23 +18,31 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
+	"maps"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/huh/spinner"
-	"github.com/charmbracelet/lipgloss"
+	"github.com/go-task/task/v3/taskfile/ast"
 	"github.com/livekit/livekit-cli/pkg/bootstrap"
 	"github.com/livekit/livekit-cli/pkg/config"
 	"github.com/livekit/livekit-cli/pkg/util"
 	"github.com/urfave/cli/v3"
+	"gopkg.in/yaml.v3"
 )
 
 var (
 	template        *bootstrap.Template
 	templateName    string
 	templateURL     string
+	templateRef     string
 	sandboxID       string
 	appName         string
 	appNameRegex    = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
@@ -49,7 +57,7 @@ var (
 				{
 					Name:      "create",
 					Usage:     "Bootstrap a new application from a template or through guided creation",
-					Before:    requireProject,
+					Before:    beforeCreateApp,
 					Action:    setupTemplate,
 					ArgsUsage: "`APP_NAME`",
 					Flags: []cli.Flag{
@@ -63,11 +71,20 @@ var (
 							Usage:       "`URL` to instantiate, must contain a taskfile.yaml",
 							Destination: &templateURL,
 						},
+						&cli.StringFlag{
+							Name:        "template-ref",
+							Usage:       "Git `REF` of the template to use, a branch, tag or full commit SHA",
+							Destination: &templateRef,
+						},
 						&cli.StringFlag{
 							Name:        "sandbox",
 							Usage:       "`NAME` of the sandbox, see your cloud dashboard",
 							Destination: &sandboxID,
 						},
+						&cli.BoolFlag{
+							Name:  "choose-sandbox",
+							Usage: "Select the sandbox from those of your project instead of naming it with --sandbox",
+						},
 						&cli.StringFlag{
 							Name:        "server-url",
 							Value:       cloudAPIServerURL,
@@ -80,14 +97,98 @@ var (
 							Usage:   "Run installation tasks after creating the app",
 							Hidden:  true,
 						},
+						&cli.BoolFlag{
+							Name:  "show-inputs",
+							Usage: "List the values the template will prompt for, without creating the app",
+						},
+						&cli.BoolFlag{
+							Name:  "mirror",
+							Usage: "Clone the full template repository, keeping .git, and skip instantiation, for developing templates",
+						},
+						packageManagerFlag,
+						chooseProjectFlag,
+						&cli.IntFlag{
+							Name:  "git-depth",
+							Usage: "Clone the last `N` commits of the template repository",
+							Value: 1,
+						},
+						&cli.BoolFlag{
+							Name:  "full-history",
+							Usage: "Clone the full history of the template repository, keeping .git unless --strip-git is set",
+						},
+						&cli.BoolFlag{
+							Name:  "strip-git",
+							Usage: "Remove .git after cloning with --full-history",
+						},
+						&cli.StringFlag{
+							Name:  "output-dir",
+							Usage: "Existing `DIR` to create the app in (default: current directory)",
+						},
+						&cli.GenericFlag{
+							Name:      "env-file",
+							Usage:     "Answer environment prompts with the values in `FILE`, can be used multiple times, later files taking precedence",
+							TakesFile: true,
+							Value:     &stringList{},
+						},
+						&cli.BoolFlag{
+							Name:  "dry-run",
+							Usage: "Print the clone command, app directory and environment keys, without creating the app",
+						},
+						refreshTemplatesFlag,
+						templatesTTLFlag,
+						&cli.BoolFlag{
+							Name:  "resume",
+							Usage: "Continue creating APP_NAME after a failed run, skipping the steps it completed",
+						},
+						&cli.BoolFlag{
+							Name:  "non-interactive",
+							Usage: "Fail instead of prompting for values that are missing, such as variables not set by --env-file",
+						},
+						jsonFlag,
 					},
 				},
 				{
-					Name:   "list-templates",
-					Usage:  "List available templates to bootstrap a new application",
-					Flags:  []cli.Flag{jsonFlag},
+					Name:  "list-templates",
+					Usage: "List available templates to bootstrap a new application",
+					Flags: []cli.Flag{
+						jsonFlag,
+						&cli.BoolFlag{
+							Name:  "yaml",
+							Usage: "Output as YAML, in the format of the template index",
+						},
+						refreshTemplatesFlag,
+						templatesTTLFlag,
+					},
 					Action: listTemplates,
 				},
+				{
+					Name:      "update",
+					Usage:     "Pull the changes made to an app's template since it was created",
+					ArgsUsage: "[DIR] of the app (default: current directory)",
+					Action:    updateApp,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "template-ref",
+							Usage: "Update to git `REF` of the template, instead of the latest commit of the ref the app was created from",
+						},
+						&cli.BoolFlag{
+							Name:  "dry-run",
+							Usage: "List the template files that changed, without updating the app",
+						},
+						&cli.BoolFlag{
+							Name:  "non-interactive",
+							Usage: "Fail instead of prompting for values of new template variables",
+						},
+						jsonFlag,
+					},
+				},
+				{
+					Name:      "doctor",
+					Usage:     "Check that the app's tools, env and project credentials are ready to run its tasks",
+					ArgsUsage: "[DIR] location of the app directory (default: current directory)",
+					Action:    diagnoseApp,
+					Flags:     []cli.Flag{jsonFlag},
+				},
 				{
 					Hidden:    true,
 					Name:      "install",
@@ -95,13 +196,32 @@ var (
 					ArgsUsage: "[DIR] location of the project directory (default: current directory)",
 					Before:    requireProject,
 					Action:    installTemplate,
+					Flags:     []cli.Flag{packageManagerFlag, chooseProjectFlag},
 				},
 				{
 					Hidden:    true,
 					Name:      "run",
 					Usage:     "Execute a task defined in " + bootstrap.TaskFile,
-					ArgsUsage: "[TASK] to run in the project's taskfile.yaml",
+					ArgsUsage: "[TASK] to run in the project's taskfile.yaml, followed by KEY=VALUE variables and -- ARGS for {{.CLI_ARGS}}, or [DIR] of the project with --list",
 					Action:    runTask,
+					Flags: []cli.Flag{
+						&cli.BoolFlag{
+							Name:  "list",
+							Usage: "List the tasks defined in the project's taskfile.yaml without running any",
+						},
+						jsonFlag,
+						&cli.GenericFlag{
+							Name:      "env-file",
+							Usage:     "Load extra environment variables for the task from `FILE`, can be used multiple times, later files taking precedence",
+							TakesFile: true,
+							Value:     &stringList{},
+						},
+						&cli.GenericFlag{
+							Name:  "env",
+							Usage: "Set `KEY=VALUE` in the task's environment, taking precedence over --env-file, can be used multiple times",
+							Value: &stringList{},
+						},
+					},
 				},
 				{
 					Name:  "env",
@@ -128,19 +248,66 @@ var (
 							TakesFile:   true,
 							Destination: &exampleFile,
 						},
+						chooseProjectFlag,
 					},
 					ArgsUsage: "[DIR] location of the project directory (default: current directory)",
-					Before:    requireProject,
 					Action:    manageEnv,
+					Commands: []*cli.Command{
+						{
+							Name:      "get",
+							Usage:     "Print the value of a variable in the app's env file",
+							ArgsUsage: "KEY",
+							Action:    getAppEnv,
+							Flags:     []cli.Flag{appDirFlag},
+						},
+						{
+							Name:      "set",
+							Usage:     "Set variables in the app's env file, keeping its comments and order",
+							ArgsUsage: "KEY=VALUE [KEY=VALUE...]",
+							Action:    setAppEnv,
+							Flags:     []cli.Flag{appDirFlag},
+						},
+						{
+							Name:   "list",
+							Usage:  "List the variables in the app's env file",
+							Action: listAppEnv,
+							Flags:  []cli.Flag{appDirFlag, jsonFlag},
+						},
+					},
 				},
 			},
 		},
 	}
 )
 
+// beforeCreateApp fails before any project or template prompt when APP_NAME
+// was partially created, which only --resume continues.
+func beforeCreateApp(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+	if name := cmd.Args().First(); name != "" && !cmd.Bool("resume") {
+		if err := checkPartialApp(filepath.Join(cmd.String("output-dir"), name)); err != nil {
+			return nil, err
+		}
+	}
+	return requireProject(ctx, cmd)
+}
+
 func requireProject(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+	if cmd.Bool("show-inputs") || cmd.Bool("mirror") || cmd.Bool("dry-run") {
+		// inspecting or forking a template doesn't need credentials
+		return nil, nil
+	}
 	var err error
-	if project, err = loadProjectDetails(cmd); err != nil {
+	// --choose-project skips the default project, unless one is named
+	if cmd.Bool("choose-project") && cmd.String("project") == "" {
+		err = errors.New("no project selected")
+	} else {
+		project, err = loadProjectDetails(cmd)
+	}
+	if err != nil && cmd.String("project") != "" {
+		// a named project is never replaced by the selector
+		return nil, err
+	}
+	if err != nil {
 		if _, err = loadProjectConfig(ctx, cmd); err != nil {
 			// something is wrong with config file
 			return nil, err
@@ -161,6 +328,11 @@ func requireProject(ctx context.Context, cmd *cli.Command) (context.Context, err
 				Run(); err != nil {
 				return nil, err
 			}
+			// remember the choice so later commands don't prompt again
+			cliConfig.DefaultProject = project.Name
+			if err = cliConfig.PersistIfNeeded(); err != nil {
+				return nil, err
+			}
 		} else {
 			shouldAuth := true
 			if err = huh.NewConfirm().
@@ -186,14 +358,33 @@ func requireProject(ctx context.Context, cmd *cli.Command) (context.Context, err
 	return nil, err
 }
 
+// fetchTemplates returns the template index, read from a cache in the CLI
+// config directory while it is younger than --templates-ttl.
+func fetchTemplates(ctx context.Context, cmd *cli.Command) ([]bootstrap.Template, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return bootstrap.FetchTemplates(ctx)
+	}
+	return bootstrap.FetchTemplatesCached(ctx, filepath.Join(dir, "cache"), cmd.Duration("templates-ttl"), cmd.Bool("refresh"))
+}
+
 func listTemplates(ctx context.Context, cmd *cli.Command) error {
-	templates, err := bootstrap.FetchTemplates(ctx)
+	templates, err := fetchTemplates(ctx, cmd)
 	if err != nil {
 		return err
 	}
 
+	if cmd.Bool("json") && cmd.Bool("yaml") {
+		return errors.New("only one of --json or --yaml can be set")
+	}
 	if cmd.Bool("json") {
 		util.PrintJSON(templates)
+	} else if cmd.Bool("yaml") {
+		out, err := yaml.Marshal(templates)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(out))
 	} else {
 		const maxDescLength = 64
 		table := util.CreateTable().Headers("Template", "Description").BorderRow(true)
@@ -212,8 +403,18 @@ func listTemplates(ctx context.Context, cmd *cli.Command) error {
 }
 
 func setupTemplate(ctx context.Context, cmd *cli.Command) error {
-	verbose := cmd.Bool("verbose")
-	install := cmd.Bool("install")
+	if cmd.Bool("resume") {
+		return resumeApp(ctx, cmd)
+	}
+
+	verbose := debugEnabled()
+	if cmd.Bool("choose-sandbox") && sandboxID == "" {
+		id, err := selectSandbox(ctx, cmd)
+		if err != nil {
+			return err
+		}
+		sandboxID = id
+	}
 	isSandbox := sandboxID != ""
 
 	var preinstallPrompts []huh.Field
@@ -222,6 +423,9 @@ func setupTemplate(ctx context.Context, cmd *cli.Command) error {
 	if templateName != "" && templateURL != "" {
 		return errors.New("only one of template or template-url can be specified")
 	}
+	if isSandbox && cmd.Bool("mirror") {
+		return validationErrorf("--mirror cannot be used with --sandbox")
+	}
 
 	if isSandbox {
 		token, err := requireToken(ctx, cmd)
@@ -238,9 +442,9 @@ func setupTemplate(ctx context.Context, cmd *cli.Command) error {
 			}
 			templateOptions = details.ChildTemplates
 		}
-	} else {
+	} else if templateURL == "" {
 		var err error
-		templateOptions, err = bootstrap.FetchTemplates(ctx)
+		templateOptions, err = fetchTemplates(ctx, cmd)
 		if err != nil {
 			return err
 		}
@@ -274,6 +478,36 @@ func setupTemplate(ctx context.Context, cmd *cli.Command) error {
 		}
 	}
 
+	if cmd.IsSet("template-url") {
+		stderr, err := bootstrap.ValidateTemplateURL(ctx, templateURL, templateRef)
+		if err != nil {
+			if verbose && stderr != "" {
+				fmt.Fprintln(os.Stderr, stderr)
+			}
+			return fmt.Errorf("%w, run `lk app list-templates` to see available templates", err)
+		}
+	}
+
+	if cmd.Bool("show-inputs") {
+		if templateURL == "" {
+			return validationErrorf("--show-inputs requires --template or --template-url")
+		}
+		return showTemplateInputs(ctx, cmd, templateURL)
+	}
+
+	outputDir := cmd.String("output-dir")
+	if outputDir != "" {
+		info, err := os.Stat(outputDir)
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("output directory %s does not exist", outputDir)
+		} else if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("output directory %s is not a directory", outputDir)
+		}
+	}
+
 	appName = cmd.Args().First()
 	if appName == "" {
 		appName = sandboxID
@@ -288,7 +522,10 @@ func setupTemplate(ctx context.Context, cmd *cli.Command) error {
 				if !appNameRegex.MatchString(s) {
 					return errors.New("try a simpler name")
 				}
-				if s, _ := os.Stat(s); s != nil {
+				if err := checkPartialApp(filepath.Join(outputDir, s)); err != nil {
+					return err
+				}
+				if s, _ := os.Stat(filepath.Join(outputDir, s)); s != nil {
 					return errors.New("that name is in use")
 				}
 				return nil
@@ -296,6 +533,9 @@ func setupTemplate(ctx context.Context, cmd *cli.Command) error {
 			WithTheme(util.Theme))
 	}
 
+	if len(preinstallPrompts) > 0 && cmd.Bool("non-interactive") {
+		return validationErrorf("--non-interactive requires --template or --template-url, and an app name")
+	}
 	if len(preinstallPrompts) > 0 {
 		group := huh.NewGroup(preinstallPrompts...)
 		if err := huh.NewForm(group).
@@ -305,21 +545,528 @@ func setupTemplate(ctx context.Context, cmd *cli.Command) error {
 		}
 	}
 
-	fmt.Println("Cloning template...")
-	if err := cloneTemplate(ctx, cmd, templateURL, appName); err != nil {
+	appDir := filepath.Join(outputDir, appName)
+
+	if cmd.Bool("dry-run") {
+		return printCreatePlan(ctx, cmd, appDir)
+	}
+
+	if cmd.Bool("mirror") {
+		fmt.Fprintln(progressWriter(cmd), "Mirroring template...")
+		if err := cloneTemplate(ctx, cmd, templateURL, appDir); err != nil {
+			return err
+		}
+		fmt.Println("Mirrored template to", util.Theme.Focused.Title.Render(appDir))
+		fmt.Println("Skipped environment instantiation and post-create tasks")
+		return nil
+	}
+
+	return createApp(ctx, cmd, appName, appDir, &bootstrap.CreateState{
+		TemplateURL: templateURL,
+		TemplateRef: templateRef,
+		SandboxID:   sandboxID,
+	})
+}
+
+// checkPartialApp fails when appDir was left partially created by an earlier
+// run, which only --resume continues.
+func checkPartialApp(appDir string) error {
+	state, err := bootstrap.ReadCreateState(appDir)
+	if err != nil {
+		return err
+	}
+	if state != nil {
+		return fmt.Errorf("%s was partially created, run again with --resume to continue, or remove it", appDir)
+	}
+	return nil
+}
+
+// resumeApp continues creating an app from the last phase recorded in its
+// directory by a failed run.
+func resumeApp(ctx context.Context, cmd *cli.Command) error {
+	appName = cmd.Args().First()
+	if appName == "" {
+		return validationErrorf("--resume requires the APP_NAME to resume")
+	}
+	if templateName != "" || templateURL != "" {
+		return validationErrorf("--resume uses the template of the first run, --template and --template-url cannot be set")
+	}
+	appDir := filepath.Join(cmd.String("output-dir"), appName)
+	state, err := bootstrap.ReadCreateState(appDir)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return fmt.Errorf("nothing to resume, %s was not partially created", appDir)
+	}
+	templateURL, templateRef = state.TemplateURL, state.TemplateRef
+	if sandboxID == "" {
+		sandboxID = state.SandboxID
+	}
+	return createApp(ctx, cmd, appName, appDir, state)
+}
+
+// createApp runs the phases of creating an app from a template that state
+// doesn't record as done, recording each one in the app directory.
+func createApp(ctx context.Context, cmd *cli.Command, appName, appDir string, state *bootstrap.CreateState) error {
+	verbose := debugEnabled()
+	install := cmd.Bool("install")
+
+	// clone, instantiate, and clean up always run, with one more step for
+	// install or post-create when the template defines it
+	steps := &stepCounter{total: 4}
+	if state.Done(bootstrap.CreatePhaseCloned) {
+		steps.Skip("Cloning template...")
+	} else {
+		steps.Println("Cloning template...")
+		if err := cloneTemplate(ctx, cmd, state.TemplateURL, appDir); err != nil {
+			return err
+		}
+		// remember the template so `lk app update` can pull its later changes
+		commit, err := bootstrap.GitHeadCommit(appDir)
+		if err != nil {
+			return err
+		}
+		origin := &bootstrap.TemplateOrigin{URL: state.TemplateURL, Ref: state.TemplateRef, Commit: commit}
+		if err := origin.Write(appDir); err != nil {
+			return err
+		}
+		if err := state.Complete(appDir, bootstrap.CreatePhaseCloned); err != nil {
+			return err
+		}
+	}
+
+	tf, err := bootstrap.ParseTaskfile(appDir)
+	if err != nil {
+		return err
+	}
+	hasPostCreate := false
+	if tf != nil {
+		_, hasPostCreate = tf.Tasks.Get(string(bootstrap.TaskPostCreate))
+	}
+	if !install && !hasPostCreate {
+		steps.total--
+	}
+
+	manifest, err := bootstrap.ParseTemplateManifest(appDir)
+	if err != nil {
+		return err
+	}
+	if manifest != nil {
+		steps.total++
+		if state.Done(bootstrap.CreatePhaseSubstituted) {
+			steps.Skip("Substituting template variables...")
+		} else {
+			steps.Println("Substituting template variables...")
+			changed, variables, err := substituteTemplate(cmd, manifest, appDir, map[string]string{"APP_NAME": appName})
+			if err != nil {
+				return err
+			}
+			if verbose {
+				for _, f := range changed {
+					fmt.Println("  updated", f)
+				}
+			}
+			origin, err := bootstrap.ReadTemplateOrigin(appDir)
+			if err != nil {
+				return err
+			}
+			if origin != nil {
+				origin.Variables = variables
+				if err := origin.Write(appDir); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if !state.Done(bootstrap.CreatePhaseSubstituted) {
+		if err := state.Complete(appDir, bootstrap.CreatePhaseSubstituted); err != nil {
+			return err
+		}
+	}
+
+	if state.Done(bootstrap.CreatePhaseEnv) {
+		steps.Skip("Instantiating environment...")
+	} else {
+		steps.Println("Instantiating environment...")
+		addlEnv := &map[string]string{
+			"LIVEKIT_SANDBOX_ID":             state.SandboxID,
+			"NEXT_PUBLIC_LIVEKIT_SANDBOX_ID": state.SandboxID,
+		}
+		envOutputFile, envExampleFile := envFilesFromTaskfile(tf)
+		env, err := instantiateEnv(ctx, cmd, appDir, addlEnv, envExampleFile)
+		if err != nil {
+			return err
+		}
+		if err := bootstrap.WriteDotEnv(appDir, envOutputFile, env); err != nil {
+			return err
+		}
+		if err := state.Complete(appDir, bootstrap.CreatePhaseEnv); err != nil {
+			return err
+		}
+	}
+
+	if install || hasPostCreate {
+		msg := "Running post-create tasks..."
+		if install {
+			msg = "Installing template..."
+		}
+		if state.Done(bootstrap.CreatePhaseInstalled) {
+			steps.Skip(msg)
+		} else {
+			steps.Println(msg)
+			if install {
+				err = doInstall(ctx, bootstrap.TaskInstall, appDir, verbose)
+			} else {
+				err = doPostCreate(ctx, cmd, appDir, verbose)
+			}
+			if err != nil {
+				return err
+			}
+			if err := state.Complete(appDir, bootstrap.CreatePhaseInstalled); err != nil {
+				return err
+			}
+		}
+	}
+
+	steps.Println("Cleaning up...")
+	return cleanupTemplate(ctx, cmd, appDir)
+}
+
+// TemplateUpdate is the result of `lk app update`.
+type TemplateUpdate struct {
+	URL     string                         `json:"url"`
+	From    string                         `json:"from,omitempty"`
+	To      string                         `json:"to"`
+	Changes []bootstrap.TemplateFileChange `json:"changes"`
+	Applied bool                           `json:"applied"`
+}
+
+func updateApp(ctx context.Context, cmd *cli.Command) error {
+	appDir := cmd.Args().First()
+	if appDir == "" {
+		appDir = "."
+	}
+	origin, err := bootstrap.ReadTemplateOrigin(appDir)
+	if err != nil {
 		return err
 	}
+	if origin == nil {
+		return fmt.Errorf("no %s in %s, only apps created with `lk app create` can be updated", bootstrap.TemplateOriginFile, appDir)
+	}
+	ref := origin.Ref
+	if cmd.IsSet("template-ref") {
+		ref = cmd.String("template-ref")
+	}
+	// the project is optional, only used for placeholders of its credentials
+	if project == nil {
+		project, _ = loadProjectDetails(cmd, quietLoad)
+	}
 
-	tf, err := bootstrap.ParseTaskfile(appName)
+	upstreamDir, err := os.MkdirTemp("", "lk-template-")
 	if err != nil {
 		return err
 	}
+	defer os.RemoveAll(upstreamDir)
+	var cloneErr error
+	s := spinner.New().Style(util.Theme.Focused.Title)
+	if err := runSpinner(s, "Fetching template from "+origin.URL, func() {
+		_, _, cloneErr = bootstrap.CloneTemplateRef(origin.URL, upstreamDir, ref, 1)
+	}); err != nil {
+		return err
+	}
+	if cloneErr != nil {
+		return cloneErr
+	}
+	commit, err := bootstrap.GitHeadCommit(upstreamDir)
+	if err != nil {
+		return err
+	}
+	res := &TemplateUpdate{URL: origin.URL, From: origin.Commit, To: commit}
+
+	// the template at the commit the app was created from is the base for
+	// telling template changes apart from local ones
+	var baseDir string
+	if origin.Commit != "" && origin.Commit != commit {
+		baseDir, err = os.MkdirTemp("", "lk-template-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(baseDir)
+		if _, _, err := bootstrap.CloneTemplateRef(origin.URL, baseDir, origin.Commit, 1); err != nil {
+			warnf("WARNING: could not fetch the template commit the app was created from, changed files can't be merged: %v", err)
+			baseDir = ""
+		}
+	}
+
+	if origin.Commit != commit {
+		for _, dir := range []string{baseDir, upstreamDir} {
+			if dir == "" {
+				continue
+			}
+			manifest, err := bootstrap.ParseTemplateManifest(dir)
+			if err != nil {
+				return err
+			}
+			if manifest == nil {
+				continue
+			}
+			if _, origin.Variables, err = substituteTemplate(cmd, manifest, dir, origin.Variables); err != nil {
+				return err
+			}
+		}
+		if res.Changes, err = bootstrap.DiffTemplate(appDir, baseDir, upstreamDir); err != nil {
+			return err
+		}
+	}
+
+	if !cmd.Bool("dry-run") && origin.Commit != commit {
+		for i, change := range res.Changes {
+			switch change.Status {
+			case bootstrap.TemplateFileAdded, bootstrap.TemplateFileUpdated:
+				err = bootstrap.CopyTemplateFile(appDir, upstreamDir, change.Path)
+			case bootstrap.TemplateFileDiverged:
+				if baseDir == "" {
+					continue
+				}
+				var conflict bool
+				if conflict, err = bootstrap.MergeTemplateFile(appDir, baseDir, upstreamDir, change.Path); conflict {
+					res.Changes[i].Status = bootstrap.TemplateFileConflict
+				} else if err == nil {
+					res.Changes[i].Status = bootstrap.TemplateFileMerged
+				}
+			}
+			if err != nil {
+				return err
+			}
+		}
+		origin.Commit = commit
+		origin.Ref = ref
+		if err := origin.Write(appDir); err != nil {
+			return err
+		}
+		res.Applied = true
+	}
+
+	if cmd.Bool("json") {
+		util.PrintJSON(res)
+		return nil
+	}
+	if len(res.Changes) == 0 {
+		fmt.Println("App is up to date with template commit", commit)
+		return nil
+	}
+	table := util.CreateTable().Headers("Status", "File")
+	for _, change := range res.Changes {
+		table.Row(string(change.Status), change.Path)
+	}
+	fmt.Println(table)
+	if !res.Applied {
+		fmt.Println("Dry run, app was not updated")
+	} else if slices.ContainsFunc(res.Changes, func(c bootstrap.TemplateFileChange) bool {
+		return c.Status == bootstrap.TemplateFileConflict || c.Status == bootstrap.TemplateFileDiverged
+	}) {
+		fmt.Println("Resolve conflicts and diverged files, then review the changes before committing them")
+	}
+	return nil
+}
+
+// DoctorCheck is a check made by `lk app doctor`.
+type DoctorCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+	Fix    string `json:"fix,omitempty"`
+	// failing critical checks make the command fail
+	Critical bool `json:"critical"`
+}
+
+func diagnoseApp(ctx context.Context, cmd *cli.Command) error {
+	appDir := cmd.Args().First()
+	if appDir == "" {
+		appDir = "."
+	}
+
+	var checks []DoctorCheck
+	check := func(c DoctorCheck) {
+		checks = append(checks, c)
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		check(DoctorCheck{Name: "git", Critical: true, Detail: "not found on the PATH", Fix: "install git, used to clone and update templates"})
+	} else {
+		check(DoctorCheck{Name: "git", Critical: true, OK: true})
+	}
+
+	tf, err := bootstrap.ParseTaskfile(appDir)
+	switch {
+	case err != nil:
+		check(DoctorCheck{Name: bootstrap.TaskFile, Critical: true, Detail: err.Error(), Fix: "fix the syntax of " + bootstrap.TaskFile})
+	case tf == nil:
+		check(DoctorCheck{Name: bootstrap.TaskFile, Detail: "not found, the app has no tasks to run", Fix: "run this in the directory of an app created with `lk app create`"})
+	default:
+		check(DoctorCheck{Name: bootstrap.TaskFile, Critical: true, OK: true, Detail: "defines " + strings.Join(tf.Tasks.Keys(), ", ")})
+		for _, program := range bootstrap.TaskPrograms(tf) {
+			c := DoctorCheck{Name: program, Critical: true, OK: bootstrap.CommandExists(program)}
+			if !c.OK {
+				c.Detail = "not found on the PATH"
+				c.Fix = "install " + program + ", which tasks in " + bootstrap.TaskFile + " run"
+			}
+			check(c)
+		}
+	}
+
+	check(diagnoseEnv(appDir, tf))
+	check(diagnoseCredentials(ctx, cmd))
 
-	fmt.Println("Instantiating environment...")
-	addlEnv := &map[string]string{
-		"LIVEKIT_SANDBOX_ID":             sandboxID,
-		"NEXT_PUBLIC_LIVEKIT_SANDBOX_ID": sandboxID,
+	failed := 0
+	for _, c := range checks {
+		if !c.OK && c.Critical {
+			failed++
+		}
 	}
+	if cmd.Bool("json") {
+		util.PrintJSON(checks)
+	} else {
+		for _, c := range checks {
+			status := "pass"
+			if !c.OK && c.Critical {
+				status = "fail"
+			} else if !c.OK {
+				status = "warn"
+			}
+			line := fmt.Sprintf("[%s] %s", status, c.Name)
+			if c.Detail != "" {
+				line += ": " + c.Detail
+			}
+			fmt.Println(line)
+			if !c.OK && c.Fix != "" {
+				fmt.Println("       fix: " + c.Fix)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d critical checks failed", failed)
+	}
+	return nil
+}
+
+// diagnoseEnv checks that the keys of the app's env example are set in its
+// env file.
+func diagnoseEnv(appDir string, tf *ast.Taskfile) DoctorCheck {
+	envOutputFile, envExampleFile := envFilesFromTaskfile(tf)
+	c := DoctorCheck{Name: envOutputFile, Critical: true}
+	exampleKeys, _, err := bootstrap.ReadDotEnv(filepath.Join(appDir, envExampleFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		c.OK = true
+		c.Detail = "no " + envExampleFile + " listing required keys"
+		return c
+	} else if err != nil {
+		c.Detail = err.Error()
+		c.Fix = "fix the syntax of " + envExampleFile
+		return c
+	}
+
+	_, env, err := bootstrap.ReadDotEnv(filepath.Join(appDir, envOutputFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		c.Detail = err.Error()
+		c.Fix = "fix the syntax of " + envOutputFile
+		return c
+	}
+	var missing []string
+	for _, k := range exampleKeys {
+		if env[k] == "" {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) > 0 {
+		c.Detail = "missing " + strings.Join(missing, ", ")
+		c.Fix = "run `lk app env -w -d " + envOutputFile + "`, or set them with `lk app env set KEY=VALUE`"
+		return c
+	}
+	c.OK = true
+	c.Detail = fmt.Sprintf("%d keys set", len(exampleKeys))
+	return c
+}
+
+// diagnoseCredentials checks that the project's credentials are accepted by
+// its server.
+func diagnoseCredentials(ctx context.Context, cmd *cli.Command) DoctorCheck {
+	c := DoctorCheck{Name: "project credentials", Critical: true}
+	p, err := loadProjectDetails(cmd, quietLoad)
+	if err != nil {
+		c.Detail = err.Error()
+		c.Fix = "run `lk cloud auth` or `lk project add`, or pass --project"
+		return c
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	res, err := testProjectCredentials(ctx, p, false)
+	if err != nil {
+		c.Detail = err.Error()
+		c.Fix = "check the project URL " + p.URL + " and your connection"
+		return c
+	}
+	if !res.OK {
+		c.Detail = res.Status
+		c.Fix = "update the project's API key and secret, or run `lk cloud auth`"
+		return c
+	}
+	c.OK = true
+	c.Detail = fmt.Sprintf("%s, %dms", p.URL, res.LatencyMs)
+	return c
+}
+
+// substituteTemplate replaces the placeholders of the template in dir with
+// variables and the project's credentials, prompting for other variables of
+// the manifest. It returns the changed files, and variables with the prompted
+// values added, which are recorded in the app's template origin.
+func substituteTemplate(cmd *cli.Command, manifest *bootstrap.TemplateManifest, dir string, variables map[string]string) ([]string, map[string]string, error) {
+	answer, err := answerPrompt(cmd, huh.EchoModeNormal)
+	if err != nil {
+		return nil, nil, err
+	}
+	recorded := maps.Clone(variables)
+	prompt := func(key, value string) (string, error) {
+		value, err := answer(key, value)
+		recorded[key] = value
+		return value, err
+	}
+
+	values := maps.Clone(variables)
+	if project != nil {
+		// not recorded, to keep credentials out of the app
+		values["LIVEKIT_URL"] = project.URL
+		values["LIVEKIT_API_KEY"] = project.APIKey
+	}
+	changed, err := manifest.Substitute(dir, values, prompt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return changed, recorded, nil
+}
+
+// stepCounter prefixes progress messages with the current and total step.
+type stepCounter struct {
+	current int
+	total   int
+}
+
+func (s *stepCounter) Println(msg string) {
+	s.current++
+	if !quietOutput {
+		fmt.Printf("[%d/%d] %s\n", s.current, s.total, msg)
+	}
+}
+
+// Skip counts a step completed by a previous run.
+func (s *stepCounter) Skip(msg string) {
+	s.Println(strings.TrimSuffix(msg, "...") + ", already done")
+}
+
+// envFilesFromTaskfile returns the env output and example file paths for a
+// template, which may be overridden by its taskfile vars.
+func envFilesFromTaskfile(tf *ast.Taskfile) (string, string) {
 	envOutputFile := ".env.local"
 	envExampleFile := ".env.example"
 	if tf != nil {
@@ -334,25 +1081,182 @@ func setupTemplate(ctx context.Context, cmd *cli.Command) error {
 			}
 		}
 	}
-	env, err := instantiateEnv(ctx, cmd, appName, addlEnv, envExampleFile)
+	return envOutputFile, envExampleFile
+}
+
+func showTemplateInputs(_ context.Context, cmd *cli.Command, url string) error {
+	tempName, _, cleanup := util.UseTempPath("")
+	defer cleanup()
+
+	stdout, stderr, err := bootstrap.CloneTemplateRef(url, tempName, templateRef, 1)
+	if len(stdout) > 0 {
+		debugf("%s", stdout)
+	}
+	if len(stderr) > 0 {
+		debugf("%s", stderr)
+	}
 	if err != nil {
 		return err
 	}
 
-	bootstrap.WriteDotEnv(appName, envOutputFile, env)
+	tf, err := bootstrap.ParseTaskfile(tempName)
+	if err != nil {
+		return err
+	}
+	_, envExampleFile := envFilesFromTaskfile(tf)
+	// these are filled in from project credentials and never prompted for
+	substitutions := map[string]string{
+		"LIVEKIT_API_KEY":                "",
+		"LIVEKIT_API_SECRET":             "",
+		"LIVEKIT_URL":                    "",
+		"NEXT_PUBLIC_LIVEKIT_URL":        "",
+		"LIVEKIT_SANDBOX_ID":             "",
+		"NEXT_PUBLIC_LIVEKIT_SANDBOX_ID": "",
+	}
+	inputs, err := bootstrap.ListTemplateInputs(tempName, tf, envExampleFile, substitutions)
+	if err != nil {
+		return err
+	}
 
-	if install {
-		fmt.Println("Installing template...")
-		if err := doInstall(ctx, bootstrap.TaskInstall, appName, verbose); err != nil {
-			return err
+	if cmd.Bool("json") {
+		if inputs == nil {
+			inputs = []bootstrap.TemplateInput{}
 		}
+		util.PrintJSON(inputs)
+	} else if len(inputs) == 0 {
+		fmt.Println("This template does not prompt for any inputs")
 	} else {
-		if err := doPostCreate(ctx, cmd, appName, verbose); err != nil {
-			return err
+		table := util.CreateTable().Headers("Name", "Default", "Options", "Source")
+		for _, in := range inputs {
+			table.Row(in.Name, in.Default, strings.Join(in.Options, ", "), in.Source)
 		}
+		fmt.Println(table)
 	}
+	return nil
+}
+
+// templateCloneDepth returns the number of commits of the template to clone,
+// or 0 to clone its full history.
+func templateCloneDepth(cmd *cli.Command) (int, error) {
+	if cmd.Bool("full-history") || cmd.Bool("mirror") {
+		return 0, nil
+	}
+	depth := int(cmd.Int("git-depth"))
+	if depth < 1 {
+		return 0, validationErrorf("--git-depth must be at least 1, use --full-history to clone all commits")
+	}
+	return depth, nil
+}
+
+// envKeysFromProject are filled in from the project and sandbox when
+// instantiating a template's env, without prompting.
+var envKeysFromProject = []string{
+	"LIVEKIT_API_KEY",
+	"LIVEKIT_API_SECRET",
+	"LIVEKIT_URL",
+	"NEXT_PUBLIC_LIVEKIT_URL",
+	"LIVEKIT_SANDBOX_ID",
+	"NEXT_PUBLIC_LIVEKIT_SANDBOX_ID",
+}
+
+// CreatePlan describes what app create would do, printed by --dry-run.
+type CreatePlan struct {
+	TemplateURL  string `json:"templateUrl"`
+	TemplateRef  string `json:"templateRef,omitempty"`
+	CloneCommand string `json:"cloneCommand"`
+	AppDir       string `json:"appDir"`
+	// keys of the template's env, and those that would be prompted for
+	EnvKeys         []string `json:"envKeys"`
+	PromptedEnvKeys []string `json:"promptedEnvKeys"`
+	// why the env keys could not be determined
+	EnvNote string `json:"envNote,omitempty"`
+}
 
-	return cleanupTemplate(ctx, cmd, appName)
+// printCreatePlan prints what app create would do, fetching the template's
+// env example over HTTP instead of cloning it.
+func printCreatePlan(ctx context.Context, cmd *cli.Command, appDir string) error {
+	depth, err := templateCloneDepth(cmd)
+	if err != nil {
+		return err
+	}
+	absDir, err := filepath.Abs(appDir)
+	if err != nil {
+		return err
+	}
+	var cloneCommands []string
+	for _, args := range bootstrap.CloneTemplateCommands(templateURL, absDir, templateRef, depth) {
+		cloneCommands = append(cloneCommands, "git "+strings.Join(args, " "))
+	}
+	plan := &CreatePlan{
+		TemplateURL:     templateURL,
+		TemplateRef:     templateRef,
+		CloneCommand:    strings.Join(cloneCommands, " && "),
+		AppDir:          absDir,
+		EnvKeys:         []string{},
+		PromptedEnvKeys: []string{},
+	}
+
+	if cmd.Bool("mirror") {
+		plan.EnvNote = "environment is not instantiated with --mirror"
+	} else if err := planTemplateEnv(ctx, cmd, plan); err != nil {
+		plan.EnvNote = err.Error()
+	}
+
+	if cmd.Bool("json") {
+		util.PrintJSON(plan)
+		return nil
+	}
+	fmt.Println("Template:     ", plan.TemplateURL)
+	if plan.TemplateRef != "" {
+		fmt.Println("Template ref: ", plan.TemplateRef)
+	}
+	fmt.Println("Clone command:", plan.CloneCommand)
+	fmt.Println("App directory:", plan.AppDir)
+	if plan.EnvNote != "" {
+		fmt.Println("Environment:  ", plan.EnvNote)
+	} else {
+		fmt.Println("Environment:  ", strings.Join(plan.EnvKeys, ", "))
+		if len(plan.PromptedEnvKeys) > 0 {
+			fmt.Println("Prompts for:  ", strings.Join(plan.PromptedEnvKeys, ", "))
+		}
+	}
+	fmt.Println("Dry run, app was not created")
+	return nil
+}
+
+// planTemplateEnv fills in the env keys of plan from the template's env
+// example, honoring a custom example file named by its taskfile.
+func planTemplateEnv(ctx context.Context, cmd *cli.Command, plan *CreatePlan) error {
+	var tf *ast.Taskfile
+	content, err := bootstrap.FetchTemplateFile(ctx, templateURL, templateRef, bootstrap.TaskFile)
+	if err == nil {
+		tf = &ast.Taskfile{}
+		if err := yaml.Unmarshal(content, tf); err != nil {
+			return fmt.Errorf("could not parse %s: %w", bootstrap.TaskFile, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	_, envExampleFile := envFilesFromTaskfile(tf)
+
+	content, err = bootstrap.FetchTemplateFile(ctx, templateURL, templateRef, envExampleFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		plan.EnvKeys = slices.Clone(envKeysFromProject)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	answers, err := bootstrap.ReadEnvFiles(cmd.StringSlice("env-file"))
+	if err != nil {
+		return err
+	}
+	for _, key := range bootstrap.DotEnvKeys(content) {
+		plan.EnvKeys = append(plan.EnvKeys, key)
+		if _, ok := answers[key]; !ok && !slices.Contains(envKeysFromProject, key) {
+			plan.PromptedEnvKeys = append(plan.PromptedEnvKeys, key)
+		}
+	}
+	return nil
 }
 
 func cloneTemplate(_ context.Context, cmd *cli.Command, url, appName string) error {
@@ -360,24 +1264,26 @@ func cloneTemplate(_ context.Context, cmd *cli.Command, url, appName string) err
 	var stderr string
 	var cmdErr error
 
+	depth, err := templateCloneDepth(cmd)
+	if err != nil {
+		return err
+	}
+
 	tempName, relocate, cleanup := util.UseTempPath(appName)
 	defer cleanup()
 
-	if err := spinner.New().
-		Title("Cloning template from " + url).
-		Action(func() {
-			stdout, stderr, cmdErr = bootstrap.CloneTemplate(url, tempName)
-		}).
-		Style(util.Theme.Focused.Title).
-		Run(); err != nil {
+	s := spinner.New().Style(util.Theme.Focused.Title)
+	if err := runSpinner(s, "Cloning template from "+url, func() {
+		stdout, stderr, cmdErr = bootstrap.CloneTemplateRef(url, tempName, templateRef, depth)
+	}); err != nil {
 		return err
 	}
 
-	if len(stdout) > 0 && cmd.Bool("verbose") {
-		fmt.Println(string(stdout))
+	if len(stdout) > 0 {
+		debugf("%s", stdout)
 	}
-	if len(stderr) > 0 && cmd.Bool("verbose") {
-		fmt.Fprintln(os.Stderr, string(stderr))
+	if len(stderr) > 0 {
+		debugf("%s", stderr)
 	}
 
 	if cmdErr != nil {
@@ -387,6 +1293,9 @@ func cloneTemplate(_ context.Context, cmd *cli.Command, url, appName string) err
 }
 
 func cleanupTemplate(ctx context.Context, cmd *cli.Command, appName string) error {
+	if cmd.Bool("full-history") && !cmd.Bool("strip-git") {
+		return bootstrap.CleanupTemplate(appName, ".git")
+	}
 	return bootstrap.CleanupTemplate(appName)
 }
 
@@ -395,6 +1304,9 @@ func manageEnv(ctx context.Context, cmd *cli.Command) error {
 	if rootDir == "" {
 		rootDir = "."
 	}
+	if _, err := requireProject(ctx, cmd); err != nil {
+		return err
+	}
 
 	env, err := instantiateEnv(ctx, cmd, rootDir, nil, exampleFile)
 	if err != nil {
@@ -408,6 +1320,84 @@ func manageEnv(ctx context.Context, cmd *cli.Command) error {
 	}
 }
 
+// appEnvFile returns the path of the env file of the app in --dir, as named
+// by its taskfile.
+func appEnvFile(cmd *cli.Command) (string, error) {
+	rootDir := cmd.String("dir")
+	tf, err := bootstrap.ParseTaskfile(rootDir)
+	if err != nil {
+		return "", err
+	}
+	envOutputFile, _ := envFilesFromTaskfile(tf)
+	return filepath.Join(rootDir, envOutputFile), nil
+}
+
+func getAppEnv(ctx context.Context, cmd *cli.Command) error {
+	key := cmd.Args().First()
+	if key == "" {
+		return errors.New("KEY is required")
+	}
+	file, err := appEnvFile(cmd)
+	if err != nil {
+		return err
+	}
+	_, env, err := bootstrap.ReadDotEnv(file)
+	if err != nil {
+		return err
+	}
+	value, ok := env[key]
+	if !ok {
+		return fmt.Errorf("%s is not set in %s", key, file)
+	}
+	fmt.Println(value)
+	return nil
+}
+
+func setAppEnv(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() == 0 {
+		return errors.New("at least one KEY=VALUE is required")
+	}
+	values, err := bootstrap.ParseEnvAssignments(cmd.Args().Slice())
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, cmd.Args().Len())
+	for _, a := range cmd.Args().Slice() {
+		k, _, _ := strings.Cut(a, "=")
+		keys = append(keys, k)
+	}
+	file, err := appEnvFile(cmd)
+	if err != nil {
+		return err
+	}
+	if err := bootstrap.SetDotEnv(file, keys, values); err != nil {
+		return err
+	}
+	fmt.Fprintf(progressWriter(cmd), "Updated %s\n", file)
+	return nil
+}
+
+func listAppEnv(ctx context.Context, cmd *cli.Command) error {
+	file, err := appEnvFile(cmd)
+	if err != nil {
+		return err
+	}
+	keys, env, err := bootstrap.ReadDotEnv(file)
+	if err != nil {
+		return err
+	}
+	if cmd.Bool("json") {
+		util.PrintJSON(env)
+		return nil
+	}
+	table := util.CreateTable().Headers("Key", "Value")
+	for _, k := range keys {
+		table.Row(k, env[k])
+	}
+	fmt.Println(table)
+	return nil
+}
+
 func instantiateEnv(ctx context.Context, cmd *cli.Command, rootPath string, addlEnv *map[string]string, exampleFile string) (map[string]string, error) {
 	env := map[string]string{
 		"LIVEKIT_API_KEY":         project.APIKey,
@@ -421,10 +1411,36 @@ func instantiateEnv(ctx context.Context, cmd *cli.Command, rootPath string, addl
 		}
 	}
 
-	prompt := func(key, oldValue string) (string, error) {
+	prompt, err := answerPrompt(cmd, huh.EchoModePassword)
+	if err != nil {
+		return nil, err
+	}
+
+	return bootstrap.InstantiateDotEnv(ctx, rootPath, exampleFile, env, debugEnabled(), prompt)
+}
+
+// answerPrompt returns a prompt for template values which answers from
+// --env-file, then asks the user, or keeps the default with --non-interactive.
+func answerPrompt(cmd *cli.Command, echoMode huh.EchoMode) (bootstrap.PromptFunc, error) {
+	answers, err := bootstrap.ReadEnvFiles(cmd.StringSlice("env-file"))
+	if err != nil {
+		return nil, err
+	}
+	nonInteractive := cmd.Bool("non-interactive")
+
+	return func(key, oldValue string) (string, error) {
+		if value, ok := answers[key]; ok {
+			return value, nil
+		}
+		if nonInteractive {
+			if oldValue == "" {
+				return "", fmt.Errorf("no value for %s, set it in --env-file", key)
+			}
+			return oldValue, nil
+		}
 		var newValue string
 		if err := huh.NewInput().
-			EchoMode(huh.EchoModePassword).
+			EchoMode(echoMode).
 			Title("Enter " + key + "?").
 			Placeholder(oldValue).
 			Value(&newValue).
@@ -433,13 +1449,47 @@ func instantiateEnv(ctx context.Context, cmd *cli.Command, rootPath string, addl
 			return oldValue, err
 		}
 		return newValue, nil
-	}
+	}, nil
+}
+
+var appDirFlag = &cli.StringFlag{
+	Name:      "dir",
+	Usage:     "`DIR` of the app (default: current directory)",
+	Value:     ".",
+	TakesFile: true,
+}
+
+var refreshTemplatesFlag = &cli.BoolFlag{
+	Name:  "refresh",
+	Usage: "Fetch the list of templates, ignoring the cached copy",
+}
+
+var templatesTTLFlag = &cli.DurationFlag{
+	Name:    "templates-ttl",
+	Usage:   "Use the cached list of templates for `DURATION` after fetching it, 0 to always fetch it",
+	Value:   time.Hour,
+	Sources: cli.EnvVars("LK_TEMPLATES_TTL"),
+}
 
-	return bootstrap.InstantiateDotEnv(ctx, rootPath, exampleFile, env, cmd.Bool("verbose"), prompt)
+var chooseProjectFlag = &cli.BoolFlag{
+	Name:  "choose-project",
+	Usage: "Select the project to use even if a default is set, making the selection the new default",
+}
+
+var packageManagerFlag = &cli.StringFlag{
+	Name:  "package-manager",
+	Usage: "Package manager `TOOL` for template tasks to use, one of " + strings.Join(util.MapStrings(bootstrap.PackageManagers, util.WrapWith("\"")), ", ") + ", passed to them as " + bootstrap.EnvPackageManager,
+	Action: func(ctx context.Context, cmd *cli.Command, pm string) error {
+		if !slices.Contains(bootstrap.PackageManagers, pm) {
+			return fmt.Errorf("unsupported package manager %q, must be one of: %s", pm, strings.Join(bootstrap.PackageManagers, ", "))
+		}
+		// tasks inherit the environment of the CLI
+		return os.Setenv(bootstrap.EnvPackageManager, pm)
+	},
 }
 
 func installTemplate(ctx context.Context, cmd *cli.Command) error {
-	verbose := cmd.Bool("verbose")
+	verbose := debugEnabled()
 	rootPath := cmd.Args().First()
 	if rootPath == "" {
 		rootPath = "."
@@ -456,22 +1506,12 @@ func doPostCreate(ctx context.Context, _ *cli.Command, rootPath string, verbose
 		return nil
 	}
 
-	task, err := bootstrap.NewTask(ctx, tf, rootPath, string(bootstrap.TaskPostCreate), verbose)
+	progress := newTaskProgress("Cleaning up...", verbose)
+	task, err := bootstrap.NewTaskWithOutput(ctx, tf, rootPath, string(bootstrap.TaskPostCreate), verbose, progress, progress)
 	if task == nil || err != nil {
 		return nil
 	}
-
-	var cmdErr error
-	if err := spinner.New().
-		Title("Cleaning up...").
-		TitleStyle(lipgloss.NewStyle()).
-		Style(lipgloss.NewStyle()).
-		Action(func() { cmdErr = task() }).
-		Accessible(true).
-		Run(); err != nil {
-		return err
-	}
-	return cmdErr
+	return progress.Run(ctx, task)
 }
 
 func doInstall(ctx context.Context, task bootstrap.KnownTask, rootPath string, verbose bool) error {
@@ -480,32 +1520,71 @@ func doInstall(ctx context.Context, task bootstrap.KnownTask, rootPath string, v
 		return err
 	}
 
-	install, err := bootstrap.NewTask(ctx, tf, rootPath, string(task), verbose)
+	progress := newTaskProgress("Installing...", verbose)
+	install, err := bootstrap.NewTaskWithOutput(ctx, tf, rootPath, string(task), verbose, progress, progress)
 	if err != nil {
 		return err
 	}
+	return progress.Run(ctx, install)
+}
 
-	var cmdErr error
-	if err := spinner.New().
-		Title("Installing...").
-		Action(func() { cmdErr = install() }).
-		Style(util.Theme.Focused.Title).
-		Accessible(true).
-		Run(); err != nil {
+// TaskDescription is a task defined in a project's taskfile.yaml.
+type TaskDescription struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+func listTasks(_ context.Context, cmd *cli.Command) error {
+	rootDir := cmd.Args().First()
+	if rootDir == "" {
+		rootDir = "."
+	}
+	tf, err := bootstrap.ParseTaskfile(rootDir)
+	if err != nil {
 		return err
 	}
-	return cmdErr
+	if tf == nil {
+		return fmt.Errorf("no %s found in %s", bootstrap.TaskFile, rootDir)
+	}
+
+	tasks := make([]TaskDescription, 0, tf.Tasks.Len())
+	for _, name := range tf.Tasks.Keys() {
+		desc := ""
+		if t, ok := tf.Tasks.Get(name); ok && t != nil {
+			desc = t.Desc
+		}
+		tasks = append(tasks, TaskDescription{Name: name, Description: desc})
+	}
+
+	if cmd.Bool("json") {
+		util.PrintJSON(tasks)
+		return nil
+	}
+	table := util.CreateTable().Headers("Task", "Description")
+	for _, t := range tasks {
+		table.Row(t.Name, t.Description)
+	}
+	fmt.Println(table)
+	return nil
 }
 
 func runTask(ctx context.Context, cmd *cli.Command) error {
-	verbose := cmd.Bool("verbose")
+	if cmd.Bool("list") {
+		return listTasks(ctx, cmd)
+	}
+
+	verbose := debugEnabled()
 	rootDir := "."
 	tf, err := bootstrap.ParseTaskfile(rootDir)
 	if err != nil {
 		return err
 	}
 
-	taskName := cmd.Args().First()
+	// the task name may be omitted to select it, e.g. `lk app run -- --watch`
+	taskName, taskArgs := "", cmd.Args().Slice()
+	if len(taskArgs) > 0 && taskArgs[0] != "--" {
+		taskName, taskArgs = taskArgs[0], taskArgs[1:]
+	}
 	if taskName == "" {
 		var options []huh.Option[string]
 		for _, name := range tf.Tasks.Keys() {
@@ -522,17 +1601,33 @@ func runTask(ctx context.Context, cmd *cli.Command) error {
 		}
 	}
 
-	task, err := bootstrap.NewTask(ctx, tf, rootDir, taskName, verbose)
+	// tasks inherit this process's environment, which takes precedence over
+	// their dotenv files, so the app's env file is loaded here to keep it
+	// above variables that happen to be set in the calling shell
+	overrides, err := bootstrap.ParseEnvAssignments(cmd.StringSlice("env"))
+	if err != nil {
+		return err
+	}
+	appEnvFile, _ := envFilesFromTaskfile(tf)
+	env, err := bootstrap.TaskEnv(rootDir, appEnvFile, cmd.StringSlice("env-file"), overrides)
+	if err != nil {
+		return err
+	}
+	for k, v := range env {
+		if err := os.Setenv(k, v); err != nil {
+			return err
+		}
+	}
+
+	task, err := bootstrap.NewTask(ctx, tf, rootDir, taskName, verbose, taskArgs...)
 	if err != nil {
 		return err
 	}
 	var cmdErr error
-	if err := spinner.New().
-		Title("Running task " + taskName + "...").
-		Action(func() { cmdErr = task() }).
+	s := spinner.New().
 		Style(util.Theme.Focused.Title).
-		Accessible(verbose).
-		Run(); err != nil {
+		Accessible(verbose)
+	if err := runSpinner(s, "Running task "+taskName+"...", func() { cmdErr = task() }); err != nil {
 		return err
 	}
 	return cmdErr
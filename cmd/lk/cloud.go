@@ -21,6 +21,11 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/huh"
@@ -51,10 +56,17 @@ const (
 	claimKeyEndpoint    = "/cli/claim"
 	confirmAuthEndpoint = "/cli/confirm-auth"
 	revokeKeyEndpoint   = "/cli/revoke"
+	renewKeyEndpoint    = "/cli/renew"
+	usageEndpoint       = "/api/usage"
+	projectsEndpoint    = "/api/projects"
 )
 
+var errInteractiveAuthRequired = errors.New("credentials can no longer be renewed, run `lk cloud auth` to authenticate again")
+
 var (
 	revoke        bool
+	renew         bool
+	deviceFlow    bool
 	timeout       int64  = 60 * 15
 	interval      int64  = 4
 	serverURL     string = cloudAPIServerURL
@@ -76,6 +88,16 @@ var (
 							Aliases:     []string{"R"},
 							Destination: &revoke,
 						},
+						&cli.BoolFlag{
+							Name:        "renew",
+							Usage:       "Refresh the credentials of the current project without user interaction, for use in scheduled jobs",
+							Destination: &renew,
+						},
+						&cli.BoolFlag{
+							Name:        "device-flow",
+							Usage:       "Authenticate without a local browser by visiting the confirmation URL on another device (default when no browser or TTY is available)",
+							Destination: &deviceFlow,
+						},
 						&cli.IntFlag{
 							Name:        "timeout",
 							Aliases:     []string{"t"},
@@ -102,6 +124,67 @@ var (
 							Destination: &dashboardURL,
 							Hidden:      true,
 						},
+						jsonFlag,
+					},
+				},
+				{
+					Name:   "usage",
+					Usage:  "Summarize usage of the current project for a billing period",
+					Action: showUsage,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "period",
+							Usage: "Billing `PERIOD` to summarize, one of \"day\", \"week\", or \"month\"",
+							Value: "month",
+						},
+						&cli.StringFlag{
+							Name:        "server-url",
+							Value:       cloudAPIServerURL,
+							Destination: &serverURL,
+							Hidden:      true,
+						},
+						jsonFlag,
+					},
+				},
+				{
+					Name:   "whoami",
+					Usage:  "Show the project and credentials other commands would use, and check them with its server",
+					Action: whoAmI,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:        "server-url",
+							Value:       cloudAPIServerURL,
+							Destination: &serverURL,
+							Hidden:      true,
+						},
+						jsonFlag,
+					},
+				},
+				{
+					Name:  "projects",
+					Usage: "Manage the cloud projects of your account",
+					Commands: []*cli.Command{
+						{
+							Name:   "sync",
+							Usage:  "Import credentials of the cloud projects you have access to into the local config",
+							Action: syncCloudProjects,
+							Flags: []cli.Flag{
+								&cli.BoolFlag{
+									Name:  "all",
+									Usage: "Import every project instead of selecting them interactively",
+								},
+								&cli.BoolFlag{
+									Name:  "overwrite",
+									Usage: "Replace local projects with the same name without asking",
+								},
+								&cli.StringFlag{
+									Name:        "server-url",
+									Value:       cloudAPIServerURL,
+									Destination: &serverURL,
+									Hidden:      true,
+								},
+							},
+						},
 					},
 				},
 			},
@@ -109,6 +192,33 @@ var (
 	}
 )
 
+type RenewAccessKeyResponse struct {
+	Key     string
+	Secret  string
+	Expires int64
+}
+
+type UsageMetric struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+}
+
+type UsageSummary struct {
+	ProjectName string        `json:"project_name"`
+	PeriodStart time.Time     `json:"period_start"`
+	PeriodEnd   time.Time     `json:"period_end"`
+	Metrics     []UsageMetric `json:"metrics"`
+}
+
+type CloudProject struct {
+	ProjectId string `json:"project_id"`
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	APIKey    string `json:"api_key"`
+	APISecret string `json:"api_secret"`
+}
+
 type VerificationToken struct {
 	Identifier string
 	Token      string
@@ -212,6 +322,45 @@ func (a *AuthClient) Deauthenticate(ctx context.Context, projectName, token stri
 	return cliConfig.RemoveProject(projectName)
 }
 
+// RenewCliKey exchanges the current key of a project for a fresh one. It
+// returns errInteractiveAuthRequired when the server will not renew the key
+// and the user must authenticate again.
+func (a *AuthClient) RenewCliKey(ctx context.Context, token string) (*RenewAccessKeyResponse, error) {
+	reqURL, err := url.Parse(a.baseURL + renewKeyEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", reqURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = authutil.NewHeaderWithToken(token)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return nil, errInteractiveAuthRequired
+	default:
+		return nil, errors.New(resp.Status)
+	}
+
+	rk := &RenewAccessKeyResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(rk); err != nil {
+		return nil, err
+	}
+	if rk.Key == "" || rk.Secret == "" {
+		return nil, errors.New("server returned incomplete credentials")
+	}
+	return rk, nil
+}
+
 func NewAuthClient(client *http.Client, baseURL string) *AuthClient {
 	a := &AuthClient{
 		client:  client,
@@ -226,6 +375,9 @@ func initAuth(ctx context.Context, cmd *cli.Command) (context.Context, error) {
 }
 
 func handleAuth(ctx context.Context, cmd *cli.Command) error {
+	if revoke && renew {
+		return validationErrorf("--revoke and --renew cannot be used together")
+	}
 	if revoke {
 		if _, err := loadProjectConfig(ctx, cmd); err != nil {
 			return err
@@ -236,9 +388,62 @@ func handleAuth(ctx context.Context, cmd *cli.Command) error {
 		}
 		return authClient.Deauthenticate(ctx, project.Name, token)
 	}
+	if renew {
+		return renewAuth(ctx, cmd)
+	}
 	return tryAuthIfNeeded(ctx, cmd)
 }
 
+// renewAuth replaces the stored credentials of the current project without
+// prompting, failing if the user needs to authenticate again.
+func renewAuth(ctx context.Context, cmd *cli.Command) error {
+	if _, err := loadProjectConfig(ctx, cmd); err != nil {
+		return err
+	}
+	token, err := requireToken(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	if project.Name == "" || !cliConfig.ProjectExists(project.Name) {
+		return validationErrorf("--renew requires a configured project, use --project or set a default")
+	}
+
+	rk, err := authClient.RenewCliKey(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	for i := range cliConfig.Projects {
+		if cliConfig.Projects[i].Name == project.Name {
+			cliConfig.Projects[i].APIKey = rk.Key
+			cliConfig.Projects[i].APISecret = rk.Secret
+		}
+	}
+	if err = cliConfig.PersistIfNeeded(); err != nil {
+		return err
+	}
+
+	var expires time.Time
+	if rk.Expires > 0 {
+		expires = time.Unix(rk.Expires, 0)
+	}
+	if cmd.Bool("json") {
+		res := map[string]any{
+			"project": project.Name,
+			"api_key": rk.Key,
+		}
+		if !expires.IsZero() {
+			res["expires"] = expires
+		}
+		util.PrintJSON(res)
+	} else if expires.IsZero() {
+		fmt.Println("Renewed credentials for [" + util.Theme.Focused.Title.Render(project.Name) + "]")
+	} else {
+		fmt.Println("Renewed credentials for [" + util.Theme.Focused.Title.Render(project.Name) + "], expiring " + expires.Format(time.RFC3339))
+	}
+	return nil
+}
+
 func requireToken(_ context.Context, cmd *cli.Command) (string, error) {
 	if project == nil {
 		var err error
@@ -268,6 +473,9 @@ func tryAuthIfNeeded(ctx context.Context, cmd *cli.Command) error {
 	if _, err := loadProjectConfig(ctx, cmd); err != nil {
 		return err
 	}
+	if deviceFlow || (!cmd.IsSet("device-flow") && isHeadless()) {
+		return tryDeviceAuth(ctx, cmd)
+	}
 
 	// get devicename
 	if err := huh.NewInput().
@@ -302,13 +510,10 @@ func tryAuthIfNeeded(ctx context.Context, cmd *cli.Command) error {
 
 	var ak *ClaimAccessKeyResponse
 	var pollErr error
-	if err := spinner.New().
-		Title("Awaiting confirmation...").
-		Action(func() {
-			ak, pollErr = pollClaim(ctx, cmd)
-		}).
-		Style(util.Theme.Focused.Title).
-		Run(); err != nil {
+	s := spinner.New().Style(util.Theme.Focused.Title)
+	if err := runSpinner(s, "Awaiting confirmation...", func() {
+		ak, pollErr = pollClaim(ctx, cmd)
+	}); err != nil {
 		return err
 	}
 	if pollErr != nil {
@@ -367,6 +572,387 @@ func tryAuthIfNeeded(ctx context.Context, cmd *cli.Command) error {
 	return err
 }
 
+// tryDeviceAuth authenticates without relying on a local browser or
+// interactive prompts, for use on remote machines and in containers.
+func tryDeviceAuth(ctx context.Context, cmd *cli.Command) error {
+	if cliConfig.DeviceName == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return err
+		}
+		cliConfig.DeviceName = hostname
+	}
+	fmt.Println("Device:", cliConfig.DeviceName)
+
+	fmt.Println("Requesting verification token...")
+	token, err := authClient.GetVerificationToken(cliConfig.DeviceName)
+	if err != nil {
+		return err
+	}
+
+	authURL, err := generateConfirmURL(token.Token)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("On another device, confirm access by visiting:\n\n   %s\n\n", authURL.String())
+	if token.Identifier != "" {
+		fmt.Printf("and verify that the code shown matches:\n\n   %s\n\n", token.Identifier)
+	}
+	fmt.Println("Awaiting confirmation...")
+
+	ak, err := pollClaim(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	if ak == nil {
+		return errors.New("operation cancelled")
+	}
+
+	baseName, err := util.URLSafeName(ak.URL)
+	if err != nil {
+		return err
+	}
+	name := baseName
+	for i := 2; cliConfig.ProjectExists(name); i++ {
+		name = fmt.Sprintf("%s-%d", baseName, i)
+	}
+
+	cliConfig.Projects = append(cliConfig.Projects, config.ProjectConfig{
+		Name:      name,
+		APIKey:    ak.Key,
+		APISecret: ak.Secret,
+		URL:       ak.URL,
+	})
+	// without a prompt, only claim the default slot if it is free
+	if cliConfig.DefaultProject == "" {
+		cliConfig.DefaultProject = name
+	}
+	if err = cliConfig.PersistIfNeeded(); err != nil {
+		return err
+	}
+	fmt.Println("Added project [" + util.Theme.Focused.Title.Render(name) + "]")
+	return nil
+}
+
+// isHeadless reports whether the CLI is unable to prompt the user or open a
+// browser on their behalf.
+func isHeadless() bool {
+	if !isInteractive() {
+		return true
+	}
+	switch runtime.GOOS {
+	case "darwin", "windows":
+		return false
+	default:
+		return os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == ""
+	}
+}
+
+func showUsage(ctx context.Context, cmd *cli.Command) error {
+	period := cmd.String("period")
+	switch period {
+	case "day", "week", "month":
+	default:
+		return errors.New("unrecognized period " + util.WrapWith("\"")(period))
+	}
+
+	token, err := requireToken(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	reqURL, err := url.Parse(serverURL + usageEndpoint)
+	if err != nil {
+		return err
+	}
+	params := url.Values{}
+	params.Add("period", period)
+	reqURL.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header = authutil.NewHeaderWithToken(token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(resp.Status)
+	}
+
+	summary := &UsageSummary{}
+	if err = json.NewDecoder(resp.Body).Decode(summary); err != nil {
+		return err
+	}
+
+	if cmd.Bool("json") {
+		util.PrintJSON(summary)
+	} else {
+		fmt.Printf("Usage for [%s] from %s to %s\n",
+			util.Theme.Focused.Title.Render(summary.ProjectName),
+			summary.PeriodStart.Format(time.DateOnly),
+			summary.PeriodEnd.Format(time.DateOnly),
+		)
+		table := util.CreateTable().Headers("Metric", "Usage", "Unit")
+		for _, m := range summary.Metrics {
+			table.Row(m.Name, strconv.FormatFloat(m.Value, 'f', -1, 64), m.Unit)
+		}
+		fmt.Println(table)
+	}
+	return nil
+}
+
+// WhoAmI describes the credentials resolved for other commands.
+type WhoAmI struct {
+	Project string `json:"project,omitempty"`
+	// where the credentials come from: --project, flags, environment or default
+	Source   string `json:"source"`
+	URL      string `json:"url"`
+	APIKey   string `json:"api_key"`
+	Verified bool   `json:"verified"`
+	Status   string `json:"status"`
+	// the LiveKit Cloud project the credentials belong to, when it can be found
+	CloudProject   string `json:"cloud_project,omitempty"`
+	CloudProjectID string `json:"cloud_project_id,omitempty"`
+	// agent dispatched when `lk dispatch create` has no --agent-name
+	DefaultAgentName string `json:"default_agent_name,omitempty"`
+}
+
+func whoAmI(ctx context.Context, cmd *cli.Command) error {
+	p, err := loadProjectDetails(cmd, quietLoad)
+	if err != nil {
+		return err
+	}
+	res := &WhoAmI{
+		Project: p.Name,
+		Source:  credentialSource(cmd),
+		URL:     p.URL,
+		APIKey:  maskAPIKey(p.APIKey),
+
+		DefaultAgentName: p.DefaultAgentName,
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	test, testErr := testProjectCredentials(checkCtx, p, false)
+	if testErr != nil {
+		res.Status = "unreachable"
+	} else {
+		res.Verified = test.OK
+		res.Status = test.Status
+	}
+	if res.Verified && isCloudURL(p.URL) {
+		// best effort, the project is still shown when the lookup fails
+		project = p
+		if cp, err := findCloudProject(checkCtx, cmd, p); err == nil && cp != nil {
+			res.CloudProject = cp.Name
+			res.CloudProjectID = cp.ProjectId
+		}
+	}
+
+	if cmd.Bool("json") {
+		util.PrintJSON(res)
+	} else {
+		table := util.CreateTable().Headers("Field", "Value")
+		if res.Project != "" {
+			table.Row("Project", res.Project)
+		}
+		table.Row("Source", res.Source)
+		table.Row("URL", res.URL)
+		table.Row("API Key", res.APIKey)
+		table.Row("Status", res.Status)
+		if res.CloudProject != "" {
+			table.Row("Cloud Project", res.CloudProject+" ("+res.CloudProjectID+")")
+		}
+		if res.DefaultAgentName != "" {
+			table.Row("Default Agent", res.DefaultAgentName)
+		}
+		fmt.Println(table)
+	}
+
+	if testErr != nil {
+		return testErr
+	}
+	if !res.Verified {
+		return authErrorf("credentials were not accepted by %s: %s", p.URL, res.Status)
+	}
+	return nil
+}
+
+// credentialSource names where loadProjectDetails found credentials,
+// following the same order.
+func credentialSource(cmd *cli.Command) string {
+	if cmd.String("project") != "" {
+		return "--project"
+	}
+	if cmd.String("api-key") != "" && cmd.String("api-secret") != "" {
+		if os.Getenv("LIVEKIT_API_KEY") == cmd.String("api-key") && os.Getenv("LIVEKIT_API_SECRET") == cmd.String("api-secret") {
+			return "environment"
+		}
+		return "flags"
+	}
+	return "default project"
+}
+
+// maskAPIKey keeps enough of a key to tell it apart from others.
+func maskAPIKey(key string) string {
+	if len(key) <= 6 {
+		return maskedValue
+	}
+	return key[:6] + maskedValue
+}
+
+func isCloudURL(projectURL string) bool {
+	u, err := url.Parse(projectURL)
+	return err == nil && strings.HasSuffix(u.Hostname(), ".livekit.cloud")
+}
+
+// findCloudProject looks up the cloud project with the URL of p among those
+// the credentials of p have access to.
+func findCloudProject(ctx context.Context, cmd *cli.Command, p *config.ProjectConfig) (*CloudProject, error) {
+	projects, err := fetchCloudProjects(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	for _, cp := range projects {
+		if strings.TrimSuffix(cp.URL, "/") == strings.TrimSuffix(p.URL, "/") {
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+// fetchCloudProjects lists the cloud projects of the account of the current
+// project, with their credentials.
+func fetchCloudProjects(ctx context.Context, cmd *cli.Command) ([]CloudProject, error) {
+	token, err := requireToken(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", serverURL+projectsEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = authutil.NewHeaderWithToken(token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status)
+	}
+	var res struct {
+		Projects []CloudProject `json:"projects"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+	return res.Projects, nil
+}
+
+func syncCloudProjects(ctx context.Context, cmd *cli.Command) error {
+	if _, err := loadProjectConfig(ctx, cmd); err != nil {
+		return err
+	}
+	projects, err := fetchCloudProjects(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	if len(projects) == 0 {
+		fmt.Println("No cloud projects found")
+		return nil
+	}
+
+	// local projects are named after their URL, as with `lk cloud auth`
+	remote := make([]config.ProjectConfig, 0, len(projects))
+	for _, p := range projects {
+		name, err := util.URLSafeName(p.URL)
+		if err != nil {
+			return fmt.Errorf("project %s: %w", p.Name, err)
+		}
+		remote = append(remote, config.ProjectConfig{
+			Name:      name,
+			URL:       p.URL,
+			APIKey:    p.APIKey,
+			APISecret: p.APISecret,
+		})
+	}
+
+	selected := remote
+	if !cmd.Bool("all") {
+		if !isInteractive() {
+			return errors.New("select projects interactively, or use --all")
+		}
+		var names []string
+		options := make([]huh.Option[string], 0, len(remote))
+		for i, p := range remote {
+			options = append(options, huh.NewOption(projects[i].Name+" ("+p.URL+")", p.Name))
+		}
+		if err = huh.NewMultiSelect[string]().
+			Title("Projects to import").
+			Options(options...).
+			Value(&names).
+			WithTheme(util.Theme).
+			Run(); err != nil {
+			return err
+		}
+		selected = slices.DeleteFunc(slices.Clone(remote), func(p config.ProjectConfig) bool {
+			return !slices.Contains(names, p.Name)
+		})
+	}
+
+	table := util.CreateTable().Headers("Project", "URL", "Result")
+	changed := false
+	for _, p := range selected {
+		i := slices.IndexFunc(cliConfig.Projects, func(e config.ProjectConfig) bool { return e.Name == p.Name })
+		switch {
+		case i < 0:
+			cliConfig.Projects = append(cliConfig.Projects, p)
+			table.Row(p.Name, p.URL, "added")
+			changed = true
+		case cliConfig.Projects[i].SameCredentials(p):
+			table.Row(p.Name, p.URL, "unchanged")
+		case cmd.Bool("overwrite") || confirmProjectOverwrite(p.Name):
+			cliConfig.Projects[i] = cliConfig.Projects[i].MergeCredentials(p)
+			table.Row(p.Name, p.URL, "updated")
+			changed = true
+		default:
+			table.Row(p.Name, p.URL, "skipped, already exists (use --overwrite)")
+		}
+	}
+	fmt.Println(table)
+
+	if !changed {
+		return nil
+	}
+	return cliConfig.PersistIfNeeded()
+}
+
+// confirmProjectOverwrite asks whether to replace the local project name,
+// declining when not running interactively.
+func confirmProjectOverwrite(name string) bool {
+	if !isInteractive() {
+		return false
+	}
+	overwrite := false
+	if err := huh.NewConfirm().
+		Title("Project " + name + " already exists with different credentials, replace it?").
+		Value(&overwrite).
+		Inline(true).
+		WithTheme(util.Theme).
+		Run(); err != nil {
+		return false
+	}
+	return overwrite
+}
+
 func generateConfirmURL(token string) (*url.URL, error) {
 	base, err := url.Parse(dashboardURL + confirmAuthEndpoint)
 	if err != nil {
@@ -0,0 +1,218 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/charmbracelet/huh"
+	"github.com/urfave/cli/v3"
+
+	"github.com/livekit/livekit-cli/pkg/config"
+	"github.com/livekit/livekit-cli/pkg/util"
+)
+
+var (
+	ConfigCommands = []*cli.Command{
+		{
+			Name:  "config",
+			Usage: "Move project credentials between machines",
+			Commands: []*cli.Command{
+				{
+					Name:      "export",
+					Usage:     "Write configured projects in a portable form",
+					UsageText: "lk config export [OPTIONS] [PROJECT_NAME ...]",
+					ArgsUsage: "[PROJECT_NAME ...]",
+					Before:    loadProjectConfig,
+					Action:    exportConfig,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:    "out",
+							Aliases: []string{"o"},
+							Usage:   "Write the export to `FILE` instead of stdout",
+						},
+						&cli.BoolFlag{
+							Name:  "encrypt",
+							Usage: "Encrypt the export, prompting for a passphrase if --passphrase is not set",
+						},
+						passphraseFlag,
+					},
+				},
+				{
+					Name:      "import",
+					Usage:     "Merge projects from an export into the local config",
+					UsageText: "lk config import [OPTIONS] FILE",
+					ArgsUsage: "FILE",
+					Before:    loadProjectConfig,
+					Action:    importConfig,
+					Flags: []cli.Flag{
+						&cli.BoolFlag{
+							Name:  "overwrite",
+							Usage: "Replace local projects that have the same name as imported ones",
+						},
+						passphraseFlag,
+					},
+				},
+			},
+		},
+	}
+
+	passphraseFlag = &cli.StringFlag{
+		Name:    "passphrase",
+		Usage:   "`PASSPHRASE` used to encrypt or decrypt secrets",
+		Sources: cli.EnvVars("LIVEKIT_CONFIG_PASSPHRASE"),
+	}
+)
+
+func exportConfig(ctx context.Context, cmd *cli.Command) error {
+	projects := cliConfig.Projects
+	if cmd.NArg() > 0 {
+		projects = nil
+		for _, name := range cmd.Args().Slice() {
+			i := slices.IndexFunc(cliConfig.Projects, func(p config.ProjectConfig) bool { return p.Name == name })
+			if i < 0 {
+				return fmt.Errorf("project %s not found", name)
+			}
+			projects = append(projects, cliConfig.Projects[i])
+		}
+	}
+	if len(projects) == 0 {
+		return errors.New("no projects to export")
+	}
+
+	passphrase := cmd.String("passphrase")
+	if passphrase == "" && cmd.Bool("encrypt") {
+		var err error
+		if passphrase, err = promptPassphrase("Passphrase to encrypt the export", true); err != nil {
+			return err
+		}
+	}
+
+	data, err := config.ExportProjects(projects, passphrase)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.String("out")
+	if out == "" || out == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err = os.WriteFile(out, data, 0600); err != nil {
+		return err
+	}
+	if passphrase == "" {
+		warnf("WARNING: export is not encrypted, it contains API secrets in plain text")
+	}
+	fmt.Printf("Exported %d project(s) to %s\n", len(projects), out)
+	return nil
+}
+
+func importConfig(ctx context.Context, cmd *cli.Command) error {
+	file, err := extractArg(cmd)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return err
+	}
+
+	passphrase := cmd.String("passphrase")
+	if passphrase == "" && config.IsEncryptedExport(data) && file != "-" {
+		if passphrase, err = promptPassphrase("Passphrase to decrypt the export", false); err != nil {
+			return err
+		}
+	}
+	projects, err := config.ImportProjects(data, passphrase)
+	if err != nil {
+		return err
+	}
+
+	table := util.CreateTable().Headers("Project", "URL", "Result")
+	changed := false
+	for _, p := range projects {
+		if !nameRegex.MatchString(p.Name) {
+			table.Row(p.Name, p.URL, "skipped, invalid name")
+			continue
+		}
+		i := slices.IndexFunc(cliConfig.Projects, func(e config.ProjectConfig) bool { return e.Name == p.Name })
+		switch {
+		case i < 0:
+			cliConfig.Projects = append(cliConfig.Projects, p)
+			table.Row(p.Name, p.URL, "added")
+			changed = true
+		case cliConfig.Projects[i].SameCredentials(p):
+			table.Row(p.Name, p.URL, "unchanged")
+		case cmd.Bool("overwrite"):
+			cliConfig.Projects[i] = cliConfig.Projects[i].MergeCredentials(p)
+			table.Row(p.Name, p.URL, "updated")
+			changed = true
+		default:
+			table.Row(p.Name, p.URL, "skipped, already exists (use --overwrite)")
+		}
+	}
+	fmt.Println(table)
+
+	if !changed {
+		return nil
+	}
+	return cliConfig.PersistIfNeeded()
+}
+
+func promptPassphrase(title string, confirm bool) (string, error) {
+	if !isInteractive() {
+		return "", errors.New("a passphrase is required, use --passphrase or LIVEKIT_CONFIG_PASSPHRASE")
+	}
+	var passphrase, confirmation string
+	fields := []huh.Field{
+		huh.NewInput().
+			Title(title).
+			EchoMode(huh.EchoModePassword).
+			Value(&passphrase).
+			Validate(func(s string) error {
+				if s == "" {
+					return errors.New("passphrase is required")
+				}
+				return nil
+			}),
+	}
+	if confirm {
+		fields = append(fields, huh.NewInput().
+			Title("Confirm passphrase").
+			EchoMode(huh.EchoModePassword).
+			Value(&confirmation).
+			Validate(func(s string) error {
+				if s != passphrase {
+					return errors.New("passphrases do not match")
+				}
+				return nil
+			}))
+	}
+	if err := huh.NewForm(huh.NewGroup(fields...)).WithTheme(util.Theme).Run(); err != nil {
+		return "", err
+	}
+	return passphrase, nil
+}
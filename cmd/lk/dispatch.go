@@ -15,13 +15,27 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/charmbracelet/huh"
+	"github.com/twitchtv/twirp"
 	"github.com/urfave/cli/v3"
+	"golang.org/x/sync/errgroup"
+	"gopkg.in/yaml.v3"
 
 	"github.com/livekit/livekit-cli/pkg/util"
+	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/utils"
 	lksdk "github.com/livekit/server-sdk-go/v2"
@@ -31,27 +45,67 @@ var (
 	DispatchCommands = []*cli.Command{
 		{
 			Name:  "dispatch",
-			Usage: "Create, list, and delete agent dispatches",
+			Usage: "Create, list, update, and delete agent dispatches",
+			Flags: []cli.Flag{
+				&cli.DurationFlag{
+					Name:  "timeout",
+					Usage: "Give up on agent dispatch requests after `DURATION`, 0 to wait indefinitely",
+					Value: 30 * time.Second,
+				},
+				&cli.IntFlag{
+					Name:  "max-retries",
+					Usage: "Retry transient failures of agent dispatch requests up to `N` times",
+					Value: 2,
+				},
+			},
 			Commands: []*cli.Command{
 				{
 					Name:      "list",
 					Usage:     "List all agent dispatches in a room",
 					Before:    createDispatchClient,
 					Action:    listAgentDispatches,
-					ArgsUsage: "ROOM_NAME",
+					ArgsUsage: "[ROOM_NAME]",
+					Flags: []cli.Flag{
+						listJSONFlag,
+						outputFlag,
+						templateFlag,
+						countOnlyFlag,
+						&cli.StringSliceFlag{
+							Name:  "label-selector",
+							Usage: "Only list dispatches with all labels in `KEY=VALUE[,KEY=VALUE]`, can be used multiple times",
+						},
+						&cli.StringFlag{
+							Name:  "room-pattern",
+							Usage: "List dispatches in every room with a name matching `REGEXP` instead of a single room, as an object keyed by room with --json",
+						},
+						&cli.BoolFlag{
+							Name:  "watch",
+							Usage: "Refresh the list until interrupted, printing newline-delimited JSON snapshots with --json",
+						},
+						&cli.DurationFlag{
+							Name:  "interval",
+							Usage: "How often to refresh the list with --watch",
+							Value: 2 * time.Second,
+						},
+					},
 				},
 				{
-					Name:      "get",
-					Usage:     "Get an agent dispatch by room and ID",
-					Before:    createDispatchClient,
-					Action:    getAgentDispatch,
-					ArgsUsage: "ROOM_NAME ID",
+					Name:          "get",
+					Usage:         "Get an agent dispatch by room and ID",
+					Before:        createDispatchClient,
+					Action:        getAgentDispatch,
+					ShellComplete: completeDispatchArgs,
+					ArgsUsage:     "ROOM_NAME ID",
+					Flags: []cli.Flag{
+						rawFlag,
+					},
 				},
 				{
-					Name:   "create",
-					Usage:  "Create an agent dispatch",
-					Before: createDispatchClient,
-					Action: createAgentDispatch,
+					Name:          "create",
+					Usage:         "Create an agent dispatch",
+					Before:        createDispatchClient,
+					Action:        createAgentDispatch,
+					ShellComplete: completeDispatchCreate,
 					Flags: []cli.Flag{
 						&cli.StringFlag{
 							Name:  "room",
@@ -61,22 +115,81 @@ var (
 							Name:  "new-room",
 							Usage: "when set, will generate a unique room name",
 						},
+						roomPrefixFlag,
 						&cli.StringFlag{
 							Name:  "agent-name",
-							Usage: "agent to dispatch",
+							Usage: "agent to dispatch, defaults to the agent set with \"lk project set-agent-name\"",
 						},
 						&cli.StringFlag{
 							Name:  "metadata",
 							Usage: "metadata to send to agent",
 						},
+						metadataFileFlag,
+						&cli.StringSliceFlag{
+							Name:  "label",
+							Usage: "`KEY=VALUE` label stored in the metadata under \"" + dispatchLabelsKey + "\", can be used multiple times",
+						},
+						&cli.BoolFlag{
+							Name:  "wait-and-tail",
+							Usage: "wait for the agent to join the room, then print room events until interrupted",
+						},
+						&cli.DurationFlag{
+							Name:  "wait-timeout",
+							Usage: "maximum `TIME` to wait for the agent to join, required with --wait-and-tail when not running interactively",
+							Value: time.Minute,
+						},
+						&cli.StringFlag{
+							Name:      "batch",
+							Usage:     "create the dispatches listed in `FILE`, a JSON or YAML array of {room, agent_name, metadata}, or from stdin when \"-\". Flags fill in fields a dispatch leaves empty",
+							TakesFile: true,
+						},
+						&cli.IntFlag{
+							Name:  "parallel",
+							Usage: "`NUMBER` of dispatches to create at once with --batch",
+							Value: 4,
+						},
+						jsonFlag,
 					},
 				},
 				{
-					Name:      "delete",
-					Usage:     "Delete an agent dispatch",
-					Before:    createDispatchClient,
-					Action:    deleteAgentDispatch,
-					ArgsUsage: "ROOM_NAME ID",
+					Name:          "update",
+					Usage:         "Update the metadata of an agent dispatch, replacing it with a new dispatch",
+					Before:        createDispatchClient,
+					Action:        updateAgentDispatch,
+					ShellComplete: completeDispatchArgs,
+					ArgsUsage:     "ROOM_NAME ID",
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "metadata",
+							Usage: "metadata to send to agent",
+						},
+						metadataFileFlag,
+						jsonFlag,
+					},
+				},
+				{
+					Name:          "delete",
+					Usage:         "Delete an agent dispatch",
+					Before:        createDispatchClient,
+					Action:        deleteAgentDispatch,
+					ShellComplete: completeDispatchArgs,
+					ArgsUsage:     "ROOM_NAME [ID]",
+					Flags: []cli.Flag{
+						&cli.BoolFlag{
+							Name:  "all",
+							Usage: "delete every dispatch in the room instead of a single ID",
+						},
+						&cli.BoolFlag{
+							Name:  "dry-run",
+							Usage: "list the dispatches that would be deleted, without deleting them",
+						},
+						&cli.BoolFlag{
+							Name:    "yes",
+							Aliases: []string{"y"},
+							Usage:   "skip the confirmation prompt, required when not running interactively",
+						},
+						jsonFlag,
+					},
 				},
 			},
 		},
@@ -85,104 +198,329 @@ var (
 	dispatchClient *lksdk.AgentDispatchClient
 )
 
+// dispatchLabelsKey is the metadata field holding labels set with --label,
+// kept apart from fields used by agents.
+const dispatchLabelsKey = "_lk_labels"
+
+// dispatchRetryBackoff is the delay before retrying a failed dispatch RPC,
+// doubling with each attempt.
+const dispatchRetryBackoff = 500 * time.Millisecond
+
 func createDispatchClient(ctx context.Context, cmd *cli.Command) (context.Context, error) {
 	pc, err := loadProjectDetails(cmd)
 	if err != nil {
 		return nil, err
 	}
 
+	project = pc
 	dispatchClient = lksdk.NewAgentDispatchServiceClient(pc.URL, pc.APIKey, pc.APISecret, withDefaultClientOpts(pc)...)
 	return nil, nil
 }
 
+// completeDispatchArgs completes the ROOM_NAME and ID arguments of dispatch
+// commands. Before hooks don't run while completing, so clients are created
+// here.
+func completeDispatchArgs(ctx context.Context, cmd *cli.Command) {
+	if completingFlag() {
+		cli.DefaultCompleteWithFlags(ctx, cmd)
+		return
+	}
+	switch cmd.NArg() {
+	case 0:
+		completeRoomNames(ctx, cmd)
+	case 1:
+		completeDispatchIDs(ctx, cmd, cmd.Args().First())
+	}
+}
+
+func completeDispatchCreate(ctx context.Context, cmd *cli.Command) {
+	if completingFlagValue("room") {
+		completeRoomNames(ctx, cmd)
+		return
+	}
+	cli.DefaultCompleteWithFlags(ctx, cmd)
+}
+
+func completeDispatchIDs(ctx context.Context, cmd *cli.Command, roomName string) {
+	pc, err := loadProjectDetails(cmd, quietLoad)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, completionTimeout)
+	defer cancel()
+
+	client := lksdk.NewAgentDispatchServiceClient(pc.URL, pc.APIKey, pc.APISecret, withDefaultClientOpts(pc)...)
+	res, err := client.ListDispatch(ctx, &livekit.ListAgentDispatchRequest{Room: roomName})
+	if err != nil {
+		return
+	}
+	candidates := make([][2]string, 0, len(res.AgentDispatches))
+	for _, d := range res.AgentDispatches {
+		candidates = append(candidates, [2]string{d.Id, d.AgentName})
+	}
+	printCompletions(cmd, candidates)
+}
+
 func getAgentDispatch(ctx context.Context, cmd *cli.Command) error {
 	if cmd.Args().Len() == 0 {
 		return cli.ShowSubcommandHelp(cmd)
 	}
 	roomName := cmd.Args().First()
 	if roomName == "" {
-		return errors.New("room name is required")
+		return validationErrorf("room name is required")
 	}
 	id := cmd.Args().Get(1)
 	if id == "" {
-		return errors.New("dispatch ID is required")
+		return validationErrorf("dispatch ID is required")
 	}
 
-	return listDispatchAndPrint(cmd, &livekit.ListAgentDispatchRequest{
+	return listDispatchAndPrint(ctx, cmd, &livekit.ListAgentDispatchRequest{
 		Room:       roomName,
 		DispatchId: id,
 	})
 }
 
 func listAgentDispatches(ctx context.Context, cmd *cli.Command) error {
+	if pattern := cmd.String("room-pattern"); pattern != "" {
+		if cmd.Args().Len() > 0 {
+			return validationErrorf("only one of ROOM_NAME or --room-pattern can be specified")
+		}
+		return listDispatchesByRoomPattern(ctx, cmd, pattern)
+	}
 	if cmd.Args().Len() == 0 {
 		return cli.ShowSubcommandHelp(cmd)
 	}
 	roomName := cmd.Args().First()
 	if roomName == "" {
-		return errors.New("room name is required")
+		return validationErrorf("room name is required")
 	}
 
-	return listDispatchAndPrint(cmd, &livekit.ListAgentDispatchRequest{
+	req := &livekit.ListAgentDispatchRequest{
 		Room: roomName,
+	}
+	if cmd.Bool("watch") {
+		return watchAgentDispatches(ctx, cmd, req)
+	}
+	return listDispatchAndPrint(ctx, cmd, req)
+}
+
+// watchAgentDispatches lists dispatches every --interval until ctx is done,
+// redrawing the output in place, or printing a JSON snapshot per line.
+func watchAgentDispatches(ctx context.Context, cmd *cli.Command, req *livekit.ListAgentDispatchRequest) error {
+	interval := cmd.Duration("interval")
+	if interval <= 0 {
+		return validationErrorf("--interval must be positive")
+	}
+	output, _, err := listOutput(cmd)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if output == "json" {
+			res, err := fetchDispatches(ctx, cmd, req)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return err
+			}
+			b, err := json.Marshal(res)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+		} else {
+			// clear the screen and move the cursor home before redrawing
+			fmt.Print("\033[H\033[2J")
+			fmt.Printf("Every %s: dispatches in room %s, %s\n\n", interval, req.Room, time.Now().Format(time.TimeOnly))
+			if err := listDispatchAndPrint(ctx, cmd, req); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetchDispatches lists dispatches, keeping those matching --label-selector.
+func fetchDispatches(ctx context.Context, cmd *cli.Command, req *livekit.ListAgentDispatchRequest) (*livekit.ListAgentDispatchResponse, error) {
+	selector, err := parseLabels(cmd.StringSlice("label-selector"))
+	if err != nil {
+		return nil, err
+	}
+	res, err := withDispatchRetries(ctx, cmd, true, func(ctx context.Context) (*livekit.ListAgentDispatchResponse, error) {
+		return dispatchClient.ListDispatch(ctx, req)
 	})
+	if err != nil {
+		return nil, err
+	}
+	if len(selector) > 0 {
+		res.AgentDispatches = slices.DeleteFunc(res.AgentDispatches, func(d *livekit.AgentDispatch) bool {
+			labels := dispatchLabels(d.Metadata)
+			for k, v := range selector {
+				if labels[k] != v {
+					return true
+				}
+			}
+			return false
+		})
+	}
+	return res, nil
 }
 
-func listDispatchAndPrint(cmd *cli.Command, req *livekit.ListAgentDispatchRequest) error {
+func listDispatchAndPrint(ctx context.Context, cmd *cli.Command, req *livekit.ListAgentDispatchRequest) error {
 	if cmd.Args().Len() == 0 {
 		return cli.ShowSubcommandHelp(cmd)
 	}
-	if cmd.Bool("verbose") {
-		util.PrintJSON(req)
+	output, tmpl, err := listOutput(cmd)
+	if err != nil {
+		return err
 	}
-	res, err := dispatchClient.ListDispatch(context.Background(), req)
+	debugJSON(req)
+	res, err := fetchDispatches(ctx, cmd, req)
 	if err != nil {
 		return err
 	}
-	if cmd.Bool("json") {
-		util.PrintJSON(res)
-	} else {
-		table := util.CreateTable().
-			Headers("DispatchID", "Room", "AgentName", "Metadata")
-		for _, item := range res.AgentDispatches {
-			if item == nil {
-				continue
-			}
+	if cmd.Bool("raw") {
+		return util.PrintProtoJSON(res)
+	}
+	if cmd.Bool("count-only") {
+		printCount(cmd, len(res.AgentDispatches))
+		return nil
+	}
+	return printList(output, tmpl, res, res.AgentDispatches, dispatchHeader, dispatchRow)
+}
+
+var dispatchHeader = []string{"DispatchID", "Room", "AgentName", "Metadata"}
+
+func dispatchRow(item *livekit.AgentDispatch) []string {
+	return []string{item.Id, item.Room, item.AgentName, item.Metadata}
+}
 
-			table.Row(
-				item.Id,
-				item.Room,
-				item.AgentName,
-				item.Metadata,
-			)
+// listDispatchesByRoomPattern lists the dispatches of every room with a name
+// matching pattern. Dispatches can only be listed per room, so the rooms are
+// listed first.
+func listDispatchesByRoomPattern(ctx context.Context, cmd *cli.Command, pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return validationErrorf("invalid room pattern: %w", err)
+	}
+	if cmd.Bool("watch") {
+		return validationErrorf("--watch cannot be used with --room-pattern")
+	}
+	output, tmpl, err := listOutput(cmd)
+	if err != nil {
+		return err
+	}
+
+	roomClient := lksdk.NewRoomServiceClient(project.URL, project.APIKey, project.APISecret, withDefaultClientOpts(project)...)
+	rooms, err := roomClient.ListRooms(ctx, &livekit.ListRoomsRequest{})
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, rm := range rooms.Rooms {
+		if re.MatchString(rm.Name) {
+			names = append(names, rm.Name)
 		}
-		fmt.Println(table)
 	}
-	return nil
+	slices.Sort(names)
+
+	results := make([][]*livekit.AgentDispatch, len(names))
+	var g errgroup.Group
+	g.SetLimit(4)
+	for i, name := range names {
+		g.Go(func() error {
+			res, err := fetchDispatches(ctx, cmd, &livekit.ListAgentDispatchRequest{Room: name})
+			if err != nil {
+				return fmt.Errorf("could not list dispatches of room %s: %w", name, err)
+			}
+			results[i] = res.AgentDispatches
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	var all []*livekit.AgentDispatch
+	for _, dispatches := range results {
+		all = append(all, dispatches...)
+	}
+	if cmd.Bool("count-only") {
+		printCount(cmd, len(all))
+		return nil
+	}
+	byRoom := make(map[string][]*livekit.AgentDispatch, len(names))
+	for i, name := range names {
+		byRoom[name] = append([]*livekit.AgentDispatch{}, results[i]...)
+	}
+	return printList(output, tmpl, byRoom, all, dispatchHeader, dispatchRow)
 }
 
 func createAgentDispatch(ctx context.Context, cmd *cli.Command) error {
+	if cmd.IsSet("batch") {
+		return createAgentDispatchBatch(ctx, cmd)
+	}
+	metadata, err := extractMetadata(cmd)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(strings.TrimSpace(metadata), "{") && !json.Valid([]byte(metadata)) {
+		warnf("WARNING: metadata looks like JSON but is not valid JSON")
+	}
 	req := &livekit.CreateAgentDispatchRequest{
 		Room:      cmd.String("room"),
 		AgentName: cmd.String("agent-name"),
-		Metadata:  cmd.String("metadata"),
+		Metadata:  metadata,
 	}
 	if cmd.Bool("new-room") {
-		req.Room = utils.NewGuid("room-")
+		if req.Room, err = newRoomName(cmd); err != nil {
+			return err
+		}
+		fmt.Fprintln(progressWriter(cmd), "Generated room name:", req.Room)
+	} else if cmd.IsSet("room-prefix") {
+		return validationErrorf("--room-prefix requires --new-room")
 	}
 	if req.Room == "" {
 		_ = cli.ShowSubcommandHelp(cmd)
-		return errors.New("room or new-room is required")
+		return validationErrorf("room or new-room is required")
+	}
+	if req.AgentName == "" {
+		req.AgentName = project.DefaultAgentName
 	}
 	if req.AgentName == "" {
 		_ = cli.ShowSubcommandHelp(cmd)
-		return errors.New("agent-name is required")
+		return validationErrorf("agent-name is required, or set a default with `lk project set-agent-name`")
 	}
-	if cmd.Bool("verbose") {
-		util.PrintJSON(req)
+	if cmd.IsSet("label") {
+		labels, err := parseLabels(cmd.StringSlice("label"))
+		if err != nil {
+			return err
+		}
+		if req.Metadata, err = withDispatchLabels(req.Metadata, labels); err != nil {
+			return err
+		}
 	}
+	waitAndTail := cmd.Bool("wait-and-tail")
+	if waitAndTail && !cmd.IsSet("wait-timeout") && !isInteractive() {
+		return validationErrorf("--wait-timeout is required with --wait-and-tail when not running interactively")
+	}
+	debugJSON(req)
 
-	info, err := dispatchClient.CreateDispatch(context.Background(), req)
+	info, err := withDispatchRetries(ctx, cmd, false, func(ctx context.Context) (*livekit.AgentDispatch, error) {
+		return dispatchClient.CreateDispatch(ctx, req)
+	})
 	if err != nil {
 		return err
 	}
@@ -193,35 +531,536 @@ func createAgentDispatch(ctx context.Context, cmd *cli.Command) error {
 		fmt.Printf("Dispatch created: %v\n", info)
 	}
 
+	if waitAndTail {
+		return waitForAgentAndTail(ctx, cmd, req.Room, cmd.Duration("wait-timeout"))
+	}
 	return nil
 }
 
-func deleteAgentDispatch(ctx context.Context, cmd *cli.Command) error {
+// DispatchSpec is a dispatch to create with --batch.
+type DispatchSpec struct {
+	Room      string `yaml:"room"`
+	AgentName string `yaml:"agent_name"`
+	Metadata  string `yaml:"metadata"`
+}
+
+// DispatchResult reports the outcome of creating a dispatch with --batch.
+type DispatchResult struct {
+	Room       string `json:"room"`
+	AgentName  string `json:"agent_name"`
+	DispatchID string `json:"dispatch_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// parseDispatchSpecs reads a batch of dispatches, as YAML is a superset of
+// JSON either can be used.
+func parseDispatchSpecs(content []byte) ([]DispatchSpec, error) {
+	var specs []DispatchSpec
+	if err := yaml.Unmarshal(content, &specs); err != nil {
+		return nil, fmt.Errorf("could not parse dispatches: %w", err)
+	}
+	return specs, nil
+}
+
+func createAgentDispatchBatch(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Bool("wait-and-tail") {
+		return validationErrorf("--wait-and-tail cannot be used with --batch")
+	}
+	parallel := int(cmd.Int("parallel"))
+	if parallel < 1 {
+		return validationErrorf("--parallel must be at least 1")
+	}
+	var (
+		content []byte
+		err     error
+	)
+	if file := cmd.String("batch"); file == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return err
+	}
+	specs, err := parseDispatchSpecs(content)
+	if err != nil {
+		return validationErrorf("%w", err)
+	}
+	if len(specs) == 0 {
+		return validationErrorf("no dispatches in %s", cmd.String("batch"))
+	}
+	metadata, err := extractMetadata(cmd)
+	if err != nil {
+		return err
+	}
+	if cmd.IsSet("room-prefix") && !cmd.Bool("new-room") {
+		return validationErrorf("--room-prefix requires --new-room")
+	}
+	var labels map[string]string
+	if cmd.IsSet("label") {
+		if labels, err = parseLabels(cmd.StringSlice("label")); err != nil {
+			return err
+		}
+	}
+
+	// validate every dispatch before creating any of them
+	reqs := make([]*livekit.CreateAgentDispatchRequest, len(specs))
+	for i, spec := range specs {
+		req := &livekit.CreateAgentDispatchRequest{
+			Room:      cmp.Or(spec.Room, cmd.String("room")),
+			AgentName: cmp.Or(spec.AgentName, cmd.String("agent-name"), project.DefaultAgentName),
+			Metadata:  cmp.Or(spec.Metadata, metadata),
+		}
+		if req.Room == "" && cmd.Bool("new-room") {
+			if req.Room, err = newRoomName(cmd); err != nil {
+				return err
+			}
+		}
+		if req.Room == "" {
+			return validationErrorf("dispatch %d: room is required, in the file or with --room or --new-room", i+1)
+		}
+		if req.AgentName == "" {
+			return validationErrorf("dispatch %d: agent_name is required, in the file or with --agent-name", i+1)
+		}
+		if labels != nil {
+			if req.Metadata, err = withDispatchLabels(req.Metadata, labels); err != nil {
+				return validationErrorf("dispatch %d: %w", i+1, err)
+			}
+		}
+		reqs[i] = req
+	}
+
+	results := make([]DispatchResult, len(reqs))
+	var g errgroup.Group
+	g.SetLimit(parallel)
+	for i, req := range reqs {
+		g.Go(func() error {
+			debugJSON(req)
+			results[i] = DispatchResult{Room: req.Room, AgentName: req.AgentName}
+			info, err := withDispatchRetries(ctx, cmd, false, func(ctx context.Context) (*livekit.AgentDispatch, error) {
+				return dispatchClient.CreateDispatch(ctx, req)
+			})
+			if err != nil {
+				results[i].Error = err.Error()
+			} else {
+				results[i].DispatchID = info.Id
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	var failed int
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+	if cmd.Bool("json") {
+		util.PrintJSON(results)
+	} else {
+		table := util.CreateTable().Headers("Room", "AgentName", "DispatchID", "Result")
+		for _, r := range results {
+			result := "created"
+			if r.Error != "" {
+				result = r.Error
+			}
+			table.Row(r.Room, r.AgentName, r.DispatchID, result)
+		}
+		fmt.Println(table)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to create %d of %d dispatches", failed, len(results))
+	}
+	return nil
+}
+
+// waitForAgentAndTail polls the room until an agent participant joins, then
+// connects as a hidden observer and logs room events until interrupted.
+func waitForAgentAndTail(ctx context.Context, cmd *cli.Command, roomName string, timeout time.Duration) error {
+	roomClient := lksdk.NewRoomServiceClient(project.URL, project.APIKey, project.APISecret, withDefaultClientOpts(project)...)
+
+	fmt.Println("Waiting for agent to join room", roomName)
+	agent, err := waitForAgent(ctx, roomClient, roomName, timeout)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Agent joined:", agent.Identity)
+
+	grant := &auth.VideoGrant{
+		RoomJoin: true,
+		Room:     roomName,
+		Hidden:   true,
+	}
+	grant.SetCanPublish(false)
+	token, err := auth.NewAccessToken(project.APIKey, project.APISecret).
+		SetIdentity(utils.NewGuid("lk-tail-")).
+		SetVideoGrant(grant).
+		ToJWT()
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	room, err := lksdk.ConnectToRoomWithToken(project.URL, token, newRoomEventLogger(func() { close(done) }), lksdk.WithAutoSubscribe(false))
+	if err != nil {
+		return err
+	}
+	defer room.Disconnect()
+	fmt.Println("Tailing room events, press Ctrl-C to exit")
+
+	select {
+	case <-ctx.Done():
+	case <-done:
+	}
+	return nil
+}
+
+func waitForAgent(ctx context.Context, roomClient *lksdk.RoomServiceClient, roomName string, timeout time.Duration) (*livekit.ParticipantInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		res, err := roomClient.ListParticipants(ctx, &livekit.ListParticipantsRequest{
+			Room: roomName,
+		})
+		if err == nil {
+			for _, p := range res.Participants {
+				if p.Kind == livekit.ParticipantInfo_AGENT {
+					return p, nil
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for agent to join room %s", roomName)
+		case <-ticker.C:
+		}
+	}
+}
+
+// updateAgentDispatch replaces a dispatch with one for the same agent and
+// room with new metadata, as dispatches cannot be updated in place.
+func updateAgentDispatch(ctx context.Context, cmd *cli.Command) error {
 	if cmd.Args().Len() == 0 {
 		return cli.ShowSubcommandHelp(cmd)
 	}
-
 	roomName := cmd.Args().First()
 	if roomName == "" {
-		return errors.New("room name is required")
+		return validationErrorf("room name is required")
 	}
 	id := cmd.Args().Get(1)
 	if id == "" {
-		return errors.New("dispatch ID is required")
+		return validationErrorf("dispatch ID is required")
+	}
+	if !cmd.IsSet("metadata") && !cmd.IsSet("metadata-file") {
+		return validationErrorf("metadata or metadata-file is required")
+	}
+	metadata, err := extractMetadata(cmd)
+	if err != nil {
+		return err
 	}
 
-	info, err := dispatchClient.DeleteDispatch(ctx, &livekit.DeleteAgentDispatchRequest{
-		Room:       roomName,
-		DispatchId: id,
-	})
+	targets, err := dispatchDeleteTargets(ctx, cmd, roomName, id)
 	if err != nil {
 		return err
 	}
+	existing := targets[0]
+	if metadata, err = keepDispatchLabels(existing.Metadata, metadata); err != nil {
+		return err
+	}
+
+	warnf("WARNING: dispatches cannot be updated in place, dispatch %s will be deleted and recreated with a new ID", id)
+	if _, err := withDispatchRetries(ctx, cmd, false, func(ctx context.Context) (*livekit.AgentDispatch, error) {
+		return dispatchClient.DeleteDispatch(ctx, &livekit.DeleteAgentDispatchRequest{
+			Room:       roomName,
+			DispatchId: id,
+		})
+	}); err != nil {
+		return err
+	}
+	info, err := withDispatchRetries(ctx, cmd, false, func(ctx context.Context) (*livekit.AgentDispatch, error) {
+		return dispatchClient.CreateDispatch(ctx, &livekit.CreateAgentDispatchRequest{
+			Room:      existing.Room,
+			AgentName: existing.AgentName,
+			Metadata:  metadata,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("dispatch %s was deleted but could not be recreated: %w", id, err)
+	}
 
 	if cmd.Bool("json") {
 		util.PrintJSON(info)
 	} else {
-		fmt.Printf("Dispatch deleted: %v\n", info)
+		fmt.Printf("Dispatch %s replaced by: %v\n", id, info)
 	}
 	return nil
 }
+
+func deleteAgentDispatch(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() == 0 {
+		return cli.ShowSubcommandHelp(cmd)
+	}
+
+	roomName := cmd.Args().First()
+	if roomName == "" {
+		return validationErrorf("room name is required")
+	}
+	id := cmd.Args().Get(1)
+	if cmd.Bool("all") && id != "" {
+		return validationErrorf("dispatch ID cannot be used with --all")
+	}
+	if !cmd.Bool("all") && id == "" {
+		return validationErrorf("dispatch ID or --all is required")
+	}
+
+	if cmd.Bool("dry-run") {
+		targets, err := dispatchDeleteTargets(ctx, cmd, roomName, id)
+		if err != nil {
+			return err
+		}
+		ids := make([]string, 0, len(targets))
+		for _, d := range targets {
+			ids = append(ids, d.Id)
+		}
+		if cmd.Bool("json") {
+			util.PrintJSON(map[string]any{
+				"room":        roomName,
+				"dispatchIds": ids,
+			})
+			return nil
+		}
+		if len(targets) == 0 {
+			fmt.Println("No dispatches would be deleted in room", roomName)
+			return nil
+		}
+		table := util.CreateTable().Headers("DispatchID", "Room", "AgentName", "Metadata")
+		for _, d := range targets {
+			table.Row(d.Id, d.Room, d.AgentName, d.Metadata)
+		}
+		fmt.Println(table)
+		fmt.Printf("Dry run, %d dispatch(es) would be deleted\n", len(targets))
+		return nil
+	}
+
+	if !cmd.Bool("yes") && (!isInteractive() || !stdoutIsTerminal()) {
+		return validationErrorf("refusing to delete without confirmation when not running interactively, use --yes")
+	}
+	ids := []string{id}
+	if cmd.Bool("all") || !cmd.Bool("yes") {
+		targets, err := dispatchDeleteTargets(ctx, cmd, roomName, id)
+		if err != nil {
+			return err
+		}
+		ids = ids[:0]
+		for _, d := range targets {
+			ids = append(ids, d.Id)
+		}
+		if len(targets) > 0 && !cmd.Bool("yes") {
+			if err := confirmDispatchDelete(roomName, targets); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(ids) == 0 && !cmd.Bool("json") {
+		fmt.Println("No dispatches to delete in room", roomName)
+	}
+	deleted := make([]*livekit.AgentDispatch, 0, len(ids))
+	for _, id := range ids {
+		info, err := withDispatchRetries(ctx, cmd, false, func(ctx context.Context) (*livekit.AgentDispatch, error) {
+			return dispatchClient.DeleteDispatch(ctx, &livekit.DeleteAgentDispatchRequest{
+				Room:       roomName,
+				DispatchId: id,
+			})
+		})
+		if err != nil {
+			return err
+		}
+		deleted = append(deleted, info)
+		if !cmd.Bool("json") {
+			fmt.Printf("Dispatch deleted: %v\n", info)
+		}
+	}
+
+	if cmd.Bool("json") {
+		if cmd.Bool("all") {
+			util.PrintJSON(deleted)
+		} else {
+			util.PrintJSON(deleted[0])
+		}
+	}
+	return nil
+}
+
+// confirmDispatchDelete asks before deleting the targets of a delete,
+// listing their IDs and agents.
+func confirmDispatchDelete(roomName string, targets []*livekit.AgentDispatch) error {
+	lines := make([]string, 0, len(targets))
+	for _, d := range targets {
+		lines = append(lines, fmt.Sprintf("%s (agent %s)", d.Id, d.AgentName))
+	}
+	confirmed := false
+	if err := huh.NewConfirm().
+		Title(fmt.Sprintf("Delete %d dispatch(es) in room %s?", len(targets), roomName)).
+		Description(strings.Join(lines, "\n")).
+		Value(&confirmed).
+		WithTheme(util.Theme).
+		Run(); err != nil {
+		return err
+	}
+	if !confirmed {
+		return errors.New("operation cancelled")
+	}
+	return nil
+}
+
+// dispatchDeleteTargets returns the dispatches of a room that a delete would
+// remove, all of them when id is empty.
+func dispatchDeleteTargets(ctx context.Context, cmd *cli.Command, roomName, id string) ([]*livekit.AgentDispatch, error) {
+	res, err := withDispatchRetries(ctx, cmd, true, func(ctx context.Context) (*livekit.ListAgentDispatchResponse, error) {
+		return dispatchClient.ListDispatch(ctx, &livekit.ListAgentDispatchRequest{
+			Room:       roomName,
+			DispatchId: id,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if id != "" && len(res.AgentDispatches) == 0 {
+		return nil, notFoundErrorf("dispatch %s not found in room %s", id, roomName)
+	}
+	return res.AgentDispatches, nil
+}
+
+// withDispatchRetries calls fn, retrying transient failures up to
+// --max-retries times with exponential backoff, each attempt bounded by
+// --timeout. Calls that are not idempotent are only retried when the
+// connection could not be established, so the request cannot have reached
+// the server.
+func withDispatchRetries[T any](ctx context.Context, cmd *cli.Command, idempotent bool, fn func(context.Context) (T, error)) (T, error) {
+	maxRetries := int(cmd.Int("max-retries"))
+	backoff := dispatchRetryBackoff
+	for attempt := 1; ; attempt++ {
+		res, err := callWithTimeout(ctx, cmd.Duration("timeout"), fn)
+		if err == nil {
+			return res, nil
+		}
+		if attempt > maxRetries || !isTransientError(ctx, err, idempotent) {
+			if attempt > 1 {
+				err = fmt.Errorf("failed after %d attempts: %w", attempt, err)
+			}
+			return res, err
+		}
+		infof("attempt %d failed, retrying in %s: %v", attempt, backoff, err)
+		select {
+		case <-ctx.Done():
+			return res, fmt.Errorf("failed after %d attempts: %w", attempt, err)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// callWithTimeout calls fn with a context that expires after timeout, or
+// without a deadline when timeout is 0.
+func callWithTimeout[T any](ctx context.Context, timeout time.Duration, fn func(context.Context) (T, error)) (T, error) {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+	rpcCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	res, err := fn(rpcCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(rpcCtx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("no response from server after %s, use --timeout to wait longer: %w", timeout, err)
+	}
+	return res, err
+}
+
+func isTransientError(ctx context.Context, err error, idempotent bool) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	if !idempotent {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var twErr twirp.Error
+	if errors.As(err, &twErr) {
+		switch twErr.Code() {
+		case twirp.Unavailable, twirp.DeadlineExceeded, twirp.ResourceExhausted:
+			return true
+		}
+	}
+	return false
+}
+
+// parseLabels parses KEY=VALUE pairs, each entry possibly holding several
+// separated by commas.
+func parseLabels(entries []string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, entry := range entries {
+		for _, pair := range strings.Split(entry, ",") {
+			k, v, ok := strings.Cut(pair, "=")
+			k = strings.TrimSpace(k)
+			if !ok || k == "" {
+				return nil, validationErrorf("invalid label %q, expected KEY=VALUE", pair)
+			}
+			labels[k] = strings.TrimSpace(v)
+		}
+	}
+	return labels, nil
+}
+
+// withDispatchLabels adds labels to dispatch metadata, which must be empty
+// or a JSON object.
+func withDispatchLabels(metadata string, labels map[string]string) (string, error) {
+	obj := map[string]any{}
+	if strings.TrimSpace(metadata) != "" {
+		if err := json.Unmarshal([]byte(metadata), &obj); err != nil {
+			return "", validationErrorf("--label requires --metadata to be a JSON object")
+		}
+	}
+	obj[dispatchLabelsKey] = labels
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// keepDispatchLabels carries the labels of a dispatch's old metadata over to
+// its new metadata.
+func keepDispatchLabels(oldMetadata, metadata string) (string, error) {
+	labels := dispatchLabels(oldMetadata)
+	if len(labels) == 0 {
+		return metadata, nil
+	}
+	merged, err := withDispatchLabels(metadata, labels)
+	if err != nil {
+		return "", validationErrorf("the dispatch has labels, which require the new metadata to be a JSON object")
+	}
+	return merged, nil
+}
+
+// dispatchLabels returns the labels stored in dispatch metadata, if any.
+func dispatchLabels(metadata string) map[string]string {
+	var obj struct {
+		Labels map[string]string `json:"_lk_labels"`
+	}
+	if err := json.Unmarshal([]byte(metadata), &obj); err != nil {
+		return nil
+	}
+	return obj.Labels
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestDispatchLabels(t *testing.T) {
+	labels, err := parseLabels([]string{"env=prod,team=voice", "owner=ci"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"env": "prod", "team": "voice", "owner": "ci"}
+	if !maps.Equal(labels, expected) {
+		t.Fatalf("parseLabels = %v, expected %v", labels, expected)
+	}
+	if _, err = parseLabels([]string{"env"}); err == nil {
+		t.Error("expected error for label without value")
+	}
+
+	metadata, err := withDispatchLabels(`{"prompt":"hi"}`, labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := dispatchLabels(metadata); !maps.Equal(actual, expected) {
+		t.Errorf("dispatchLabels = %v, expected %v", actual, expected)
+	}
+	if _, err = withDispatchLabels("plain text", labels); err == nil {
+		t.Error("expected error for metadata that is not a JSON object")
+	}
+	if actual := dispatchLabels("plain text"); actual != nil {
+		t.Errorf("expected no labels, got %v", actual)
+	}
+
+	// updating the metadata keeps the labels
+	updated, err := keepDispatchLabels(metadata, `{"prompt":"bye"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := dispatchLabels(updated); !maps.Equal(actual, expected) {
+		t.Errorf("keepDispatchLabels labels = %v, expected %v", actual, expected)
+	}
+	if _, err = keepDispatchLabels(metadata, "plain text"); err == nil {
+		t.Error("expected error for new metadata that is not a JSON object")
+	}
+	if updated, err = keepDispatchLabels(`{"prompt":"hi"}`, "plain text"); err != nil || updated != "plain text" {
+		t.Errorf("keepDispatchLabels without labels = %q, %v", updated, err)
+	}
+}
+
+func TestParseDispatchSpecs(t *testing.T) {
+	expected := []DispatchSpec{
+		{Room: "r1", AgentName: "a"},
+		{Room: "r2", Metadata: `{"k":"v"}`},
+	}
+	for _, content := range []string{
+		`[{"room": "r1", "agent_name": "a"}, {"room": "r2", "metadata": "{\"k\":\"v\"}"}]`,
+		"- room: r1\n  agent_name: a\n- room: r2\n  metadata: '{\"k\":\"v\"}'\n",
+	} {
+		specs, err := parseDispatchSpecs([]byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(specs, expected) {
+			t.Errorf("parseDispatchSpecs(%q) = %v, expected %v", content, specs, expected)
+		}
+	}
+	if _, err := parseDispatchSpecs([]byte(`{"room": "r1"}`)); err == nil {
+		t.Error("expected error for a dispatch that is not in an array")
+	}
+}
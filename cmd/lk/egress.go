@@ -15,13 +15,16 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"os/signal"
 	"reflect"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
@@ -35,6 +38,7 @@ import (
 	"github.com/livekit/protocol/livekit"
 	lksdk "github.com/livekit/server-sdk-go/v2"
 
+	"github.com/livekit/livekit-cli/pkg/config"
 	"github.com/livekit/livekit-cli/pkg/loadtester"
 	"github.com/livekit/livekit-cli/pkg/util"
 )
@@ -49,7 +53,19 @@ const (
 	EgressTypeWeb            egressType = "web"
 )
 
+// egressRetryWindow is how soon after starting an egress must fail to be
+// retried with --retry-on-failure. Later failures are not considered
+// transient, and already produced partial output.
+const egressRetryWindow = 2 * time.Minute
+
+var errEgressFailed = errors.New("failed")
+
 var (
+	roomCompositeLayouts = []string{
+		"grid", "grid-light", "grid-dark",
+		"speaker", "speaker-light", "speaker-dark",
+		"single-speaker", "single-speaker-light", "single-speaker-dark",
+	}
 	egressStartDescription = `Initiates a new egress of the chosen TYPE:
 	- "room-composite" composes multiple participant tracks into a single output stream
 	- "participant" captures a single participant
@@ -77,14 +93,106 @@ var (
 					Name:        "start",
 					Usage:       "Start egresses of various types",
 					Description: egressStartDescription,
-					Before:      createEgressClient,
 					Action:      handleEgressStart,
+					Commands: []*cli.Command{
+						{
+							Name:      "room-composite",
+							Usage:     "Record a room to a file, configured with flags instead of REQUEST_JSON",
+							UsageText: "lk egress start room-composite [OPTIONS] ROOM",
+							ArgsUsage: "ROOM",
+							Before:    createEgressClient,
+							Action:    startRoomCompositeFromFlags,
+							Flags: []cli.Flag{
+								&cli.StringFlag{
+									Name:  "layout",
+									Usage: "`LAYOUT` of the recording, one of " + strings.Join(util.MapStrings(roomCompositeLayouts, util.WrapWith("\"")), ", "),
+								},
+								&cli.StringFlag{
+									Name:     "output",
+									Usage:    "Where to write the recording, a file `PATH` or a file://, s3://BUCKET/KEY or gs://BUCKET/KEY URL. Upload credentials are read from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_REGION, or GOOGLE_APPLICATION_CREDENTIALS, when set, otherwise those of the egress server are used",
+									Required: true,
+								},
+								&cli.BoolFlag{
+									Name:  "audio-only",
+									Usage: "Record only the audio of the room",
+								},
+								&cli.BoolFlag{
+									Name:  "video-only",
+									Usage: "Record only the video of the room",
+								},
+								&cli.StringFlag{
+									Name:  "preset",
+									Usage: "Encoding `PRESET`, one of " + strings.Join(util.MapStrings(egressPresetNames(), util.WrapWith("\"")), ", "),
+								},
+								&cli.BoolFlag{
+									Name:  "await-first-participant",
+									Usage: "Wait for a participant to publish in the room before starting the egress",
+								},
+								&cli.DurationFlag{
+									Name:  "await-timeout",
+									Usage: "Maximum `TIME` to wait with --await-first-participant, exiting with status 3 when it elapses",
+									Value: 5 * time.Minute,
+								},
+								jsonFlag,
+								&cli.BoolFlag{
+									Name:  "wait",
+									Usage: "Wait for the egress to end before returning, exiting with an error if it fails",
+								},
+								&cli.DurationFlag{
+									Name:  "wait-timeout",
+									Usage: "Maximum `TIME` to wait with --wait, unlimited by default",
+								},
+							},
+						},
+					},
 					Flags: []cli.Flag{
 						&cli.StringFlag{
 							Name:  "type",
 							Usage: "Specify `TYPE` of egress (see above)",
 							Value: string(EgressTypeRoomComposite),
 						},
+						&cli.StringFlag{
+							Name:  "layout",
+							Usage: "`LAYOUT` of a room-composite egress, overriding the request, one of " + strings.Join(util.MapStrings(roomCompositeLayouts, util.WrapWith("\"")), ", "),
+						},
+						&cli.BoolFlag{
+							Name:  "await-first-participant",
+							Usage: "Wait for a participant to publish in the room before starting a room-composite egress",
+						},
+						&cli.DurationFlag{
+							Name:  "await-timeout",
+							Usage: "Maximum `TIME` to wait with --await-first-participant, exiting with status 3 when it elapses",
+							Value: 5 * time.Minute,
+						},
+						&cli.BoolFlag{
+							Name:    "json",
+							Aliases: []string{"j"},
+							Usage:   "Output only a JSON handle, {\"egressId\": ..., \"room\": ...}, for use in scripts",
+						},
+						&cli.BoolFlag{
+							Name:  "wait",
+							Usage: "Wait for the egress to end before returning, exiting with an error if it fails",
+						},
+						&cli.DurationFlag{
+							Name:  "wait-timeout",
+							Usage: "Maximum `TIME` to wait with --wait, unlimited by default",
+						},
+						&cli.BoolFlag{
+							Name:  "on-failure-cleanup",
+							Usage: "When the egress fails, list its partial outputs and remove it from the history of egress recent (requires --wait)",
+						},
+						&cli.IntFlag{
+							Name:  "retry-on-failure",
+							Usage: "Start the egress again up to `N` times, with backoff, when it fails shortly after starting (requires --wait)",
+						},
+						&cli.StringFlag{
+							Name:  "copy-from",
+							Usage: "Start a new egress with the request of `EGRESS_ID` instead of REQUEST_JSON",
+						},
+						&cli.StringFlag{
+							Name:  "room",
+							Usage: "`NAME` of the room to use instead of the original one with --copy-from",
+						},
 					},
 					ArgsUsage: "REQUEST_JSON",
 				},
@@ -107,19 +215,90 @@ var (
 							Aliases: []string{"a"},
 							Usage:   "Lists only active egresses",
 						},
+						&cli.StringFlag{
+							Name:  "sort-by",
+							Usage: "Sort egresses by `FIELD` (\"started\", \"ended\", or \"updated\"), entries without a timestamp are listed last",
+						},
+						&cli.BoolFlag{
+							Name:  "sort-desc",
+							Usage: "Sort in descending order, most recent first (requires --sort-by)",
+						},
+						&cli.IntFlag{
+							Name:  "limit",
+							Usage: "Show at most `N` egresses, applied after sorting",
+						},
+						listJSONFlag,
+						outputFlag,
+						templateFlag,
+						jsonPathFlag,
+						countOnlyFlag,
+					},
+				},
+				{
+					Name:   "recent",
+					Usage:  "List egresses recently started from this machine",
+					Action: listRecentEgress,
+					Flags: []cli.Flag{
+						&cli.IntFlag{
+							Name:  "limit",
+							Usage: "Show at most `N` egresses, most recent first",
+							Value: 10,
+						},
 						jsonFlag,
 					},
 				},
 				{
-					Name:   "stop",
-					Usage:  "Stop an active egress",
-					Before: createEgressClient,
-					Action: stopEgress,
+					Name:      "stop",
+					Usage:     "Stop an active egress",
+					UsageText: "lk egress stop [OPTIONS] EGRESS_ID...",
+					ArgsUsage: "EGRESS_ID...",
+					Before:    createEgressClient,
+					Action:    stopEgress,
 					Flags: []cli.Flag{
 						&cli.StringSliceFlag{
-							Name:     "id",
-							Usage:    "Egress ID to stop, can be specified multiple times",
-							Required: true,
+							Name:  "id",
+							Usage: "Egress ID to stop, instead of EGRESS_ID, can be specified multiple times",
+						},
+						&cli.BoolFlag{
+							Name:  "wait",
+							Usage: "Wait for egresses to finalize their output before returning",
+						},
+						&cli.DurationFlag{
+							Name:  "timeout",
+							Usage: "Maximum `TIME` to wait for each egress to finalize (requires --wait)",
+							Value: 5 * time.Minute,
+						},
+						jsonFlag,
+					},
+				},
+				{
+					Name:      "track",
+					Usage:     "Record or stream a single track without transcoding, prompting for the track when TRACK_SID is omitted",
+					UsageText: "lk egress track [OPTIONS] ROOM [TRACK_SID]",
+					ArgsUsage: "ROOM [TRACK_SID]",
+					Before:    createEgressClient,
+					Action:    startTrackEgressFromFlags,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "output",
+							Usage: "Where to write the track, a file `PATH` or a file://, s3://BUCKET/KEY or gs://BUCKET/KEY URL, with credentials as for \"egress start room-composite\"",
+						},
+						&cli.StringFlag{
+							Name:  "websocket-url",
+							Usage: "Stream the raw track to a websocket `URL` instead of writing it to --output (audio tracks only)",
+						},
+						&cli.StringFlag{
+							Name:  "identity",
+							Usage: "Only offer the tracks of the participant with `IDENTITY` when prompting for the track",
+						},
+						jsonFlag,
+						&cli.BoolFlag{
+							Name:  "wait",
+							Usage: "Wait for the egress to end before returning, exiting with an error if it fails",
+						},
+						&cli.DurationFlag{
+							Name:  "wait-timeout",
+							Usage: "Maximum `TIME` to wait with --wait, unlimited by default",
 						},
 					},
 				},
@@ -387,11 +566,72 @@ func createEgressClient(ctx context.Context, cmd *cli.Command) (context.Context,
 		return nil, err
 	}
 
+	project = pc
 	egressClient = lksdk.NewEgressClient(pc.URL, pc.APIKey, pc.APISecret, withDefaultClientOpts(pc)...)
 	return nil, nil
 }
 
+func waitForPublisher(ctx context.Context, roomName string, timeout time.Duration) (*livekit.ParticipantInfo, error) {
+	roomClient := lksdk.NewRoomServiceClient(project.URL, project.APIKey, project.APISecret, withDefaultClientOpts(project)...)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		res, err := roomClient.ListParticipants(ctx, &livekit.ListParticipantsRequest{
+			Room: roomName,
+		})
+		if err == nil {
+			for _, p := range res.Participants {
+				if p.IsPublisher || len(p.Tracks) > 0 {
+					return p, nil
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out after %s waiting for a participant to publish in room %s", timeout, roomName)
+		case <-ticker.C:
+		}
+	}
+}
+
 func handleEgressStart(ctx context.Context, cmd *cli.Command) error {
+	// created here rather than in Before, which would also run ahead of the
+	// subcommands and their own flags
+	if _, err := createEgressClient(ctx, cmd); err != nil {
+		return err
+	}
+	if cmd.IsSet("layout") {
+		if cmd.String("type") != string(EgressTypeRoomComposite) {
+			return validationErrorf("--layout can only be used with room-composite egresses")
+		}
+		if !slices.Contains(roomCompositeLayouts, cmd.String("layout")) {
+			return validationErrorf("unrecognized layout %q, must be one of: %s", cmd.String("layout"), strings.Join(roomCompositeLayouts, ", "))
+		}
+	}
+	if cmd.Bool("await-first-participant") && cmd.String("type") != string(EgressTypeRoomComposite) {
+		return validationErrorf("--await-first-participant can only be used with room-composite egresses")
+	}
+	if cmd.Bool("on-failure-cleanup") && !cmd.Bool("wait") {
+		return validationErrorf("--on-failure-cleanup requires --wait")
+	}
+	if cmd.Int("retry-on-failure") < 0 {
+		return validationErrorf("--retry-on-failure cannot be negative")
+	}
+	if cmd.Int("retry-on-failure") > 0 && !cmd.Bool("wait") {
+		warnf("WARNING: --retry-on-failure has no effect without --wait")
+	}
+	if cmd.IsSet("copy-from") {
+		return startCopiedEgress(ctx, cmd)
+	}
+	if cmd.IsSet("room") {
+		return validationErrorf("--room can only be used with --copy-from")
+	}
+
 	switch cmd.String("type") {
 	case string(EgressTypeRoomComposite):
 		return startRoomCompositeEgress(ctx, cmd)
@@ -414,16 +654,299 @@ func startRoomCompositeEgress(ctx context.Context, cmd *cli.Command) error {
 	if err != nil {
 		return err
 	}
+	if layout := cmd.String("layout"); layout != "" {
+		req.Layout = layout
+	}
 
-	info, err := egressClient.StartRoomCompositeEgress(ctx, req)
+	if err = awaitFirstParticipant(ctx, cmd, req.RoomName); err != nil {
+		return err
+	}
+
+	return startEgress(ctx, cmd, func() (*livekit.EgressInfo, error) {
+		return egressClient.StartRoomCompositeEgress(ctx, req)
+	})
+}
+
+// startRoomCompositeFromFlags starts a room-composite egress recording to a
+// single file, with the request built from flags.
+func startRoomCompositeFromFlags(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() == 0 {
+		return cli.ShowSubcommandHelp(cmd)
+	}
+	roomName := cmd.Args().First()
+	if roomName == "" {
+		return validationErrorf("room name is required")
+	}
+	if cmd.Bool("audio-only") && cmd.Bool("video-only") {
+		return validationErrorf("only one of --audio-only or --video-only can be set")
+	}
+	layout := cmd.String("layout")
+	if layout != "" && !slices.Contains(roomCompositeLayouts, layout) {
+		return validationErrorf("unrecognized layout %q, must be one of: %s", layout, strings.Join(roomCompositeLayouts, ", "))
+	}
+	output, err := egressFileOutput(cmd.String("output"))
 	if err != nil {
+		return validationErrorf("invalid --output: %w", err)
+	}
+
+	req := &livekit.RoomCompositeEgressRequest{
+		RoomName:    roomName,
+		Layout:      layout,
+		AudioOnly:   cmd.Bool("audio-only"),
+		VideoOnly:   cmd.Bool("video-only"),
+		FileOutputs: []*livekit.EncodedFileOutput{output},
+	}
+	if p := cmd.String("preset"); p != "" {
+		preset, ok := livekit.EncodingOptionsPreset_value[strings.ToUpper(strings.ReplaceAll(p, "-", "_"))]
+		if !ok {
+			return validationErrorf("unrecognized preset %q, must be one of: %s", p, strings.Join(egressPresetNames(), ", "))
+		}
+		req.Options = &livekit.RoomCompositeEgressRequest_Preset{Preset: livekit.EncodingOptionsPreset(preset)}
+	}
+	debugJSON(req)
+
+	if err = awaitFirstParticipant(ctx, cmd, roomName); err != nil {
 		return err
 	}
+	return startEgress(ctx, cmd, func() (*livekit.EgressInfo, error) {
+		return egressClient.StartRoomCompositeEgress(ctx, req)
+	})
+}
 
-	printInfo(info)
+// startTrackEgressFromFlags starts a track egress with the request built
+// from flags.
+func startTrackEgressFromFlags(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() == 0 {
+		return cli.ShowSubcommandHelp(cmd)
+	}
+	roomName := cmd.Args().First()
+	if roomName == "" {
+		return validationErrorf("room name is required")
+	}
+	if cmd.IsSet("output") == cmd.IsSet("websocket-url") {
+		return validationErrorf("exactly one of --output or --websocket-url is required")
+	}
+
+	req := &livekit.TrackEgressRequest{
+		RoomName: roomName,
+		TrackId:  cmd.Args().Get(1),
+	}
+	if output := cmd.String("output"); output != "" {
+		file, err := egressDirectFileOutput(output)
+		if err != nil {
+			return validationErrorf("invalid --output: %w", err)
+		}
+		req.Output = &livekit.TrackEgressRequest_File{File: file}
+	} else {
+		req.Output = &livekit.TrackEgressRequest_WebsocketUrl{WebsocketUrl: cmd.String("websocket-url")}
+	}
+
+	if req.TrackId == "" {
+		if !isInteractive() {
+			return validationErrorf("TRACK_SID is required when not running interactively")
+		}
+		rc := lksdk.NewRoomServiceClient(project.URL, project.APIKey, project.APISecret, withDefaultClientOpts(project)...)
+		sid, err := selectTrack(ctx, rc, roomName, cmd.String("identity"), false)
+		if err != nil {
+			return err
+		}
+		req.TrackId = sid
+	}
+	debugJSON(req)
+
+	return startEgress(ctx, cmd, func() (*livekit.EgressInfo, error) {
+		return egressClient.StartTrackEgress(ctx, req)
+	})
+}
+
+// egressPresetNames lists the encoding presets accepted by --preset.
+func egressPresetNames() []string {
+	names := make([]string, 0, len(livekit.EncodingOptionsPreset_name))
+	for i := range int32(len(livekit.EncodingOptionsPreset_name)) {
+		names = append(names, strings.ToLower(livekit.EncodingOptionsPreset_name[i]))
+	}
+	return names
+}
+
+// egressFileOutput converts --output to a file output, uploaded to S3 or
+// Google Cloud Storage for s3:// and gs:// URLs, using credentials from the
+// environment when set.
+func egressFileOutput(output string) (*livekit.EncodedFileOutput, error) {
+	u, err := url.Parse(output)
+	// single letters are Windows drive letters rather than schemes
+	if err != nil || len(u.Scheme) <= 1 {
+		return &livekit.EncodedFileOutput{Filepath: output}, nil
+	}
+
+	// the egress server fills in the placeholders of the default key
+	key := cmp.Or(strings.TrimPrefix(u.Path, "/"), "{room_name}-{time}")
+	switch u.Scheme {
+	case "file":
+		// file://rec.mp4 would put the file name in the host
+		if u.Host != "" || u.Path == "" {
+			return nil, fmt.Errorf("invalid file URL %s, use file:///absolute/path or a plain file path", output)
+		}
+		return &livekit.EncodedFileOutput{Filepath: u.Path}, nil
+	case "s3":
+		if u.Host == "" {
+			return nil, errors.New("missing bucket in " + output)
+		}
+		return &livekit.EncodedFileOutput{
+			Filepath: key,
+			Output: &livekit.EncodedFileOutput_S3{S3: &livekit.S3Upload{
+				Bucket:       u.Host,
+				AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+				Secret:       os.Getenv("AWS_SECRET_ACCESS_KEY"),
+				SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+				Region:       os.Getenv("AWS_REGION"),
+			}},
+		}, nil
+	case "gs", "gcs":
+		if u.Host == "" {
+			return nil, errors.New("missing bucket in " + output)
+		}
+		upload := &livekit.GCPUpload{Bucket: u.Host}
+		if file := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); file != "" {
+			credentials, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			upload.Credentials = string(credentials)
+		}
+		return &livekit.EncodedFileOutput{
+			Filepath: key,
+			Output:   &livekit.EncodedFileOutput_Gcp{Gcp: upload},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q, must be a file path or a file://, s3:// or gs:// URL", u.Scheme)
+	}
+}
+
+// egressDirectFileOutput converts --output to the output of a track egress,
+// which is written without transcoding.
+func egressDirectFileOutput(output string) (*livekit.DirectFileOutput, error) {
+	encoded, err := egressFileOutput(output)
+	if err != nil {
+		return nil, err
+	}
+	file := &livekit.DirectFileOutput{Filepath: encoded.Filepath}
+	switch o := encoded.Output.(type) {
+	case *livekit.EncodedFileOutput_S3:
+		file.Output = &livekit.DirectFileOutput_S3{S3: o.S3}
+	case *livekit.EncodedFileOutput_Gcp:
+		file.Output = &livekit.DirectFileOutput_Gcp{Gcp: o.Gcp}
+	}
+	return file, nil
+}
+
+func awaitFirstParticipant(ctx context.Context, cmd *cli.Command, roomName string) error {
+	if !cmd.Bool("await-first-participant") {
+		return nil
+	}
+	progress := progressWriter(cmd)
+	fmt.Fprintln(progress, "Waiting for a participant to publish in room", roomName)
+	start := time.Now()
+	publisher, err := waitForPublisher(ctx, roomName, cmd.Duration("await-timeout"))
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return cli.Exit(err, exitCodeAwaitTimeout)
+	}
+	fmt.Fprintf(progress, "Participant %s is publishing, waited %s\n", publisher.Identity, time.Since(start).Round(time.Millisecond))
 	return nil
 }
 
+// startCopiedEgress starts a new egress using the request that started the
+// egress given by --copy-from, so a previous recording can be re-run as is.
+func startCopiedEgress(ctx context.Context, cmd *cli.Command) error {
+	if cmd.NArg() > 0 {
+		return validationErrorf("--copy-from cannot be used with REQUEST_JSON")
+	}
+	sourceID := cmd.String("copy-from")
+	res, err := egressClient.ListEgress(ctx, &livekit.ListEgressRequest{EgressId: sourceID})
+	if err != nil {
+		return err
+	}
+	if len(res.Items) == 0 {
+		return fmt.Errorf("egress %s not found", sourceID)
+	}
+	source := res.Items[0]
+
+	sourceType := egressRequestType(source)
+	if sourceType == "" {
+		return fmt.Errorf("egress %s has no request to copy", sourceID)
+	}
+	if cmd.IsSet("type") && cmd.String("type") != string(sourceType) {
+		return fmt.Errorf("egress %s is a %s egress, not %s", sourceID, sourceType, cmd.String("type"))
+	}
+	if cmd.IsSet("layout") && sourceType != EgressTypeRoomComposite {
+		return validationErrorf("--layout can only be used with room-composite egresses")
+	}
+	if cmd.Bool("await-first-participant") && sourceType != EgressTypeRoomComposite {
+		return validationErrorf("--await-first-participant can only be used with room-composite egresses")
+	}
+	roomName := cmd.String("room")
+
+	var start func() (*livekit.EgressInfo, error)
+	switch req := source.Request.(type) {
+	case *livekit.EgressInfo_RoomComposite:
+		r := req.RoomComposite
+		if roomName != "" {
+			r.RoomName = roomName
+		}
+		if layout := cmd.String("layout"); layout != "" {
+			r.Layout = layout
+		}
+		if err = awaitFirstParticipant(ctx, cmd, r.RoomName); err != nil {
+			return err
+		}
+		start = func() (*livekit.EgressInfo, error) { return egressClient.StartRoomCompositeEgress(ctx, r) }
+	case *livekit.EgressInfo_Web:
+		if roomName != "" {
+			return validationErrorf("--room cannot be used when copying a web egress")
+		}
+		start = func() (*livekit.EgressInfo, error) { return egressClient.StartWebEgress(ctx, req.Web) }
+	case *livekit.EgressInfo_Participant:
+		r := req.Participant
+		if roomName != "" {
+			r.RoomName = roomName
+		}
+		start = func() (*livekit.EgressInfo, error) { return egressClient.StartParticipantEgress(ctx, r) }
+	case *livekit.EgressInfo_TrackComposite:
+		r := req.TrackComposite
+		if roomName != "" {
+			r.RoomName = roomName
+		}
+		start = func() (*livekit.EgressInfo, error) { return egressClient.StartTrackCompositeEgress(ctx, r) }
+	case *livekit.EgressInfo_Track:
+		r := req.Track
+		if roomName != "" {
+			r.RoomName = roomName
+		}
+		start = func() (*livekit.EgressInfo, error) { return egressClient.StartTrackEgress(ctx, r) }
+	}
+
+	return startEgress(ctx, cmd, start)
+}
+
+func egressRequestType(info *livekit.EgressInfo) egressType {
+	switch info.Request.(type) {
+	case *livekit.EgressInfo_RoomComposite:
+		return EgressTypeRoomComposite
+	case *livekit.EgressInfo_Web:
+		return EgressTypeWeb
+	case *livekit.EgressInfo_Participant:
+		return EgressTypeParticipant
+	case *livekit.EgressInfo_TrackComposite:
+		return EgressTypeTrackComposite
+	case *livekit.EgressInfo_Track:
+		return EgressTypeTrack
+	default:
+		return ""
+	}
+}
+
 func _deprecatedStartRoomCompositeEgress(ctx context.Context, cmd *cli.Command) error {
 	req := &livekit.RoomCompositeEgressRequest{}
 	if err := unmarshalEgressRequest(cmd, req); err != nil {
@@ -435,7 +958,7 @@ func _deprecatedStartRoomCompositeEgress(ctx context.Context, cmd *cli.Command)
 		return err
 	}
 
-	printInfo(info)
+	printStartedEgress(cmd, info)
 	return nil
 }
 
@@ -445,13 +968,9 @@ func startWebEgress(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
-	info, err := egressClient.StartWebEgress(ctx, req)
-	if err != nil {
-		return err
-	}
-
-	printInfo(info)
-	return nil
+	return startEgress(ctx, cmd, func() (*livekit.EgressInfo, error) {
+		return egressClient.StartWebEgress(ctx, req)
+	})
 }
 
 func _deprecatedStartWebEgress(ctx context.Context, cmd *cli.Command) error {
@@ -465,7 +984,7 @@ func _deprecatedStartWebEgress(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
-	printInfo(info)
+	printStartedEgress(cmd, info)
 	return nil
 }
 
@@ -475,13 +994,9 @@ func startParticipantEgress(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
-	info, err := egressClient.StartParticipantEgress(ctx, req)
-	if err != nil {
-		return err
-	}
-
-	printInfo(info)
-	return nil
+	return startEgress(ctx, cmd, func() (*livekit.EgressInfo, error) {
+		return egressClient.StartParticipantEgress(ctx, req)
+	})
 }
 
 func _deprecatedStartParticipantEgress(ctx context.Context, cmd *cli.Command) error {
@@ -495,7 +1010,7 @@ func _deprecatedStartParticipantEgress(ctx context.Context, cmd *cli.Command) er
 		return err
 	}
 
-	printInfo(info)
+	printStartedEgress(cmd, info)
 	return nil
 }
 
@@ -505,13 +1020,9 @@ func startTrackCompositeEgress(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
-	info, err := egressClient.StartTrackCompositeEgress(ctx, req)
-	if err != nil {
-		return err
-	}
-
-	printInfo(info)
-	return nil
+	return startEgress(ctx, cmd, func() (*livekit.EgressInfo, error) {
+		return egressClient.StartTrackCompositeEgress(ctx, req)
+	})
 }
 
 func _deprecatedStartTrackCompositeEgress(ctx context.Context, cmd *cli.Command) error {
@@ -525,7 +1036,7 @@ func _deprecatedStartTrackCompositeEgress(ctx context.Context, cmd *cli.Command)
 		return err
 	}
 
-	printInfo(info)
+	printStartedEgress(cmd, info)
 	return nil
 }
 
@@ -535,13 +1046,9 @@ func startTrackEgress(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
-	info, err := egressClient.StartTrackEgress(ctx, req)
-	if err != nil {
-		return err
-	}
-
-	printInfo(info)
-	return nil
+	return startEgress(ctx, cmd, func() (*livekit.EgressInfo, error) {
+		return egressClient.StartTrackEgress(ctx, req)
+	})
 }
 
 func _deprecatedStartTrackEgress(ctx context.Context, cmd *cli.Command) error {
@@ -555,7 +1062,7 @@ func _deprecatedStartTrackEgress(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
-	printInfo(info)
+	printStartedEgress(cmd, info)
 	return nil
 }
 
@@ -568,13 +1075,23 @@ func unmarshalEgressRequest(cmd *cli.Command, req proto.Message) error {
 		return err
 	}
 
-	if cmd.Bool("verbose") {
-		util.PrintJSON(req)
-	}
+	debugJSON(req)
 	return nil
 }
 
 func listEgress(ctx context.Context, cmd *cli.Command) error {
+	jsonPath, err := jsonPathOption(cmd)
+	if err != nil {
+		return err
+	}
+	output, tmpl, err := listOutput(cmd)
+	if err != nil {
+		return err
+	}
+	if err := checkEgressOrder(cmd); err != nil {
+		return err
+	}
+
 	var items []*livekit.EgressInfo
 	if cmd.IsSet("id") {
 		for _, id := range cmd.StringSlice("id") {
@@ -597,53 +1114,149 @@ func listEgress(ctx context.Context, cmd *cli.Command) error {
 		items = res.Items
 	}
 
-	if cmd.Bool("json") {
-		util.PrintJSON(items)
-	} else {
-		table := util.CreateTable().
-			Headers("EgressID", "Status", "Type", "Source", "Started At", "Error")
-		for _, item := range items {
-			var startedAt string
-			if item.StartedAt != 0 {
-				startedAt = fmt.Sprint(time.Unix(0, item.StartedAt))
-			}
-			var egressType, egressSource string
-			switch req := item.Request.(type) {
-			case *livekit.EgressInfo_RoomComposite:
-				egressType = "room_composite"
-				egressSource = req.RoomComposite.RoomName
-			case *livekit.EgressInfo_Web:
-				egressType = "web"
-				egressSource = req.Web.Url
-			case *livekit.EgressInfo_Participant:
-				egressType = "participant"
-				egressSource = fmt.Sprintf("%s/%s", req.Participant.RoomName, req.Participant.Identity)
-			case *livekit.EgressInfo_TrackComposite:
-				egressType = "track_composite"
-				trackIDs := make([]string, 0)
-				if req.TrackComposite.VideoTrackId != "" {
-					trackIDs = append(trackIDs, req.TrackComposite.VideoTrackId)
-				}
-				if req.TrackComposite.AudioTrackId != "" {
-					trackIDs = append(trackIDs, req.TrackComposite.AudioTrackId)
-				}
-				egressSource = fmt.Sprintf("%s/%s", req.TrackComposite.RoomName, strings.Join(trackIDs, ","))
-			case *livekit.EgressInfo_Track:
-				egressType = "track"
-				egressSource = fmt.Sprintf("%s/%s", req.Track.RoomName, req.Track.TrackId)
-			}
-			table.Row(
-				item.EgressId,
-				item.Status.String(),
-				egressType,
-				egressSource,
-				startedAt,
-				item.Error,
-			)
+	items, err = orderEgressItems(cmd, items)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Bool("count-only") {
+		printCount(cmd, len(items))
+		return nil
+	}
+	if jsonPath != nil {
+		return printJSONPath(jsonPath, items)
+	}
+
+	header := []string{"EgressID", "Status", "Type", "Source", "Output", "Started At", "Error"}
+	return printList(output, tmpl, items, items, header, func(item *livekit.EgressInfo) []string {
+		var startedAt string
+		if item.StartedAt != 0 {
+			startedAt = fmt.Sprint(time.Unix(0, item.StartedAt))
+		}
+		egressType, egressSource := describeEgress(item)
+		return []string{
+			item.EgressId,
+			item.Status.String(),
+			egressType,
+			egressSource,
+			strings.Join(egressOutputs(item), "\n"),
+			startedAt,
+			item.Error,
+		}
+	})
+}
+
+// egressOutputs lists where an egress writes to, once it has started.
+func egressOutputs(info *livekit.EgressInfo) []string {
+	var outputs []string
+	for _, f := range info.FileResults {
+		outputs = append(outputs, cmp.Or(f.Location, f.Filename))
+	}
+	for _, s := range info.StreamResults {
+		outputs = append(outputs, s.Url)
+	}
+	for _, seg := range info.SegmentResults {
+		outputs = append(outputs, cmp.Or(seg.PlaylistLocation, seg.PlaylistName))
+	}
+	for _, img := range info.ImageResults {
+		outputs = append(outputs, img.FilenamePrefix)
+	}
+	return outputs
+}
+
+// describeEgress returns the type of an egress and a short description of
+// what it is capturing.
+func describeEgress(info *livekit.EgressInfo) (egressType, egressSource string) {
+	switch req := info.Request.(type) {
+	case *livekit.EgressInfo_RoomComposite:
+		egressType = "room_composite"
+		egressSource = req.RoomComposite.RoomName
+	case *livekit.EgressInfo_Web:
+		egressType = "web"
+		egressSource = req.Web.Url
+	case *livekit.EgressInfo_Participant:
+		egressType = "participant"
+		egressSource = fmt.Sprintf("%s/%s", req.Participant.RoomName, req.Participant.Identity)
+	case *livekit.EgressInfo_TrackComposite:
+		egressType = "track_composite"
+		trackIDs := make([]string, 0)
+		if req.TrackComposite.VideoTrackId != "" {
+			trackIDs = append(trackIDs, req.TrackComposite.VideoTrackId)
+		}
+		if req.TrackComposite.AudioTrackId != "" {
+			trackIDs = append(trackIDs, req.TrackComposite.AudioTrackId)
+		}
+		egressSource = fmt.Sprintf("%s/%s", req.TrackComposite.RoomName, strings.Join(trackIDs, ","))
+	case *livekit.EgressInfo_Track:
+		egressType = "track"
+		egressSource = fmt.Sprintf("%s/%s", req.Track.RoomName, req.Track.TrackId)
+	}
+	return
+}
+
+// checkEgressOrder validates --sort-by, --sort-desc and --limit, so that bad
+// values are reported before anything is fetched.
+func checkEgressOrder(cmd *cli.Command) error {
+	switch sortBy := cmd.String("sort-by"); sortBy {
+	case "", "started", "ended", "updated":
+	default:
+		return validationErrorf("unrecognized sort field %s", util.WrapWith("\"")(sortBy))
+	}
+	if cmd.Bool("sort-desc") && cmd.String("sort-by") == "" {
+		return validationErrorf("--sort-desc requires --sort-by")
+	}
+	if cmd.Int("limit") < 0 {
+		return validationErrorf("--limit cannot be negative")
+	}
+	return nil
+}
+
+// orderEgressItems sorts items by --sort-by and keeps at most --limit of them.
+func orderEgressItems(cmd *cli.Command, items []*livekit.EgressInfo) ([]*livekit.EgressInfo, error) {
+	if err := checkEgressOrder(cmd); err != nil {
+		return nil, err
+	}
+	if sortBy := cmd.String("sort-by"); sortBy != "" {
+		if err := sortEgressItems(items, sortBy, cmd.Bool("sort-desc")); err != nil {
+			return nil, err
 		}
-		fmt.Println(table)
 	}
+	if limit := int(cmd.Int("limit")); limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
 
+// sortEgressItems sorts items in place by the given timestamp field. Items
+// missing that timestamp are always placed last, regardless of direction.
+func sortEgressItems(items []*livekit.EgressInfo, sortBy string, desc bool) error {
+	var key func(item *livekit.EgressInfo) int64
+	switch sortBy {
+	case "started":
+		key = (*livekit.EgressInfo).GetStartedAt
+	case "ended":
+		key = (*livekit.EgressInfo).GetEndedAt
+	case "updated":
+		key = (*livekit.EgressInfo).GetUpdatedAt
+	default:
+		return errors.New("unrecognized sort field " + util.WrapWith("\"")(sortBy))
+	}
+
+	slices.SortStableFunc(items, func(a, b *livekit.EgressInfo) int {
+		ka, kb := key(a), key(b)
+		switch {
+		case ka == 0 && kb == 0:
+			return 0
+		case ka == 0:
+			return 1
+		case kb == 0:
+			return -1
+		case desc:
+			return cmp.Compare(kb, ka)
+		default:
+			return cmp.Compare(ka, kb)
+		}
+	})
 	return nil
 }
 
@@ -683,23 +1296,114 @@ func updateStream(ctx context.Context, cmd *cli.Command) error {
 }
 
 func stopEgress(ctx context.Context, cmd *cli.Command) error {
-	ids := cmd.StringSlice("id")
-	var errors []error
+	ids := append(cmd.StringSlice("id"), cmd.Args().Slice()...)
+	if len(ids) == 0 {
+		_ = cli.ShowSubcommandHelp(cmd)
+		return validationErrorf("EGRESS_ID is required")
+	}
 	for _, id := range ids {
-		_, err := egressClient.StopEgress(ctx, &livekit.StopEgressRequest{
+		if !strings.HasPrefix(id, "EG_") {
+			return validationErrorf("invalid egress ID %q, expected an ID starting with EG_", id)
+		}
+	}
+
+	progress := progressWriter(cmd)
+	var errs []error
+	var stopped []*livekit.EgressInfo
+	for _, id := range ids {
+		info, err := egressClient.StopEgress(ctx, &livekit.StopEgressRequest{
 			EgressId: id,
 		})
 		if err != nil {
-			errors = append(errors, err)
-			fmt.Println("Error stopping Egress", id, err)
+			// e.g. when the egress has already ended
+			errs = append(errs, fmt.Errorf("could not stop egress %s: %w", id, err))
 		} else {
-			fmt.Println("Stopping Egress", id)
+			fmt.Fprintln(progress, "Stopping Egress", id)
+			stopped = append(stopped, info)
 		}
 	}
-	if len(errors) != 0 {
-		return errors[0]
+	if cmd.Bool("wait") {
+		for i, info := range stopped {
+			final, err := waitForEgress(ctx, info.EgressId, cmd.Duration("timeout"))
+			if final != nil {
+				stopped[i] = final
+				if !cmd.Bool("json") {
+					printEgressResults(final)
+				}
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if cmd.Bool("json") {
+		util.PrintJSON(stopped)
+	}
+	return errors.Join(errs...)
+}
+
+func isEgressTerminal(status livekit.EgressStatus) bool {
+	switch status {
+	case livekit.EgressStatus_EGRESS_COMPLETE,
+		livekit.EgressStatus_EGRESS_FAILED,
+		livekit.EgressStatus_EGRESS_ABORTED,
+		livekit.EgressStatus_EGRESS_LIMIT_REACHED:
+		return true
+	default:
+		return false
+	}
+}
+
+// waitForEgress polls an egress until it reaches a terminal state. On timeout,
+// the last known info is returned along with an error. A timeout of zero
+// waits until ctx is done.
+func waitForEgress(ctx context.Context, id string, timeout time.Duration) (*livekit.EgressInfo, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	var info *livekit.EgressInfo
+	for {
+		res, err := egressClient.ListEgress(ctx, &livekit.ListEgressRequest{
+			EgressId: id,
+		})
+		if err == nil && len(res.Items) > 0 {
+			info = res.Items[0]
+			if isEgressTerminal(info.Status) {
+				return info, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if info != nil {
+				return info, fmt.Errorf("timed out waiting for egress %s, last status %s", id, info.Status)
+			}
+			if err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("timed out waiting for egress %s", id)
+		case <-ticker.C:
+		}
+	}
+}
+
+func printEgressResults(info *livekit.EgressInfo) {
+	printInfo(info)
+	for _, f := range info.FileResults {
+		fmt.Printf("  File: %s (%d bytes)\n", f.Location, f.Size)
+	}
+	for _, seg := range info.SegmentResults {
+		fmt.Printf("  Playlist: %s (%d segments, %d bytes)\n", seg.PlaylistLocation, seg.SegmentCount, seg.Size)
+	}
+	for _, img := range info.ImageResults {
+		fmt.Printf("  Images: %s (%d images)\n", img.FilenamePrefix, img.ImageCount)
 	}
-	return nil
 }
 
 func testEgressTemplate(ctx context.Context, cmd *cli.Command) error {
@@ -778,6 +1482,170 @@ func testEgressTemplate(ctx context.Context, cmd *cli.Command) error {
 	return nil
 }
 
+// printStartedEgress records a started egress, then prints it, or only a
+// handle for chaining commands with --json.
+func printStartedEgress(cmd *cli.Command, info *livekit.EgressInfo) {
+	recordStartedEgress(info)
+	if cmd.Bool("json") {
+		printEgressHandle(info)
+		return
+	}
+	printInfo(info)
+}
+
+func printEgressHandle(info *livekit.EgressInfo) {
+	util.PrintJSON(map[string]string{
+		"egressId": info.EgressId,
+		"room":     info.RoomName,
+	})
+}
+
+// startEgress starts an egress with start and prints it, then waits for it
+// with --wait. With --retry-on-failure, an egress failing within
+// egressRetryWindow of being started is started again, with backoff, and
+// only the handle of the last attempt is printed with --json.
+func startEgress(ctx context.Context, cmd *cli.Command, start func() (*livekit.EgressInfo, error)) error {
+	retries := 0
+	if cmd.Bool("wait") {
+		retries = int(cmd.Int("retry-on-failure"))
+	}
+	progress := progressWriter(cmd)
+	jsonHandle := cmd.Bool("json") && retries > 0
+	var last *livekit.EgressInfo
+	if jsonHandle {
+		defer func() {
+			if last != nil {
+				printEgressHandle(last)
+			}
+		}()
+	}
+	backoff := 2 * time.Second
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			fmt.Fprintf(progress, "Retrying in %s, attempt %d of %d\n", backoff, attempt+1, retries+1)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff = min(2*backoff, 30*time.Second)
+		}
+
+		startedAt := time.Now()
+		info, err := start()
+		if err != nil {
+			return err
+		}
+		if jsonHandle {
+			last = info
+			recordStartedEgress(info)
+			fmt.Fprintln(progress, "Started egress", info.EgressId)
+		} else {
+			printStartedEgress(cmd, info)
+		}
+		err = waitForStartedEgress(ctx, cmd, info)
+		if err == nil || !errors.Is(err, errEgressFailed) || attempt >= retries {
+			return err
+		}
+		if elapsed := time.Since(startedAt); elapsed > egressRetryWindow {
+			fmt.Fprintf(progress, "Not retrying, egress %s ran for %s before failing\n", info.EgressId, elapsed.Round(time.Second))
+			return err
+		}
+	}
+}
+
+// waitForStartedEgress waits for an egress started with --wait to end,
+// cleaning up after it on failure with --on-failure-cleanup.
+func waitForStartedEgress(ctx context.Context, cmd *cli.Command, info *livekit.EgressInfo) error {
+	if !cmd.Bool("wait") {
+		return nil
+	}
+	progress := progressWriter(cmd)
+	fmt.Fprintln(progress, "Waiting for egress", info.EgressId, "to end")
+	final, err := waitForEgress(ctx, info.EgressId, cmd.Duration("wait-timeout"))
+	if err != nil {
+		return err
+	}
+	if !cmd.Bool("json") {
+		printEgressResults(final)
+	}
+	if final.Status != livekit.EgressStatus_EGRESS_FAILED && final.Status != livekit.EgressStatus_EGRESS_ABORTED {
+		return nil
+	}
+
+	fmt.Fprintf(progress, "Egress %s ended with status %s: %s\n", final.EgressId, final.Status, final.Error)
+	if cmd.Bool("on-failure-cleanup") {
+		cleanupFailedEgress(progress, final)
+	}
+	return fmt.Errorf("egress %s %w", final.EgressId, errEgressFailed)
+}
+
+// cleanupFailedEgress removes a failed egress from the local history, and
+// lists the partial outputs it left behind. The outputs are not deleted, as
+// their locations are reported by the server and may not be local files the
+// user asked for.
+func cleanupFailedEgress(w io.Writer, info *livekit.EgressInfo) {
+	var locations []string
+	for _, f := range info.FileResults {
+		locations = append(locations, f.Location)
+	}
+	for _, seg := range info.SegmentResults {
+		locations = append(locations, seg.PlaylistLocation, seg.LivePlaylistLocation)
+	}
+	for _, loc := range locations {
+		if loc != "" {
+			fmt.Fprintln(w, "Partial output left at", loc+", remove it manually if not needed")
+		}
+	}
+
+	if err := config.RemoveEgressRecord(info.EgressId); err != nil {
+		fmt.Fprintln(w, "Could not remove egress from history:", err)
+	} else {
+		fmt.Fprintln(w, "Removed egress", info.EgressId, "from history")
+	}
+}
+
+// recordStartedEgress saves a started egress to the local history, so that
+// it can be found with `lk egress recent` even if the CLI exits early.
+func recordStartedEgress(info *livekit.EgressInfo) {
+	egressType, egressSource := describeEgress(info)
+	if err := config.RecordEgress(config.EgressRecord{
+		EgressID:  info.EgressId,
+		Type:      egressType,
+		Source:    egressSource,
+		URL:       project.URL,
+		StartedAt: time.Now(),
+	}); err != nil {
+		warnf("WARNING: could not record egress in history: %v", err)
+	}
+}
+
+func listRecentEgress(ctx context.Context, cmd *cli.Command) error {
+	records, err := config.LoadEgressRecords()
+	if err != nil {
+		return err
+	}
+	slices.Reverse(records)
+	if limit := int(cmd.Int("limit")); limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+
+	if cmd.Bool("json") {
+		util.PrintJSON(records)
+		return nil
+	}
+	if len(records) == 0 {
+		fmt.Println("No egresses have been started from this machine")
+		return nil
+	}
+	table := util.CreateTable().Headers("EgressID", "Type", "Source", "Started At", "URL")
+	for _, r := range records {
+		table.Row(r.EgressID, r.Type, r.Source, r.StartedAt.Local().Format(time.DateTime), r.URL)
+	}
+	fmt.Println(table)
+	return nil
+}
+
 func printInfo(info *livekit.EgressInfo) {
 	if info.Error == "" {
 		fmt.Printf("EgressID: %v Status: %v\n", info.EgressId, info.Status)
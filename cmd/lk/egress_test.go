@@ -0,0 +1,141 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/livekit"
+	"github.com/urfave/cli/v3"
+)
+
+func egressIDs(items []*livekit.EgressInfo) []string {
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.EgressId)
+	}
+	return ids
+}
+
+func TestSortEgressItems(t *testing.T) {
+	newItems := func() []*livekit.EgressInfo {
+		return []*livekit.EgressInfo{
+			{EgressId: "EG_b", StartedAt: 200},
+			{EgressId: "EG_pending"},
+			{EgressId: "EG_a", StartedAt: 100},
+			{EgressId: "EG_c", StartedAt: 300},
+		}
+	}
+
+	items := newItems()
+	require.NoError(t, sortEgressItems(items, "started", false))
+	assert.Equal(t, []string{"EG_a", "EG_b", "EG_c", "EG_pending"}, egressIDs(items))
+
+	items = newItems()
+	require.NoError(t, sortEgressItems(items, "started", true))
+	assert.Equal(t, []string{"EG_c", "EG_b", "EG_a", "EG_pending"}, egressIDs(items), "missing timestamps should sort last when descending")
+
+	assert.Error(t, sortEgressItems(newItems(), "size", false))
+}
+
+func TestOrderEgressItems(t *testing.T) {
+	newItems := func() []*livekit.EgressInfo {
+		return []*livekit.EgressInfo{
+			{EgressId: "EG_1", StartedAt: 1},
+			{EgressId: "EG_4", StartedAt: 4},
+			{EgressId: "EG_none"},
+			{EgressId: "EG_3", StartedAt: 3},
+			{EgressId: "EG_2", StartedAt: 2},
+		}
+	}
+	tests := []struct {
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{args: []string{"--sort-by", "started", "--sort-desc", "--limit", "2"}, want: []string{"EG_4", "EG_3"}},
+		{args: []string{"--sort-by", "started", "--limit", "10"}, want: []string{"EG_1", "EG_2", "EG_3", "EG_4", "EG_none"}},
+		{args: []string{"--limit", "2"}, want: []string{"EG_1", "EG_4"}},
+		{args: []string{"--limit", "0"}, want: []string{"EG_1", "EG_4", "EG_none", "EG_3", "EG_2"}},
+		{args: []string{"--limit", "-1"}, wantErr: true},
+		{args: []string{"--sort-by", "size"}, wantErr: true},
+		{args: []string{"--sort-desc"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		var (
+			items []*livekit.EgressInfo
+			err   error
+		)
+		list := &cli.Command{
+			Name: "list",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "sort-by"},
+				&cli.BoolFlag{Name: "sort-desc"},
+				&cli.IntFlag{Name: "limit"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				items, err = orderEgressItems(cmd, newItems())
+				return nil
+			},
+		}
+		require.NoError(t, list.Run(context.Background(), append([]string{"list"}, tt.args...)), tt.args)
+		if tt.wantErr {
+			assert.Error(t, err, tt.args)
+			continue
+		}
+		require.NoError(t, err, tt.args)
+		assert.Equal(t, tt.want, egressIDs(items), tt.args)
+	}
+}
+
+func TestEgressFileOutput(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+
+	output, err := egressFileOutput("recordings/{room_name}.mp4")
+	require.NoError(t, err)
+	assert.Equal(t, "recordings/{room_name}.mp4", output.Filepath)
+	assert.Nil(t, output.Output)
+
+	output, err = egressFileOutput("file:///tmp/out.mp4")
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/out.mp4", output.Filepath)
+
+	output, err = egressFileOutput("s3://bucket/path/out.mp4")
+	require.NoError(t, err)
+	assert.Equal(t, "path/out.mp4", output.Filepath)
+	assert.Equal(t, "bucket", output.GetS3().GetBucket())
+
+	output, err = egressFileOutput("gs://bucket")
+	require.NoError(t, err)
+	assert.Equal(t, "{room_name}-{time}", output.Filepath)
+	assert.Equal(t, "bucket", output.GetGcp().GetBucket())
+
+	direct, err := egressDirectFileOutput("s3://bucket/track.ogg")
+	require.NoError(t, err)
+	assert.Equal(t, "track.ogg", direct.Filepath)
+	assert.Equal(t, "bucket", direct.GetS3().GetBucket())
+
+	_, err = egressFileOutput("rtmp://example.com/live")
+	assert.Error(t, err)
+	_, err = egressFileOutput("s3:///out.mp4")
+	assert.Error(t, err)
+	_, err = egressFileOutput("file://rec.mp4")
+	assert.Error(t, err)
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+
+	"github.com/twitchtv/twirp"
+	"github.com/urfave/cli/v3"
+)
+
+// Process exit codes, so scripts can branch on the kind of failure. These are
+// documented in the README and must not change meaning.
+const (
+	exitCodeError      = 1 // any other failure
+	exitCodeValidation = 2 // invalid arguments, flags or input
+	// exitCodeAwaitTimeout is returned when --await-first-participant gives
+	// up, so scripts can tell an empty room apart from other failures.
+	exitCodeAwaitTimeout = 3
+	exitCodeAuth         = 4 // missing or rejected credentials
+	exitCodeNotFound     = 5 // the room, dispatch or other resource doesn't exist
+	exitCodeTransport    = 6 // the server could not be reached or timed out
+)
+
+// exitError attaches an exit code to an error, keeping its message.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+func validationErrorf(format string, a ...any) error {
+	return &exitError{code: exitCodeValidation, err: fmt.Errorf(format, a...)}
+}
+
+func authErrorf(format string, a ...any) error {
+	return &exitError{code: exitCodeAuth, err: fmt.Errorf(format, a...)}
+}
+
+func notFoundErrorf(format string, a ...any) error {
+	return &exitError{code: exitCodeNotFound, err: fmt.Errorf(format, a...)}
+}
+
+// cliUsageErrors are the types of the errors cli returns for missing or
+// conflicting flags, which it doesn't export.
+var cliUsageErrors = []string{
+	"*cli.errRequiredFlags",
+	"*cli.mutuallyExclusiveGroup",
+	"*cli.mutuallyExclusiveGroupRequiredFlag",
+}
+
+// withUsageErrors makes flag parsing errors of cmd and its subcommands exit
+// with exitCodeValidation, printing the same help as cli does by default.
+func withUsageErrors(cmd *cli.Command) {
+	cmd.OnUsageError = func(ctx context.Context, cmd *cli.Command, err error, isSubcommand bool) error {
+		fmt.Fprintf(cmd.Root().ErrWriter, "Incorrect Usage: %s\n\n", err)
+		if isSubcommand {
+			_ = cli.ShowCommandHelp(ctx, cmd, cmd.Name)
+		} else {
+			_ = cli.ShowAppHelp(cmd)
+		}
+		return &exitError{code: exitCodeValidation, err: err}
+	}
+	for _, c := range cmd.Commands {
+		withUsageErrors(c)
+	}
+}
+
+// exitCode classifies err, preferring a code attached with exitError, then
+// the code of a server error, then transport failures.
+func exitCode(err error) int {
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code
+	}
+	if slices.Contains(cliUsageErrors, fmt.Sprintf("%T", err)) {
+		return exitCodeValidation
+	}
+
+	var te twirp.Error
+	if errors.As(err, &te) {
+		switch te.Code() {
+		case twirp.Unauthenticated, twirp.PermissionDenied:
+			return exitCodeAuth
+		case twirp.NotFound:
+			return exitCodeNotFound
+		case twirp.InvalidArgument, twirp.Malformed, twirp.OutOfRange, twirp.AlreadyExists, twirp.FailedPrecondition:
+			return exitCodeValidation
+		case twirp.Unavailable, twirp.DeadlineExceeded:
+			return exitCodeTransport
+		}
+	}
+
+	// the client reports connection failures as internal errors wrapping them.
+	// net.Error is not matched, as file errors such as syscall.Errno satisfy it
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	var urlErr *url.Error
+	if errors.As(err, &opErr) || errors.As(err, &dnsErr) || errors.As(err, &urlErr) || errors.Is(err, context.DeadlineExceeded) {
+		return exitCodeTransport
+	}
+	return exitCodeError
+}
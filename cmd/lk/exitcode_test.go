@@ -0,0 +1,80 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/twitchtv/twirp"
+	"github.com/urfave/cli/v3"
+)
+
+func TestExitCode(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	cases := []struct {
+		err  error
+		code int
+	}{
+		{errors.New("boom"), exitCodeError},
+		{validationErrorf("room name is required"), exitCodeValidation},
+		{fmt.Errorf("failed after 3 attempts: %w", notFoundErrorf("dispatch not found")), exitCodeNotFound},
+		{twirp.NewError(twirp.Unauthenticated, "invalid token"), exitCodeAuth},
+		{twirp.NewError(twirp.NotFound, "room not found"), exitCodeNotFound},
+		{twirp.NewError(twirp.InvalidArgument, "bad request"), exitCodeValidation},
+		{twirp.InternalErrorWith(dialErr), exitCodeTransport},
+		{twirp.NewError(twirp.Internal, "server error"), exitCodeError},
+		{fmt.Errorf("could not read file: %w", &fs.PathError{Op: "open", Path: "x", Err: syscall.ENOENT}), exitCodeError},
+	}
+	for _, c := range cases {
+		if code := exitCode(c.err); code != c.code {
+			t.Errorf("exitCode(%v) = %d, expected %d", c.err, code, c.code)
+		}
+	}
+}
+
+func TestUsageExitCode(t *testing.T) {
+	run := func(args ...string) error {
+		cmd := &cli.Command{
+			Name:      "lk",
+			Writer:    io.Discard,
+			ErrWriter: io.Discard,
+			Commands: []*cli.Command{{
+				Name:   "list",
+				Flags:  []cli.Flag{&cli.StringFlag{Name: "room", Required: true}},
+				Action: func(ctx context.Context, cmd *cli.Command) error { return nil },
+			}},
+		}
+		withUsageErrors(cmd)
+		return cmd.Run(context.Background(), append([]string{"lk"}, args...))
+	}
+	for _, args := range [][]string{
+		{"list", "--room", "r", "--bogus"},
+		{"list"},
+	} {
+		if code := exitCode(run(args...)); code != exitCodeValidation {
+			t.Errorf("%v: exit code %d, expected %d", args, code, exitCodeValidation)
+		}
+	}
+	if err := run("list", "--room", "r"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/twitchtv/twirp"
+	"github.com/urfave/cli/v3"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/livekit/livekit-cli/pkg/util"
+)
+
+const maskedValue = "********"
+
+var (
+	explain     bool
+	explainFlag = &cli.BoolFlag{
+		Name:        "explain",
+		Usage:       "Print a JSON description of the command and the API requests it would make, without sending them",
+		Destination: &explain,
+		Hidden:      true,
+		Action: func(ctx context.Context, cmd *cli.Command, v bool) error {
+			if v {
+				http.DefaultTransport = explainTransport{}
+			}
+			return nil
+		},
+	}
+
+	errExplained = errors.New("request not sent in --explain mode")
+
+	explainCallsMu sync.Mutex
+	explainCalls   []ExplainedCall
+)
+
+type Explanation struct {
+	Command string          `json:"command"`
+	Args    []string        `json:"args"`
+	Flags   map[string]any  `json:"flags"`
+	Calls   []ExplainedCall `json:"calls"`
+}
+
+type ExplainedCall struct {
+	RPC     string `json:"rpc,omitempty"`
+	Method  string `json:"method"`
+	URL     string `json:"url"`
+	Request any    `json:"request,omitempty"`
+}
+
+// explainInterceptor records Twirp requests instead of sending them.
+func explainInterceptor(url string) twirp.Interceptor {
+	return func(next twirp.Method) twirp.Method {
+		return func(ctx context.Context, req any) (any, error) {
+			pkg, _ := twirp.PackageName(ctx)
+			svc, _ := twirp.ServiceName(ctx)
+			meth, _ := twirp.MethodName(ctx)
+			call := ExplainedCall{
+				RPC:    pkg + "." + svc + "/" + meth,
+				Method: http.MethodPost,
+				URL:    strings.TrimSuffix(url, "/") + "/twirp/" + pkg + "." + svc + "/" + meth,
+			}
+			if m, ok := req.(proto.Message); ok {
+				if b, err := protojson.Marshal(m); err == nil {
+					call.Request = maskJSON(b)
+				}
+			}
+			recordExplainedCall(call)
+			return nil, errExplained
+		}
+	}
+}
+
+// explainTransport catches requests made outside of the Twirp clients, such
+// as those to the LiveKit Cloud API.
+type explainTransport struct{}
+
+func (explainTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	call := ExplainedCall{
+		Method: req.Method,
+		URL:    req.URL.String(),
+	}
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err == nil && len(b) > 0 {
+			call.Request = maskJSON(b)
+		}
+	}
+	recordExplainedCall(call)
+	return nil, errExplained
+}
+
+func recordExplainedCall(call ExplainedCall) {
+	explainCallsMu.Lock()
+	defer explainCallsMu.Unlock()
+	explainCalls = append(explainCalls, call)
+}
+
+// explainedGroups are the commands whose subcommands have no effect other
+// than API requests, which --explain intercepts. Their actions are run to
+// record the requests, while other commands are described without running.
+var explainedGroups = []string{"lk room", "lk participant", "lk egress", "lk ingress", "lk dispatch", "lk sip"}
+
+// notExplained are subcommands of explainedGroups that connect to rooms or
+// act on the local machine.
+var notExplained = []string{"lk room join", "lk room watch", "lk egress recent", "lk egress test-template"}
+
+// withExplain wraps the actions of cmd and its subcommands, so that with
+// --explain they report what they would have done.
+func withExplain(cmds []*cli.Command) {
+	for _, c := range cmds {
+		if action := c.Action; action != nil {
+			c.Action = func(ctx context.Context, cmd *cli.Command) error {
+				if !explain {
+					return action(ctx, cmd)
+				}
+				if !explainByRunning(cmd) {
+					util.PrintJSON(explainCommand(cmd))
+					return nil
+				}
+				err := action(ctx, cmd)
+				if err != nil && !errors.Is(err, errExplained) && len(explainCalls) == 0 {
+					return err
+				}
+				util.PrintJSON(explainCommand(cmd))
+				return nil
+			}
+		}
+		withExplain(c.Commands)
+	}
+}
+
+// explainByRunning reports whether the action of cmd can be run with
+// --explain, every request it makes being intercepted.
+func explainByRunning(cmd *cli.Command) bool {
+	name := cmd.FullName()
+	for _, n := range notExplained {
+		if name == n || strings.HasPrefix(name, n+" ") {
+			return false
+		}
+	}
+	for _, g := range explainedGroups {
+		if strings.HasPrefix(name, g+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func explainCommand(cmd *cli.Command) *Explanation {
+	e := &Explanation{
+		Command: cmd.FullName(),
+		Args:    cmd.Args().Slice(),
+		Flags:   map[string]any{},
+		Calls:   explainCalls,
+	}
+	for _, c := range cmd.Lineage() {
+		for _, f := range c.Flags {
+			name := f.Names()[0]
+			if _, ok := e.Flags[name]; ok || name == explainFlag.Name || name == "help" || name == "version" {
+				continue
+			}
+			value := cmd.Value(name)
+			if s, ok := value.(string); ok && s != "" && isSecretFlag(f) {
+				value = maskedValue
+			}
+			e.Flags[name] = value
+		}
+	}
+	if e.Calls == nil {
+		e.Calls = []ExplainedCall{}
+	}
+	return e
+}
+
+// isSecretFlag reports whether f holds a credential, going by its name or
+// the environment variables it is read from.
+func isSecretFlag(f cli.Flag) bool {
+	if isSecretName(f.Names()[0]) {
+		return true
+	}
+	if ef, ok := f.(interface{ GetEnvVars() []string }); ok {
+		for _, env := range ef.GetEnvVars() {
+			if isSecretName(env) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isSecretName(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range []string{"secret", "password", "passphrase", "token"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskJSON decodes a request body, hiding the values of secret fields.
+// Bodies that are not JSON are returned as strings.
+func maskJSON(b []byte) any {
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return string(b)
+	}
+	return maskValue(v)
+}
+
+func maskValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if isSecretName(k) {
+				if s, ok := val.(string); ok && s != "" {
+					t[k] = maskedValue
+				}
+				continue
+			}
+			t[k] = maskValue(val)
+		}
+	case []any:
+		for i, val := range t {
+			t[i] = maskValue(val)
+		}
+	}
+	return v
+}
@@ -0,0 +1,43 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+)
+
+func TestExplainMasksSecrets(t *testing.T) {
+	var e *Explanation
+	cmd := &cli.Command{
+		Name: "export",
+		Flags: []cli.Flag{
+			passphraseFlag,
+			&cli.StringFlag{Name: "key", Sources: cli.EnvVars("LIVEKIT_API_SECRET")},
+			&cli.StringFlag{Name: "name"},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			e = explainCommand(cmd)
+			return nil
+		},
+	}
+	require.NoError(t, cmd.Run(context.Background(), []string{"export", "--passphrase", "hunter2", "--key", "s3cr3t", "--name", "demo"}))
+	require.Equal(t, maskedValue, e.Flags["passphrase"])
+	require.Equal(t, maskedValue, e.Flags["key"], "flags read from a secret env var should be masked")
+	require.Equal(t, "demo", e.Flags["name"])
+}
@@ -17,6 +17,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/urfave/cli/v3"
 
@@ -80,9 +81,21 @@ var (
 							Usage:    "List a specific ingress `ID`",
 							Required: false,
 						},
-						jsonFlag,
+						listJSONFlag,
+						outputFlag,
+						templateFlag,
+						countOnlyFlag,
 					},
 				},
+				{
+					Name:      "get",
+					Usage:     "Show an ingress, including the state of its input stream",
+					UsageText: "lk ingress get [OPTIONS] ID",
+					ArgsUsage: "ID",
+					Before:    createIngressClient,
+					Action:    getIngress,
+					Flags:     []cli.Flag{jsonFlag},
+				},
 				{
 					Name:      "delete",
 					Usage:     "Delete an ingress",
@@ -177,9 +190,7 @@ func createIngress(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
-	if cmd.Bool("verbose") {
-		util.PrintJSON(req)
-	}
+	debugJSON(req)
 
 	info, err := ingressClient.CreateIngress(context.Background(), req)
 	if err != nil {
@@ -196,9 +207,7 @@ func updateIngress(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
-	if cmd.Bool("verbose") {
-		util.PrintJSON(req)
-	}
+	debugJSON(req)
 
 	info, err := ingressClient.UpdateIngress(context.Background(), req)
 	if err != nil {
@@ -210,6 +219,10 @@ func updateIngress(ctx context.Context, cmd *cli.Command) error {
 }
 
 func listIngress(ctx context.Context, cmd *cli.Command) error {
+	output, tmpl, err := listOutput(cmd)
+	if err != nil {
+		return err
+	}
 	res, err := ingressClient.ListIngress(context.Background(), &livekit.ListIngressRequest{
 		RoomName:  cmd.String("room"),
 		IngressId: cmd.String("id"),
@@ -218,38 +231,86 @@ func listIngress(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
+	if cmd.Bool("count-only") {
+		printCount(cmd, len(res.Items))
+		return nil
+	}
+
 	// NOTE: previously, the `verbose` flag was used to output JSON in addition to the table.
 	// This is inconsistent with other commands in which verbose is used for debug info, but is
 	// kept for compatibility with the previous behavior.
-	if cmd.Bool("verbose") || cmd.Bool("json") {
-		util.PrintJSON(res)
-	} else {
-		table := util.CreateTable().
-			Headers("IngressID", "Name", "Room", "StreamKey", "URL", "Status", "Error")
-		for _, item := range res.Items {
-			if item == nil {
-				continue
-			}
+	if cmd.Bool("verbose") && !cmd.IsSet("output") {
+		output = "json"
+	}
+	header := []string{"IngressID", "Name", "Room", "StreamKey", "URL", "Status", "Error"}
+	return printList(output, tmpl, res, res.Items, header, func(item *livekit.IngressInfo) []string {
+		var status, errorStr string
+		if item.State != nil {
+			status = item.State.Status.String()
+			errorStr = item.State.Error
+		}
+		return []string{
+			item.IngressId,
+			item.Name,
+			item.RoomName,
+			item.StreamKey,
+			item.Url,
+			status,
+			errorStr,
+		}
+	})
+}
 
-			var status, errorStr string
-			if item.State != nil {
-				status = item.State.Status.String()
-				errorStr = item.State.Error
-			}
+func getIngress(ctx context.Context, cmd *cli.Command) error {
+	id, err := extractArg(cmd)
+	if err != nil {
+		return err
+	}
+	res, err := ingressClient.ListIngress(ctx, &livekit.ListIngressRequest{
+		IngressId: id,
+	})
+	if err != nil {
+		return err
+	}
+	if len(res.Items) == 0 {
+		return fmt.Errorf("ingress not found: %s", id)
+	}
+	info := res.Items[0]
 
-			table.Row(
-				item.IngressId,
-				item.Name,
-				item.RoomName,
-				item.StreamKey,
-				item.Url,
-				status,
-				errorStr,
-			)
-		}
-		fmt.Println(table)
+	if cmd.Bool("json") {
+		util.PrintJSON(info)
+		return nil
 	}
 
+	fmt.Printf("IngressID: %v\n", info.IngressId)
+	fmt.Printf("Name: %v\n", info.Name)
+	fmt.Printf("Room: %v\n", info.RoomName)
+	fmt.Printf("Input: %v\n", info.InputType)
+	fmt.Printf("URL: %v Stream Key: %s\n", info.Url, info.StreamKey)
+
+	state := info.State
+	if state == nil {
+		fmt.Println("Stream: idle, no state reported")
+		return nil
+	}
+	switch state.Status {
+	case livekit.IngressState_ENDPOINT_BUFFERING, livekit.IngressState_ENDPOINT_PUBLISHING:
+		fmt.Printf("Stream: connected (%v)\n", state.Status)
+	default:
+		fmt.Printf("Stream: idle (%v)\n", state.Status)
+	}
+	if state.StartedAt != 0 {
+		fmt.Printf("Started At: %v\n", time.Unix(0, state.StartedAt))
+	}
+	if v := state.Video; v != nil && v.MimeType != "" {
+		fmt.Printf("Video: %s %dx%d @ %.2f fps, %d bps\n", v.MimeType, v.Width, v.Height, v.Framerate, v.AverageBitrate)
+	}
+	if a := state.Audio; a != nil && a.MimeType != "" {
+		fmt.Printf("Audio: %s %d ch @ %d Hz, %d bps\n", a.MimeType, a.Channels, a.SampleRate, a.AverageBitrate)
+	}
+	if state.Error != "" {
+		fmt.Printf("Error: %v\n", state.Error)
+	}
 	return nil
 }
 
@@ -54,6 +54,11 @@ var LoadTestCommands = []*cli.Command{
 				Name:  "subscribers",
 				Usage: "`NUMBER` of participants that would subscribe to tracks",
 			},
+			&cli.StringFlag{
+				Name:      "scenario",
+				Usage:     "YAML `FILE` describing groups of participants with their own media and join/leave timing (see cmd/lk/examples/load-test-scenario.yaml), instead of publisher and subscriber counts",
+				TakesFile: true,
+			},
 			&cli.StringFlag{
 				Name:  "identity-prefix",
 				Usage: "Identity `PREFIX` of tester participants (defaults to a random prefix)",
@@ -100,7 +105,7 @@ func loadTest(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
-	if !cmd.Bool("verbose") {
+	if !debugEnabled() {
 		lksdk.SetLogger(logger.LogRLogger(logr.Discard()))
 	}
 	_ = raiseULimit()
@@ -122,6 +127,20 @@ func loadTest(ctx context.Context, cmd *cli.Command) error {
 		},
 	}
 
+	if path := cmd.String("scenario"); path != "" {
+		for _, name := range []string{"video-publishers", "audio-publishers", "subscribers", "run-all"} {
+			if cmd.IsSet(name) {
+				return validationErrorf("--scenario cannot be used with --%s", name)
+			}
+		}
+		scenario, err := loadtester.LoadScenario(path)
+		if err != nil {
+			return err
+		}
+		test := loadtester.NewLoadTest(params)
+		return test.RunScenario(ctx, scenario)
+	}
+
 	if cmd.Bool("run-all") {
 		// leave out room name and pub/sub counts
 		if params.Duration == 0 {
@@ -0,0 +1,113 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/livekit/livekit-cli/pkg/util"
+	"github.com/livekit/protocol/logger"
+	lksdk "github.com/livekit/server-sdk-go/v2"
+)
+
+// Levels of --log-level, from the most to the least detailed. Messages of the
+// CLI itself and logs of the SDK share the level.
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+// index of the level set with --log-level in logLevels
+var logLevel = slices.Index(logLevels, "info")
+
+var (
+	logLevelFlag = &cli.StringFlag{
+		Name:  "log-level",
+		Usage: "Show messages at `LEVEL` and above, one of " + strings.Join(logLevels, ", "),
+		Value: "info",
+		Action: func(ctx context.Context, cmd *cli.Command, level string) error {
+			return setLogLevel(level)
+		},
+	}
+	verboseFlag = &cli.BoolFlag{
+		Name:  "verbose",
+		Usage: "Show debug messages such as request bodies, the same as --log-level debug",
+		Action: func(ctx context.Context, cmd *cli.Command, v bool) error {
+			// an explicit level wins over --verbose
+			if v && !cmd.IsSet("log-level") {
+				return setLogLevel("debug")
+			}
+			return nil
+		},
+	}
+)
+
+// setLogLevel applies level to CLI messages and to the logger of the SDK.
+func setLogLevel(level string) error {
+	i := slices.Index(logLevels, level)
+	if i < 0 {
+		return validationErrorf("invalid log level %q, must be one of %s", level, strings.Join(logLevels, ", "))
+	}
+	logLevel = i
+	logger.InitFromConfig(&logger.Config{Level: level}, "lk")
+	lksdk.SetLogger(logger.GetLogger())
+	return nil
+}
+
+// debugEnabled reports whether debug messages are shown, for output that
+// isn't logged line by line such as the output of git and tasks.
+func debugEnabled() bool {
+	return logLevel <= slices.Index(logLevels, "debug")
+}
+
+func logf(level, format string, a ...any) {
+	i := slices.Index(logLevels, level)
+	// --quiet leaves only warnings and errors
+	if quietOutput && i < slices.Index(logLevels, "warn") {
+		return
+	}
+	if logLevel <= i {
+		fmt.Fprintf(os.Stderr, strings.TrimSuffix(format, "\n")+"\n", a...)
+	}
+}
+
+func debugf(format string, a ...any) {
+	logf("debug", format, a...)
+}
+
+func infof(format string, a ...any) {
+	logf("info", format, a...)
+}
+
+func warnf(format string, a ...any) {
+	logf("warn", format, a...)
+}
+
+// debugJSON prints obj as JSON in debug messages, such as requests before
+// they are sent.
+func debugJSON(obj any) {
+	if !debugEnabled() {
+		return
+	}
+	b, err := util.MarshalStableJSON(obj)
+	if err != nil {
+		debugf("%v", obj)
+		return
+	}
+	fmt.Fprintln(os.Stderr, string(b))
+}
@@ -24,9 +24,6 @@ import (
 
 	"github.com/urfave/cli/v3"
 
-	"github.com/livekit/protocol/logger"
-	lksdk "github.com/livekit/server-sdk-go/v2"
-
 	livekitcli "github.com/livekit/livekit-cli"
 )
 
@@ -59,8 +56,12 @@ func main() {
 
 	app.Commands = append(app.Commands, AppCommands...)
 	app.Commands = append(app.Commands, CloudCommands...)
+	app.Commands = append(app.Commands, SandboxCommands...)
+	app.Commands = append(app.Commands, AgentCommands...)
 	app.Commands = append(app.Commands, ProjectCommands...)
+	app.Commands = append(app.Commands, ConfigCommands...)
 	app.Commands = append(app.Commands, RoomCommands...)
+	app.Commands = append(app.Commands, ParticipantCommands...)
 	app.Commands = append(app.Commands, TokenCommands...)
 	app.Commands = append(app.Commands, JoinCommands...)
 	app.Commands = append(app.Commands, DispatchCommands...)
@@ -69,6 +70,8 @@ func main() {
 	app.Commands = append(app.Commands, SIPCommands...)
 	app.Commands = append(app.Commands, ReplayCommands...)
 	app.Commands = append(app.Commands, LoadTestCommands...)
+	withExplain(app.Commands)
+	withUsageErrors(app)
 
 	// Register cleanup hook for SIGINT, SIGTERM, SIGQUIT
 	ctx, stop := signal.NotifyContext(
@@ -88,7 +91,7 @@ func main() {
 
 	if err := app.Run(ctx, os.Args); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
@@ -106,16 +109,10 @@ func checkForLegacyName() {
 }
 
 func initLogger(ctx context.Context, cmd *cli.Command) (context.Context, error) {
-	logConfig := &logger.Config{
-		Level: "info",
-	}
-	if cmd.Bool("verbose") {
-		logConfig.Level = "debug"
-	}
-	logger.InitFromConfig(logConfig, "lk")
-	lksdk.SetLogger(logger.GetLogger())
+	initColor()
 
-	return nil, nil
+	// --log-level and --verbose apply their level when they are parsed
+	return nil, setLogLevel(logLevels[logLevel])
 }
 
 func generateFishCompletion(ctx context.Context, cmd *cli.Command) error {
@@ -0,0 +1,587 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/urfave/cli/v3"
+
+	"github.com/livekit/livekit-cli/pkg/util"
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	lksdk "github.com/livekit/server-sdk-go/v2"
+)
+
+var (
+	ParticipantCommands = []*cli.Command{
+		{
+			Name:   "participant",
+			Usage:  "Moderate participants across a room",
+			Before: createRoomClient,
+			Commands: []*cli.Command{
+				{
+					Name:      "list",
+					Usage:     "List the participants of a room with their tracks",
+					UsageText: "lk participant list [OPTIONS] ROOM",
+					ArgsUsage: "ROOM",
+					Action:    listParticipants,
+					Flags:     listParticipantsFlags,
+				},
+				{
+					Name:      "get",
+					Usage:     "Fetch the full details of a room participant as JSON",
+					UsageText: "lk participant get [OPTIONS] ROOM IDENTITY",
+					ArgsUsage: "ROOM IDENTITY",
+					Action:    getParticipant,
+					Flags: []cli.Flag{
+						rawFlag,
+					},
+				},
+				{
+					Name:      "remove",
+					Usage:     "Disconnect a participant from a room",
+					UsageText: "lk participant remove ROOM IDENTITY",
+					ArgsUsage: "ROOM IDENTITY",
+					Action:    removeParticipant,
+				},
+				{
+					Name:      "update",
+					Aliases:   []string{"update-metadata"},
+					Usage:     "Change the metadata and permissions for one or more room participants",
+					UsageText: "lk participant update [OPTIONS] ROOM IDENTITY",
+					ArgsUsage: "ROOM IDENTITY",
+					Action:    updateParticipant,
+					Flags: []cli.Flag{
+						optional(roomFlag),
+						&cli.StringFlag{
+							Name:  "metadata",
+							Usage: "Participant `METADATA`, validated when it looks like JSON",
+						},
+						metadataFileFlag,
+						&cli.StringFlag{
+							Name:  "permissions",
+							Usage: "JSON describing participant permissions (existing values for unset fields)",
+						},
+						permissionsFromFileFlag,
+						identitiesFlag,
+					},
+				},
+				{
+					Name:      "move",
+					Aliases:   []string{"move-to-room"},
+					Usage:     "Forward a participant to another room, carrying over their metadata and attributes",
+					UsageText: "lk participant move [OPTIONS] --to-room ROOM_NAME SOURCE_ROOM IDENTITY",
+					ArgsUsage: "SOURCE_ROOM IDENTITY",
+					Action:    moveParticipant,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:     "to-room",
+							Usage:    "`NAME` of the room to move the participant to",
+							Required: true,
+						},
+						&cli.BoolFlag{
+							Name:  "remove-from-source",
+							Usage: "Remove the participant from the source room once forwarded, instead of leaving them in both",
+						},
+						jsonFlag,
+					},
+				},
+				{
+					Name:      "mute",
+					Usage:     "Mute tracks published by a participant, prompting for the track when --track is not set",
+					UsageText: "lk participant mute [OPTIONS] ROOM IDENTITY",
+					ArgsUsage: "ROOM IDENTITY",
+					Action:    muteParticipantTracks,
+					Flags: append([]cli.Flag{
+						&cli.BoolFlag{
+							Name:  "muted",
+							Usage: "Muted state to set, use --muted=false to unmute",
+							Value: true,
+						},
+					}, muteTrackFlags...),
+				},
+				{
+					Name:      "unmute",
+					Usage:     "Unmute tracks published by a participant",
+					UsageText: "lk participant unmute [OPTIONS] ROOM IDENTITY",
+					ArgsUsage: "ROOM IDENTITY",
+					Action:    unmuteParticipantTracks,
+					Flags:     muteTrackFlags,
+				},
+				{
+					Name:      "mute-all",
+					Usage:     "Mute the audio or video tracks of every participant in a room",
+					UsageText: "lk participant mute-all [OPTIONS] ROOM_NAME",
+					ArgsUsage: "ROOM_NAME",
+					Action:    muteAllParticipants,
+					Flags: []cli.Flag{
+						&cli.BoolFlag{
+							Name:  "audio",
+							Usage: "Mute audio tracks (default when neither --audio nor --video is set)",
+						},
+						&cli.BoolFlag{
+							Name:  "video",
+							Usage: "Mute video tracks",
+						},
+						&cli.StringSliceFlag{
+							Name:  "except",
+							Usage: "`IDENTITY` of a participant to leave unmuted, can be used multiple times",
+						},
+						&cli.BoolFlag{
+							Name:    "yes",
+							Aliases: []string{"y"},
+							Usage:   "Skip the confirmation prompt",
+						},
+					},
+				},
+			},
+		},
+	}
+)
+
+var muteTrackFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "room",
+		Usage: "`NAME` of the room, instead of the ROOM argument",
+	},
+	&cli.StringSliceFlag{
+		Name:  "track",
+		Usage: "Track `SID` to change, can be used multiple times (default: all published tracks)",
+	},
+	&cli.StringFlag{
+		Name:  "source",
+		Usage: "Only change tracks from `SOURCE`, one of \"camera\", \"microphone\", \"screen_share\", or \"screen_share_audio\"",
+	},
+	jsonFlag,
+}
+
+// participantArgs reads the room and identity of a participant command, from
+// ROOM and IDENTITY arguments, or from --room and an IDENTITY argument.
+func participantArgs(cmd *cli.Command) (string, string, error) {
+	args := cmd.Args().Slice()
+	roomName := cmd.String("room")
+	if roomName == "" && len(args) > 0 {
+		roomName, args = args[0], args[1:]
+	} else if roomName != "" && len(args) > 1 {
+		return "", "", validationErrorf("only one of ROOM or --room can be specified")
+	}
+	if roomName == "" {
+		return "", "", validationErrorf("room name is required")
+	}
+	if len(args) == 0 || args[0] == "" {
+		return "", "", validationErrorf("participant identity is required")
+	}
+	return roomName, args[0], nil
+}
+
+// participantsRoomArg reads the room of a command acting on the participants
+// given with --identities, from --room or a ROOM argument. An IDENTITY argument
+// is rejected, as it would be ambiguous with --identities.
+func participantsRoomArg(cmd *cli.Command) (string, error) {
+	roomName := cmd.String("room")
+	maxArgs := 1
+	if roomName != "" {
+		maxArgs = 0
+	} else {
+		roomName = cmd.Args().First()
+	}
+	if cmd.Args().Len() > maxArgs {
+		return "", validationErrorf("only one of IDENTITY or --identities can be specified")
+	}
+	if roomName == "" {
+		return "", validationErrorf("room name is required")
+	}
+	return roomName, nil
+}
+
+// TrackMuteResult describes the muted state of a track before and after a
+// mute or unmute request.
+type TrackMuteResult struct {
+	TrackSid      string `json:"track_sid"`
+	Source        string `json:"source"`
+	PreviousMuted bool   `json:"previous_muted"`
+	Muted         bool   `json:"muted"`
+	Error         string `json:"error,omitempty"`
+}
+
+func muteParticipantTracks(ctx context.Context, cmd *cli.Command) error {
+	return setParticipantTracksMuted(ctx, cmd, cmd.Bool("muted"))
+}
+
+func unmuteParticipantTracks(ctx context.Context, cmd *cli.Command) error {
+	return setParticipantTracksMuted(ctx, cmd, false)
+}
+
+func setParticipantTracksMuted(ctx context.Context, cmd *cli.Command, muted bool) error {
+	if cmd.Args().Len() == 0 {
+		return cli.ShowSubcommandHelp(cmd)
+	}
+	// --room is still accepted in place of the ROOM argument
+	roomName, identity, err := participantArgs(cmd)
+	if err != nil {
+		return err
+	}
+	var source livekit.TrackSource
+	if s := cmd.String("source"); s != "" {
+		v, ok := livekit.TrackSource_value[strings.ToUpper(s)]
+		if !ok || v == int32(livekit.TrackSource_UNKNOWN) {
+			return fmt.Errorf("invalid source: %s", s)
+		}
+		source = livekit.TrackSource(v)
+	}
+
+	trackSids := cmd.StringSlice("track")
+	if len(trackSids) == 0 && source == livekit.TrackSource_UNKNOWN && !cmd.Bool("json") && isInteractive() {
+		sid, err := selectTrack(ctx, roomClient, roomName, identity, true)
+		if err != nil {
+			return err
+		}
+		if sid != "" {
+			trackSids = []string{sid}
+		}
+	}
+
+	results, err := setTracksMuted(ctx, roomName, identity, trackSids, source, muted)
+	if err != nil {
+		return err
+	}
+	return printTrackMuteResults(cmd, results)
+}
+
+// selectTrack prompts for one of the tracks published in a room, only those
+// of identity when set. With all, an "All tracks" option is offered first,
+// returned as an empty SID.
+func selectTrack(ctx context.Context, client *lksdk.RoomServiceClient, roomName, identity string, all bool) (string, error) {
+	res, err := client.ListParticipants(ctx, &livekit.ListParticipantsRequest{
+		Room: roomName,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var options []huh.Option[string]
+	for _, p := range res.Participants {
+		if identity != "" && p.Identity != identity {
+			continue
+		}
+		for _, t := range p.Tracks {
+			desc := fmt.Sprintf("%s, %s", strings.ToLower(t.Source.String()), mutedString(t.Muted))
+			if t.Name != "" {
+				desc = t.Name + ", " + desc
+			}
+			if identity == "" {
+				desc = p.Identity + ", " + desc
+			}
+			options = append(options, huh.NewOption(t.Sid+" "+util.Theme.Help.ShortDesc.Render(desc), t.Sid))
+		}
+	}
+	if len(options) == 0 {
+		if identity != "" {
+			return "", notFoundErrorf("no tracks published by %s in room %s", identity, roomName)
+		}
+		return "", notFoundErrorf("no tracks published in room %s", roomName)
+	}
+	if all {
+		options = append([]huh.Option[string]{huh.NewOption("All tracks", "")}, options...)
+	}
+
+	var sid string
+	if err := huh.NewSelect[string]().
+		Title("Select Track").
+		Options(options...).
+		Value(&sid).
+		WithTheme(util.Theme).
+		Run(); err != nil {
+		return "", err
+	}
+	return sid, nil
+}
+
+// setTracksMuted mutes or unmutes the matching tracks of a participant,
+// recording the state of each track before and after the change. An empty
+// trackSids or an unknown source match all tracks.
+func setTracksMuted(
+	ctx context.Context,
+	roomName, identity string,
+	trackSids []string,
+	source livekit.TrackSource,
+	muted bool,
+) ([]TrackMuteResult, error) {
+	p, err := roomClient.GetParticipant(ctx, &livekit.RoomParticipantIdentity{
+		Room:     roomName,
+		Identity: identity,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var results []TrackMuteResult
+	for _, t := range p.Tracks {
+		if len(trackSids) > 0 && !slices.Contains(trackSids, t.Sid) {
+			continue
+		}
+		if source != livekit.TrackSource_UNKNOWN && t.Source != source {
+			continue
+		}
+		result := TrackMuteResult{
+			TrackSid:      t.Sid,
+			Source:        strings.ToLower(t.Source.String()),
+			PreviousMuted: t.Muted,
+			Muted:         t.Muted,
+		}
+		res, err := roomClient.MutePublishedTrack(ctx, &livekit.MuteRoomTrackRequest{
+			Room:     roomName,
+			Identity: identity,
+			TrackSid: t.Sid,
+			Muted:    muted,
+		})
+		if err != nil {
+			result.Error = err.Error()
+		} else if res.Track != nil {
+			result.Muted = res.Track.Muted
+		}
+		results = append(results, result)
+	}
+	for _, sid := range trackSids {
+		if !slices.ContainsFunc(results, func(r TrackMuteResult) bool { return r.TrackSid == sid }) {
+			return nil, fmt.Errorf("track %s not found for participant %s", sid, identity)
+		}
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no matching tracks published by %s", identity)
+	}
+	return results, nil
+}
+
+func printTrackMuteResults(cmd *cli.Command, results []TrackMuteResult) error {
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+
+	if cmd.Bool("json") {
+		util.PrintJSON(results)
+	} else {
+		table := util.CreateTable().Headers("TrackSID", "Source", "Before", "After", "Error")
+		for _, r := range results {
+			table.Row(r.TrackSid, r.Source, mutedString(r.PreviousMuted), mutedString(r.Muted), r.Error)
+		}
+		fmt.Println(table)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to update %d track(s)", failed)
+	}
+	return nil
+}
+
+func mutedString(muted bool) string {
+	if muted {
+		return "muted"
+	}
+	return "unmuted"
+}
+
+func muteAllParticipants(ctx context.Context, cmd *cli.Command) error {
+	roomName, err := extractArg(cmd)
+	if err != nil {
+		return err
+	}
+
+	var kinds []livekit.TrackType
+	if cmd.Bool("audio") || !cmd.Bool("video") {
+		kinds = append(kinds, livekit.TrackType_AUDIO)
+	}
+	if cmd.Bool("video") {
+		kinds = append(kinds, livekit.TrackType_VIDEO)
+	}
+	except := cmd.StringSlice("except")
+
+	res, err := roomClient.ListParticipants(ctx, &livekit.ListParticipantsRequest{
+		Room: roomName,
+	})
+	if err != nil {
+		return err
+	}
+
+	type target struct {
+		identity string
+		trackSid string
+	}
+	var targets []target
+	for _, p := range res.Participants {
+		if slices.Contains(except, p.Identity) {
+			continue
+		}
+		for _, t := range p.Tracks {
+			if !t.Muted && slices.Contains(kinds, t.Type) {
+				targets = append(targets, target{p.Identity, t.Sid})
+			}
+		}
+	}
+	if len(targets) == 0 {
+		fmt.Println("No unmuted tracks to mute in room", roomName)
+		return nil
+	}
+
+	if !cmd.Bool("yes") {
+		if !isInteractive() {
+			return errors.New("refusing to mute without confirmation, use --yes")
+		}
+		confirmed := false
+		if err := huh.NewConfirm().
+			Title(fmt.Sprintf("Mute %d track(s) in room %s?", len(targets), roomName)).
+			Value(&confirmed).
+			Inline(true).
+			WithTheme(util.Theme).
+			Run(); err != nil {
+			return err
+		}
+		if !confirmed {
+			return errors.New("operation cancelled")
+		}
+	}
+
+	muted := 0
+	participants := map[string]struct{}{}
+	for _, t := range targets {
+		if _, err := roomClient.MutePublishedTrack(ctx, &livekit.MuteRoomTrackRequest{
+			Room:     roomName,
+			Identity: t.identity,
+			TrackSid: t.trackSid,
+			Muted:    true,
+		}); err != nil {
+			fmt.Println("Error muting track", t.trackSid, "of", t.identity, err)
+			continue
+		}
+		muted++
+		participants[t.identity] = struct{}{}
+	}
+
+	fmt.Printf("Muted %d track(s) across %d participant(s)\n", muted, len(participants))
+	if muted < len(targets) {
+		return fmt.Errorf("failed to mute %d track(s)", len(targets)-muted)
+	}
+	return nil
+}
+
+// ParticipantMove reports the steps of a participant move.
+type ParticipantMove struct {
+	Identity          string `json:"identity"`
+	From              string `json:"from"`
+	To                string `json:"to"`
+	CarriedOver       bool   `json:"carried_over"`
+	RemovedFromSource bool   `json:"removed_from_source"`
+	Warning           string `json:"warning,omitempty"`
+}
+
+func moveParticipant(ctx context.Context, cmd *cli.Command) error {
+	if cmd.NArg() != 2 {
+		return errors.New("expected SOURCE_ROOM and IDENTITY")
+	}
+	move := ParticipantMove{
+		From:     cmd.Args().Get(0),
+		Identity: cmd.Args().Get(1),
+		To:       cmd.String("to-room"),
+	}
+	if move.From == move.To {
+		return errors.New("source and destination rooms are the same")
+	}
+
+	source, err := roomClient.GetParticipant(ctx, &livekit.RoomParticipantIdentity{
+		Room:     move.From,
+		Identity: move.Identity,
+	})
+	if err != nil {
+		return err
+	}
+
+	// ForwardParticipant is newer than the server SDK in use
+	at := auth.NewAccessToken(project.APIKey, project.APISecret).
+		SetVideoGrant(&auth.VideoGrant{RoomAdmin: true, Room: move.From})
+	if err = callTwirpJSON(ctx, at, "livekit.RoomService", "ForwardParticipant", map[string]string{
+		"room":             move.From,
+		"identity":         move.Identity,
+		"destination_room": move.To,
+	}, nil); err != nil {
+		return fmt.Errorf("could not forward %s to room %s: %w", move.Identity, move.To, err)
+	}
+
+	if source.Metadata != "" || len(source.Attributes) > 0 {
+		if err = carryOverParticipant(ctx, move.To, source); err != nil {
+			move.Warning = fmt.Sprintf("could not carry over metadata and attributes: %v", err)
+		} else {
+			move.CarriedOver = true
+		}
+	}
+
+	if cmd.Bool("remove-from-source") {
+		if _, err = roomClient.RemoveParticipant(ctx, &livekit.RoomParticipantIdentity{
+			Room:     move.From,
+			Identity: move.Identity,
+		}); err != nil {
+			return fmt.Errorf("forwarded %s to room %s, but could not remove them from room %s: %w", move.Identity, move.To, move.From, err)
+		}
+		move.RemovedFromSource = true
+	}
+
+	if cmd.Bool("json") {
+		util.PrintJSON(move)
+		return nil
+	}
+	if move.Warning != "" {
+		warnf("WARNING: %s", move.Warning)
+	}
+	verb := "Forwarded"
+	if move.RemovedFromSource {
+		verb = "Moved"
+	}
+	fmt.Printf("%s %s from room %s to room %s\n", verb, move.Identity, move.From, move.To)
+	if move.CarriedOver {
+		fmt.Println("Carried over metadata and attributes")
+	}
+	return nil
+}
+
+// carryOverParticipant copies the metadata and attributes of source to the
+// participant forwarded to room, waiting for it to show up there.
+func carryOverParticipant(ctx context.Context, room string, source *livekit.ParticipantInfo) error {
+	req := &livekit.UpdateParticipantRequest{
+		Room:       room,
+		Identity:   source.Identity,
+		Metadata:   source.Metadata,
+		Attributes: source.Attributes,
+	}
+	var err error
+	for attempt := 0; attempt < 5; attempt++ {
+		if _, err = roomClient.UpdateParticipant(ctx, req); err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+	return err
+}
@@ -20,28 +20,36 @@ import (
 	"fmt"
 	"net/url"
 	"regexp"
+	"strconv"
+	"time"
 
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
+	"github.com/go-logr/logr"
 	"github.com/urfave/cli/v3"
 
 	"github.com/livekit/livekit-cli/pkg/config"
 	"github.com/livekit/livekit-cli/pkg/util"
+	"github.com/livekit/protocol/auth"
+	"github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
+	lksdk "github.com/livekit/server-sdk-go/v2"
 )
 
 var (
 	ProjectCommands = []*cli.Command{
 		{
-			Name:   "project",
-			Usage:  "Add or remove projects and view existing project properties",
-			Before: loadProjectConfig,
+			Name:  "project",
+			Usage: "Add or remove projects and view existing project properties",
 			Commands: []*cli.Command{
 				{
 					Name:      "add",
 					Usage:     "Add a new project (for LiveKit Cloud projects, also see `lk cloud auth`)",
 					UsageText: "lk project add PROJECT_NAME",
 					ArgsUsage: "PROJECT_NAME",
+					Before:    loadProjectConfig,
 					Action:    addProject,
 					Flags: []cli.Flag{
 						&cli.StringFlag{
@@ -60,20 +68,26 @@ var (
 							Name:  "default",
 							Usage: "Set this project as the default",
 						},
+						&cli.StringFlag{
+							Name:  "agent-name",
+							Usage: "`NAME` of the agent to dispatch when \"lk dispatch create\" is run without --agent-name",
+						},
 					},
 				},
 				{
 					Name:      "list",
 					Usage:     "List all configured projects",
 					UsageText: "lk project list",
+					Before:    loadProjectConfig,
 					Action:    listProjects,
-					Flags:     []cli.Flag{jsonFlag},
+					Flags:     []cli.Flag{listJSONFlag, outputFlag, templateFlag, countOnlyFlag},
 				},
 				{
 					Name:      "remove",
 					Usage:     "Remove an existing project from config",
 					UsageText: "lk project remove PROJECT_NAME",
 					ArgsUsage: "PROJECT_NAME",
+					Before:    loadProjectConfig,
 					Action:    removeProject,
 				},
 				{
@@ -81,8 +95,26 @@ var (
 					Usage:     "Set a project as default to use with other commands",
 					UsageText: "lk project set-default PROJECT_NAME",
 					ArgsUsage: "PROJECT_NAME",
+					Before:    loadProjectConfig,
 					Action:    setDefaultProject,
 				},
+				{
+					Name:      "set-agent-name",
+					Usage:     "Set the agent dispatched by default with `lk dispatch create`, or clear it when AGENT_NAME is empty",
+					UsageText: "lk project set-agent-name PROJECT_NAME [AGENT_NAME]",
+					ArgsUsage: "PROJECT_NAME [AGENT_NAME]",
+					Before:    loadProjectConfig,
+					Action:    setProjectAgentName,
+				},
+				{
+					Name:      "test",
+					Usage:     "Verify that the credentials of a project are accepted by its server, and show the server version reported when joining a temporary room",
+					UsageText: "lk project test [PROJECT_NAME]",
+					ArgsUsage: "[PROJECT_NAME]",
+					Before:    loadProjectConfig,
+					Action:    testProject,
+					Flags:     []cli.Flag{jsonFlag},
+				},
 			},
 		},
 	}
@@ -94,6 +126,9 @@ var (
 )
 
 func loadProjectConfig(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+	if err := useConfigFile(cmd); err != nil {
+		return nil, err
+	}
 	conf, err := config.LoadOrCreate()
 	if err != nil {
 		return nil, err
@@ -198,6 +233,8 @@ func addProject(ctx context.Context, cmd *cli.Command) error {
 			Value(&p.APISecret))
 	}
 
+	p.DefaultAgentName = cmd.String("agent-name")
+
 	// if it's first project, make it default
 	isDefault := false
 	if cmd.Bool("default") || defaultProject == nil {
@@ -239,6 +276,23 @@ func addProject(ctx context.Context, cmd *cli.Command) error {
 }
 
 func listProjects(ctx context.Context, cmd *cli.Command) error {
+	output, tmpl, err := listOutput(cmd)
+	if err != nil {
+		return err
+	}
+	if cmd.Bool("count-only") {
+		printCount(cmd, len(cliConfig.Projects))
+		return nil
+	}
+	if output != "table" {
+		projects := make([]*config.ProjectConfig, len(cliConfig.Projects))
+		for i := range cliConfig.Projects {
+			projects[i] = &cliConfig.Projects[i]
+		}
+		return printList(output, tmpl, cliConfig.Projects, projects, []string{"Name", "URL", "API Key", "Default"}, func(p *config.ProjectConfig) []string {
+			return []string{p.Name, p.URL, p.APIKey, strconv.FormatBool(p.Name == cliConfig.DefaultProject)}
+		})
+	}
 	if len(cliConfig.Projects) == 0 {
 		fmt.Println("No projects configured, use `lk project add` to add a new project.")
 		return nil
@@ -248,33 +302,28 @@ func listProjects(ctx context.Context, cmd *cli.Command) error {
 	headerStyle := baseStyle.Bold(true)
 	selectedStyle := util.Theme.Focused.Title.Padding(0, 1)
 
-	if cmd.Bool("json") {
-		util.PrintJSON(cliConfig.Projects)
-	} else {
-		table := util.CreateTable().
-			StyleFunc(func(row, col int) lipgloss.Style {
-				switch {
-				case row == table.HeaderRow:
-					return headerStyle
-				case cliConfig.Projects[row].Name == cliConfig.DefaultProject:
-					return selectedStyle
-				default:
-					return baseStyle
-				}
-			}).
-			Headers("Name", "URL", "API Key")
-		for _, p := range cliConfig.Projects {
-			var pName string
-			if p.Name == cliConfig.DefaultProject {
-				pName = "* " + p.Name
-			} else {
-				pName = "  " + p.Name
+	table := util.CreateTable().
+		StyleFunc(func(row, col int) lipgloss.Style {
+			switch {
+			case row == table.HeaderRow:
+				return headerStyle
+			case cliConfig.Projects[row].Name == cliConfig.DefaultProject:
+				return util.CellStyle(selectedStyle)
+			default:
+				return util.CellStyle(baseStyle)
 			}
-			table.Row(pName, p.URL, p.APIKey)
+		}).
+		Headers("Name", "URL", "API Key")
+	for _, p := range cliConfig.Projects {
+		var pName string
+		if p.Name == cliConfig.DefaultProject {
+			pName = "* " + p.Name
+		} else {
+			pName = "  " + p.Name
 		}
-		fmt.Println(table)
+		table.Row(pName, p.URL, p.APIKey)
 	}
-
+	fmt.Println(table)
 	return nil
 }
 
@@ -307,5 +356,146 @@ func setDefaultProject(ctx context.Context, cmd *cli.Command) error {
 		return nil
 	}
 
-	return errors.New("project not found")
+	return cliConfig.ProjectNotFoundError(name)
+}
+
+func setProjectAgentName(ctx context.Context, cmd *cli.Command) error {
+	if cmd.NArg() == 0 {
+		_ = cli.ShowSubcommandHelp(cmd)
+		return errors.New("project name is required")
+	}
+	name := cmd.Args().First()
+	agentName := cmd.Args().Get(1)
+
+	for i := range cliConfig.Projects {
+		p := &cliConfig.Projects[i]
+		if p.Name != name {
+			continue
+		}
+
+		p.DefaultAgentName = agentName
+		if err := cliConfig.PersistIfNeeded(); err != nil {
+			return err
+		}
+		if agentName == "" {
+			fmt.Println("Cleared default agent of [" + util.Theme.Focused.Title.Render(p.Name) + "]")
+		} else {
+			fmt.Println("Default agent of [" + util.Theme.Focused.Title.Render(p.Name) + "] set to " + agentName)
+		}
+		return nil
+	}
+
+	return cliConfig.ProjectNotFoundError(name)
+}
+
+type ProjectTestResult struct {
+	Project       string `json:"project"`
+	URL           string `json:"url"`
+	OK            bool   `json:"ok"`
+	Status        string `json:"status"`
+	ServerVersion string `json:"server_version,omitempty"`
+	LatencyMs     int64  `json:"latency_ms"`
+}
+
+func testProject(ctx context.Context, cmd *cli.Command) error {
+	var p *config.ProjectConfig
+	if cmd.NArg() > 0 {
+		name := cmd.Args().First()
+		for i := range cliConfig.Projects {
+			if cliConfig.Projects[i].Name == name {
+				p = &cliConfig.Projects[i]
+				break
+			}
+		}
+		if p == nil {
+			return cliConfig.ProjectNotFoundError(name)
+		}
+	} else {
+		// credentials from flags or the environment, or the default project
+		var err error
+		if p, err = loadProjectDetails(cmd, quietLoad); err != nil {
+			return err
+		}
+	}
+
+	res, err := testProjectCredentials(ctx, p, true)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Bool("json") {
+		util.PrintJSON(res)
+	} else {
+		table := util.CreateTable().Headers("Project", "URL", "Status", "Server Version", "Latency")
+		table.Row(res.Project, res.URL, res.Status, res.ServerVersion, fmt.Sprintf("%dms", res.LatencyMs))
+		fmt.Println(table)
+	}
+	if !res.OK {
+		return authErrorf("credentials were not accepted by %s: %s", res.URL, res.Status)
+	}
+	return nil
+}
+
+// testProjectCredentials makes the cheapest authenticated call available,
+// listing rooms, and times its round trip.
+func testProjectCredentials(ctx context.Context, p *config.ProjectConfig, withVersion bool) (*ProjectTestResult, error) {
+	client := lksdk.NewRoomServiceClient(p.URL, p.APIKey, p.APISecret, withDefaultClientOpts(p)...)
+	start := time.Now()
+	_, err := client.ListRooms(ctx, &livekit.ListRoomsRequest{})
+	res := &ProjectTestResult{
+		Project:   p.Name,
+		URL:       p.URL,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	switch {
+	case err == nil:
+		res.OK = true
+		res.Status = "ok"
+	case exitCode(err) == exitCodeAuth:
+		res.Status = "invalid credentials"
+	case exitCode(err) == exitCodeTransport:
+		return nil, err
+	default:
+		res.Status = err.Error()
+	}
+
+	if res.OK && withVersion {
+		if res.ServerVersion, err = serverVersion(ctx, p, client); err != nil {
+			logger.Debugw("could not get the server version", "error", err)
+		}
+	}
+	return res, nil
+}
+
+// serverVersion joins a temporary room as a hidden participant to read the
+// version the server reports on join, then deletes the room.
+func serverVersion(ctx context.Context, p *config.ProjectConfig, client *lksdk.RoomServiceClient) (string, error) {
+	roomName := utils.NewGuid("lk-project-test-")
+	grant := &auth.VideoGrant{
+		RoomJoin: true,
+		Room:     roomName,
+		Hidden:   true,
+	}
+	grant.SetCanPublish(false)
+	grant.SetCanSubscribe(false)
+	token, err := auth.NewAccessToken(p.APIKey, p.APISecret).
+		SetIdentity(utils.NewGuid("lk-test-")).
+		SetVideoGrant(grant).
+		SetValidFor(time.Minute).
+		ToJWT()
+	if err != nil {
+		return "", err
+	}
+
+	// a failed join only leaves the version out, so keep the SDK quiet about it
+	lksdk.SetLogger(logger.LogRLogger(logr.Discard()))
+	defer lksdk.SetLogger(logger.GetLogger())
+	room, err := lksdk.ConnectToRoomWithToken(p.URL, token, &lksdk.RoomCallback{}, lksdk.WithAutoSubscribe(false))
+	if err != nil {
+		return "", err
+	}
+	version := room.ServerInfo().GetVersion()
+	room.Disconnect()
+	_, _ = client.DeleteRoom(ctx, &livekit.DeleteRoomRequest{Room: roomName})
+	return version, nil
 }
@@ -41,7 +41,7 @@ var (
 					Name:   "list",
 					Before: createReplayClient,
 					Action: listReplays,
-					Flags:  []cli.Flag{jsonFlag},
+					Flags:  []cli.Flag{jsonFlag, countOnlyFlag},
 				},
 				{
 					Name:   "load",
@@ -141,6 +141,11 @@ func listReplays(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
+	if cmd.Bool("count-only") {
+		printCount(cmd, len(res.Replays))
+		return nil
+	}
+
 	if cmd.Bool("json") {
 		util.PrintJSON(res.Replays)
 	} else {
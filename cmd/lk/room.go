@@ -15,26 +15,45 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"regexp"
+	"slices"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/pion/webrtc/v4"
 	"github.com/urfave/cli/v3"
+	"golang.org/x/sync/errgroup"
 	"google.golang.org/protobuf/encoding/protojson"
 
+	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
 	"github.com/livekit/protocol/logger"
+	"github.com/livekit/protocol/utils"
 	lksdk "github.com/livekit/server-sdk-go/v2"
 
 	"github.com/livekit/livekit-cli/pkg/util"
 )
 
 var (
+	permissionsFromFileFlag = &cli.StringFlag{
+		Name:      "permissions-from-file",
+		Usage:     "ParticipantPermission `JSON` file replacing the participant's permissions",
+		TakesFile: true,
+	}
+	identitiesFlag = &cli.StringSliceFlag{
+		Name:  "identities",
+		Usage: "Apply the update to each of the comma-separated participant `IDS`",
+	}
+
 	RoomCommands = []*cli.Command{
 		{
 			Name:  "room",
@@ -43,7 +62,7 @@ var (
 				{
 					Name:      "create",
 					Usage:     "Create a room",
-					ArgsUsage: "ROOM_NAME",
+					ArgsUsage: "ROOM_NAME, or none with --new-room",
 					Before:    createRoomClient,
 					Action:    createRoom,
 					Flags: []cli.Flag{
@@ -51,6 +70,17 @@ var (
 							Name:   "name",
 							Hidden: true,
 						},
+						&cli.BoolFlag{
+							Name:  "new-room",
+							Usage: "Generate a unique room name instead of taking ROOM_NAME",
+						},
+						roomPrefixFlag,
+						&cli.StringFlag{
+							Name:  "metadata",
+							Usage: "Initial `METADATA` of the room",
+						},
+						metadataFileFlag,
+						jsonMetadataFlag,
 						&cli.StringFlag{
 							Name:      "room-egress-file",
 							Usage:     "RoomCompositeRequest `JSON` file (see examples/room-composite-file.json)",
@@ -95,11 +125,28 @@ var (
 							Name:  "departure-timeout",
 							Usage: "Number of `SECS` to keep the room open after the last participant leaves",
 						},
+						&cli.UintFlag{
+							Name:  "max-participants",
+							Usage: "Limit the room to `N` participants, 0 for the server's default",
+						},
 						&cli.BoolFlag{
 							Name:   "replay-enabled",
 							Usage:  "experimental (not yet available)",
 							Hidden: true,
 						},
+						&cli.BoolFlag{
+							Name:  "return-token",
+							Usage: "Also print a token to join the created room, requires --identity",
+						},
+						&cli.StringFlag{
+							Name:  "identity",
+							Usage: "`ID` of the participant to issue a token for with --return-token",
+						},
+						&cli.BoolFlag{
+							Name:  "dry-run",
+							Usage: "Print the CreateRoomRequest that would be sent, without creating the room",
+						},
+						jsonFlag,
 					},
 				},
 				{
@@ -108,19 +155,49 @@ var (
 					Before:    createRoomClient,
 					Action:    listRooms,
 					ArgsUsage: "[ROOM_NAME ...]",
-					Flags:     []cli.Flag{jsonFlag},
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "name-pattern",
+							Usage: "Only list rooms whose name matches `REGEX`",
+						},
+						&cli.StringFlag{
+							Name:  "metadata-contains",
+							Usage: "Only list rooms whose metadata contains `SUBSTRING`",
+						},
+						&cli.StringFlag{
+							Name:  "sort",
+							Usage: "Sort rooms by `FIELD`, one of " + strings.Join(roomSortFields, ", ") + ", with the most participants and oldest rooms first",
+						},
+						&cli.StringFlag{
+							Name:  "agent",
+							Usage: "Only list rooms where an agent dispatched with agent name `NAME` is present",
+						},
+						&cli.IntFlag{
+							Name:  "concurrency",
+							Usage: "`NUMBER` of rooms to inspect in parallel with --agent",
+							Value: 4,
+						},
+						listJSONFlag,
+						outputFlag,
+						templateFlag,
+						countOnlyFlag,
+						rawFlag,
+					},
 				},
 				{
-					Name:   "update",
-					Usage:  "Modify properties of an active room",
-					Before: createRoomClient,
-					Action: updateRoomMetadata,
+					Name:      "update",
+					Aliases:   []string{"update-metadata"},
+					Usage:     "Modify properties of an active room",
+					UsageText: "lk room update [OPTIONS] ROOM_NAME",
+					Before:    createRoomClient,
+					Action:    updateRoomMetadata,
 					Flags: []cli.Flag{
 						hidden(optional(roomFlag)),
 						&cli.StringFlag{
-							Name:     "metadata",
-							Required: true,
+							Name:  "metadata",
+							Usage: "Room `METADATA`, validated when it looks like JSON",
 						},
+						metadataFileFlag,
 					},
 					ArgsUsage: "ROOM_NAME",
 				},
@@ -170,6 +247,40 @@ var (
 						},
 					},
 				},
+				{
+					Name:      "watch",
+					Usage:     "Print events happening in a room as they occur, without being visible to other participants",
+					UsageText: "lk room watch [OPTIONS] ROOM_NAME",
+					Action:    watchRoom,
+					ArgsUsage: "ROOM_NAME",
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:  "participant",
+							Usage: "Only print events involving the participant with `IDENTITY`",
+						},
+						&cli.StringSliceFlag{
+							Name:  "filter",
+							Usage: "Only print events of `TYPE`, can be used multiple times (" + strings.Join(roomEventTypes, ", ") + ")",
+						},
+						&cli.StringFlag{
+							Name:  "identity",
+							Usage: "`ID` of the hidden participant used to watch the room",
+						},
+						&cli.IntFlag{
+							Name:  "max-events",
+							Usage: "Exit after printing `N` events",
+						},
+						&cli.DurationFlag{
+							Name:  "timeout",
+							Usage: "Exit when no event is printed for `TIME`, failing if --max-events was not reached",
+						},
+						&cli.BoolFlag{
+							Name:    "json",
+							Aliases: []string{"j"},
+							Usage:   "Output events as newline-delimited JSON",
+						},
+					},
+				},
 				{
 					Name:   "participants",
 					Usage:  "Manage room participants",
@@ -180,6 +291,7 @@ var (
 							Usage:     "List or search for active rooms by name",
 							Action:    listParticipants,
 							ArgsUsage: "ROOM_NAME",
+							Flags:     listParticipantsFlags,
 						},
 						{
 							Name:      "get",
@@ -189,6 +301,7 @@ var (
 							Action:    getParticipant,
 							Flags: []cli.Flag{
 								roomFlag,
+								rawFlag,
 							},
 						},
 						{
@@ -213,10 +326,13 @@ var (
 									Name:  "metadata",
 									Usage: "JSON describing participant metadata (existing values for unset fields)",
 								},
+								metadataFileFlag,
 								&cli.StringFlag{
 									Name:  "permissions",
 									Usage: "JSON describing participant permissions (existing values for unset fields)",
 								},
+								permissionsFromFileFlag,
+								identitiesFlag,
 							},
 						},
 					},
@@ -252,6 +368,7 @@ var (
 							Name:   "track",
 							Usage:  "Track `SID` to mute",
 						},
+						jsonFlag,
 					},
 				},
 				{
@@ -279,23 +396,35 @@ var (
 					Name:      "send-data",
 					Before:    createRoomClient,
 					Action:    sendData,
-					Usage:     "Send arbitrary JSON data to client",
-					UsageText: "lk room send-data [OPTIONS] DATA",
-					ArgsUsage: "JSON",
+					Usage:     "Send a data message to the participants of a room",
+					UsageText: "lk room send-data [OPTIONS] ROOM [DATA]",
+					ArgsUsage: "ROOM [DATA]",
 					Flags: []cli.Flag{
-						roomFlag,
 						&cli.StringFlag{
-							Hidden: true, // deprecated: use ARG0
-							Name:   "data",
-							Usage:  "`JSON` payload to send to client",
+							Name:  "room",
+							Usage: "`NAME` of the room, instead of the ROOM argument",
+						},
+						&cli.StringFlag{
+							Name:  "data",
+							Usage: "`PAYLOAD` to send, instead of the DATA argument",
+						},
+						&cli.StringFlag{
+							Name:      "data-file",
+							Usage:     "Read the payload from `FILE`, or from stdin when \"-\"",
+							TakesFile: true,
 						},
 						&cli.StringFlag{
 							Name:  "topic",
 							Usage: "`TOPIC` of the message",
 						},
 						&cli.StringSliceFlag{
-							Name:  "identity",
-							Usage: "One or more participant identities to send the message to. When empty, broadcasts to the entire room",
+							Name:    "identities",
+							Aliases: []string{"identity"},
+							Usage:   "Send only to the comma-separated participant `IDENTITIES`, instead of broadcasting to the entire room",
+						},
+						&cli.BoolFlag{
+							Name:  "lossy",
+							Usage: "Send the message lossy instead of reliable",
 						},
 					},
 				},
@@ -528,18 +657,69 @@ func createRoomClient(ctx context.Context, cmd *cli.Command) (context.Context, e
 		return nil, err
 	}
 
+	project = pc
 	roomClient = lksdk.NewRoomServiceClient(pc.URL, pc.APIKey, pc.APISecret, withDefaultClientOpts(pc)...)
 	return nil, nil
 }
 
+// completionTimeout bounds the API calls made to complete arguments, so an
+// unreachable server doesn't stall the shell.
+const completionTimeout = 2 * time.Second
+
+// completeRoomNames prints the names of active rooms as completions, printing
+// nothing when the project or server is unavailable.
+func completeRoomNames(ctx context.Context, cmd *cli.Command) {
+	pc, err := loadProjectDetails(cmd, quietLoad)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, completionTimeout)
+	defer cancel()
+
+	client := lksdk.NewRoomServiceClient(pc.URL, pc.APIKey, pc.APISecret, withDefaultClientOpts(pc)...)
+	res, err := client.ListRooms(ctx, &livekit.ListRoomsRequest{})
+	if err != nil {
+		return
+	}
+	candidates := make([][2]string, 0, len(res.Rooms))
+	for _, r := range res.Rooms {
+		candidates = append(candidates, [2]string{r.Name, fmt.Sprintf("%d participants", r.NumParticipants)})
+	}
+	printCompletions(cmd, candidates)
+}
+
 func createRoom(ctx context.Context, cmd *cli.Command) error {
-	name, err := extractFlagOrArg(cmd, "name")
+	var name string
+	if cmd.Bool("new-room") {
+		if cmd.Args().Present() || cmd.String("name") != "" {
+			return validationErrorf("ROOM_NAME cannot be used with --new-room")
+		}
+		var err error
+		if name, err = newRoomName(cmd); err != nil {
+			return err
+		}
+		fmt.Fprintln(progressWriter(cmd), "Generated room name:", name)
+	} else if cmd.IsSet("room-prefix") {
+		return validationErrorf("--room-prefix requires --new-room")
+	} else {
+		var err error
+		if name, err = extractFlagOrArg(cmd, "name"); err != nil {
+			return validationErrorf("ROOM_NAME or --new-room is required")
+		}
+	}
+	identity := cmd.String("identity")
+	if cmd.Bool("return-token") && identity == "" {
+		return validationErrorf("--return-token requires --identity")
+	}
+
+	metadata, err := extractMetadata(cmd)
 	if err != nil {
 		return err
 	}
 
 	req := &livekit.CreateRoomRequest{
-		Name: name,
+		Name:     name,
+		Metadata: metadata,
 	}
 
 	if roomEgressFile := cmd.String("room-egress-file"); roomEgressFile != "" {
@@ -596,58 +776,113 @@ func createRoom(ctx context.Context, cmd *cli.Command) error {
 		req.Agents = agent.Dispatches
 	}
 
+	progress := progressWriter(cmd)
 	if roomPreset := cmd.String("room-preset"); roomPreset != "" {
 		req.RoomPreset = roomPreset
 	}
 
 	if cmd.Uint("min-playout-delay") != 0 {
-		fmt.Printf("setting min playout delay: %d\n", cmd.Uint("min-playout-delay"))
+		fmt.Fprintf(progress, "setting min playout delay: %d\n", cmd.Uint("min-playout-delay"))
 		req.MinPlayoutDelay = uint32(cmd.Uint("min-playout-delay"))
 	}
 
 	if maxPlayoutDelay := cmd.Uint("max-playout-delay"); maxPlayoutDelay != 0 {
-		fmt.Printf("setting max playout delay: %d\n", maxPlayoutDelay)
+		fmt.Fprintf(progress, "setting max playout delay: %d\n", maxPlayoutDelay)
 		req.MaxPlayoutDelay = uint32(maxPlayoutDelay)
 	}
 
 	if syncStreams := cmd.Bool("sync-streams"); syncStreams {
-		fmt.Printf("setting sync streams: %t\n", syncStreams)
+		fmt.Fprintf(progress, "setting sync streams: %t\n", syncStreams)
 		req.SyncStreams = syncStreams
 	}
 
 	if emptyTimeout := cmd.Uint("empty-timeout"); emptyTimeout != 0 {
-		fmt.Printf("setting empty timeout: %d\n", emptyTimeout)
+		fmt.Fprintf(progress, "setting empty timeout: %d\n", emptyTimeout)
 		req.EmptyTimeout = uint32(emptyTimeout)
 	}
 
 	if departureTimeout := cmd.Uint("departure-timeout"); departureTimeout != 0 {
-		fmt.Printf("setting departure timeout: %d\n", departureTimeout)
+		fmt.Fprintf(progress, "setting departure timeout: %d\n", departureTimeout)
 		req.DepartureTimeout = uint32(departureTimeout)
 	}
 
+	if maxParticipants := cmd.Uint("max-participants"); maxParticipants != 0 {
+		fmt.Fprintf(progress, "setting max participants: %d\n", maxParticipants)
+		req.MaxParticipants = uint32(maxParticipants)
+	}
+
 	if replayEnabled := cmd.Bool("replay-enabled"); replayEnabled {
-		fmt.Printf("setting replay enabled: %t\n", replayEnabled)
+		fmt.Fprintf(progress, "setting replay enabled: %t\n", replayEnabled)
 		req.ReplayEnabled = replayEnabled
 	}
 
+	if cmd.Bool("dry-run") {
+		util.PrintJSON(req)
+		fmt.Fprintln(os.Stderr, "Dry run, room was not created")
+		return nil
+	}
+
 	room, err := roomClient.CreateRoom(ctx, req)
 	if err != nil {
 		return err
 	}
 
-	util.PrintJSON(room)
+	var token string
+	if cmd.Bool("return-token") {
+		at := accessToken(project.APIKey, project.APISecret, &auth.VideoGrant{
+			RoomJoin: true,
+			Room:     room.Name,
+		}, identity)
+		if token, err = at.SetName(identity).ToJWT(); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case token == "":
+		// the room is the only output, so --json prints it as is
+		util.PrintJSON(room)
+	case cmd.Bool("json"):
+		util.PrintJSON(map[string]any{
+			"room":  room,
+			"token": token,
+			"url":   project.URL,
+		})
+	default:
+		util.PrintJSON(room)
+		fmt.Println()
+		fmt.Println("Access token:", token)
+		fmt.Println("URL:", project.URL)
+	}
 	return nil
 }
 
 func listRooms(ctx context.Context, cmd *cli.Command) error {
 	names, _ := extractArgs(cmd)
-	if cmd.Bool("verbose") && len(names) > 0 {
-		fmt.Printf(
+	if len(names) > 0 {
+		debugf(
 			"Querying rooms matching %s",
 			strings.Join(util.MapStrings(names, util.WrapWith("\"")), ", "),
 		)
 	}
 
+	var namePattern *regexp.Regexp
+	if pattern := cmd.String("name-pattern"); pattern != "" {
+		var err error
+		if namePattern, err = regexp.Compile(pattern); err != nil {
+			return validationErrorf("invalid --name-pattern: %v", err)
+		}
+	}
+	sortBy := cmd.String("sort")
+	if sortBy != "" && !slices.Contains(roomSortFields, sortBy) {
+		return validationErrorf("invalid --sort %q, must be one of %s", sortBy, strings.Join(roomSortFields, ", "))
+	}
+
+	output, tmpl, err := listOutput(cmd)
+	if err != nil {
+		return err
+	}
+
 	req := livekit.ListRoomsRequest{}
 	if len(names) > 0 {
 		req.Names = names
@@ -657,23 +892,140 @@ func listRooms(ctx context.Context, cmd *cli.Command) error {
 	if err != nil {
 		return err
 	}
+	if namePattern != nil {
+		res.Rooms = slices.DeleteFunc(res.Rooms, func(rm *livekit.Room) bool {
+			return !namePattern.MatchString(rm.Name)
+		})
+		if len(res.Rooms) == 0 && output == "table" && !cmd.Bool("raw") && !cmd.Bool("count-only") {
+			fmt.Println("No rooms with names matching", util.WrapWith("\"")(namePattern.String()))
+			return nil
+		}
+	}
 
-	if cmd.Bool("json") {
-		util.PrintJSON(res)
-	} else {
-		table := util.CreateTable().Headers("RoomID", "Name", "Participants", "Publishers")
-		for _, rm := range res.Rooms {
-			table.Row(
-				rm.Sid,
-				rm.Name,
-				fmt.Sprintf("%d", rm.NumParticipants),
-				fmt.Sprintf("%d", rm.NumPublishers),
-			)
+	if substr := cmd.String("metadata-contains"); substr != "" {
+		res.Rooms = slices.DeleteFunc(res.Rooms, func(rm *livekit.Room) bool {
+			return !strings.Contains(rm.Metadata, substr)
+		})
+		if len(res.Rooms) == 0 && output == "table" && !cmd.Bool("raw") && !cmd.Bool("count-only") {
+			fmt.Println("No rooms with metadata containing", util.WrapWith("\"")(substr))
+			return nil
 		}
-		fmt.Println(table)
 	}
 
-	return nil
+	if agentName := cmd.String("agent"); agentName != "" {
+		concurrency := int(cmd.Int("concurrency"))
+		if concurrency < 1 {
+			return validationErrorf("--concurrency must be at least 1")
+		}
+		if res.Rooms, err = filterRoomsWithAgent(ctx, res.Rooms, agentName, concurrency); err != nil {
+			return err
+		}
+		if len(res.Rooms) == 0 && output == "table" && !cmd.Bool("raw") && !cmd.Bool("count-only") {
+			fmt.Println("No rooms with agent", util.WrapWith("\"")(agentName))
+			return nil
+		}
+	}
+
+	if cmd.Bool("count-only") {
+		printCount(cmd, len(res.Rooms))
+		return nil
+	}
+	sortRooms(res.Rooms, sortBy)
+	if cmd.Bool("raw") {
+		return util.PrintProtoJSON(res)
+	}
+	header := []string{"RoomID", "Name", "Participants", "Publishers", "Created", "Metadata"}
+	return printList(output, tmpl, res, res.Rooms, header, func(rm *livekit.Room) []string {
+		created := ""
+		if rm.CreationTime != 0 {
+			created = time.Unix(rm.CreationTime, 0).Local().Format(time.DateTime)
+		}
+		return []string{
+			rm.Sid,
+			rm.Name,
+			fmt.Sprintf("%d", rm.NumParticipants),
+			fmt.Sprintf("%d", rm.NumPublishers),
+			created,
+			rm.Metadata,
+		}
+	})
+}
+
+// Fields rooms can be sorted by with --sort
+var roomSortFields = []string{"name", "participants", "age"}
+
+// sortRooms sorts rooms by one of roomSortFields, keeping the order of the
+// server for ties and when by is empty.
+func sortRooms(rooms []*livekit.Room, by string) {
+	slices.SortStableFunc(rooms, func(a, b *livekit.Room) int {
+		switch by {
+		case "name":
+			return strings.Compare(a.Name, b.Name)
+		case "participants":
+			return cmp.Compare(b.NumParticipants, a.NumParticipants)
+		case "age":
+			return cmp.Compare(a.CreationTime, b.CreationTime)
+		}
+		return 0
+	})
+}
+
+// filterRoomsWithAgent keeps the rooms where an agent participant is running
+// a job of a dispatch for agentName.
+func filterRoomsWithAgent(ctx context.Context, rooms []*livekit.Room, agentName string, concurrency int) ([]*livekit.Room, error) {
+	dispatchClient := lksdk.NewAgentDispatchServiceClient(project.URL, project.APIKey, project.APISecret, withDefaultClientOpts(project)...)
+
+	found := make([]bool, len(rooms))
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+	for i, rm := range rooms {
+		if rm.NumParticipants == 0 {
+			continue
+		}
+		g.Go(func() error {
+			participants, err := roomClient.ListParticipants(ctx, &livekit.ListParticipantsRequest{Room: rm.Name})
+			if err != nil {
+				return fmt.Errorf("could not list participants of room %s: %w", rm.Name, err)
+			}
+			agents := make(map[string]bool)
+			for _, p := range participants.Participants {
+				if p.Kind == livekit.ParticipantInfo_AGENT {
+					agents[p.Identity] = true
+				}
+			}
+			if len(agents) == 0 {
+				return nil
+			}
+
+			dispatches, err := dispatchClient.ListDispatch(ctx, &livekit.ListAgentDispatchRequest{Room: rm.Name})
+			if err != nil {
+				return fmt.Errorf("could not list dispatches of room %s: %w", rm.Name, err)
+			}
+			for _, d := range dispatches.AgentDispatches {
+				if d.AgentName != agentName {
+					continue
+				}
+				for _, job := range d.GetState().GetJobs() {
+					if agents[job.GetState().GetParticipantIdentity()] {
+						found[i] = true
+						return nil
+					}
+				}
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	var filtered []*livekit.Room
+	for i, rm := range rooms {
+		if found[i] {
+			filtered = append(filtered, rm)
+		}
+	}
+	return filtered, nil
 }
 
 func _deprecatedListRoom(ctx context.Context, cmd *cli.Command) error {
@@ -710,10 +1062,18 @@ func deleteRoom(ctx context.Context, cmd *cli.Command) error {
 }
 
 func updateRoomMetadata(ctx context.Context, cmd *cli.Command) error {
-	roomName, _ := extractArg(cmd)
+	roomName, err := extractFlagOrArg(cmd, "room")
+	if err != nil {
+		return validationErrorf("ROOM_NAME is required")
+	}
+	metadata, err := extractUpdatedMetadata(cmd)
+	if err != nil {
+		return err
+	}
+
 	res, err := roomClient.UpdateRoomMetadata(ctx, &livekit.UpdateRoomMetadataRequest{
 		Room:     roomName,
-		Metadata: cmd.String("metadata"),
+		Metadata: metadata,
 	})
 	if err != nil {
 		return err
@@ -753,7 +1113,302 @@ func joinRoom(ctx context.Context, cmd *cli.Command) error {
 	participantIdentity := cmd.String("identity")
 
 	done := make(chan os.Signal, 1)
-	roomCB := &lksdk.RoomCallback{
+	roomCB := newRoomEventLogger(func() { close(done) })
+	room, err := lksdk.ConnectToRoom(pc.URL, lksdk.ConnectInfo{
+		APIKey:              pc.APIKey,
+		APISecret:           pc.APISecret,
+		RoomName:            roomName,
+		ParticipantIdentity: participantIdentity,
+	}, roomCB)
+	if err != nil {
+		return err
+	}
+	defer room.Disconnect()
+
+	logger.Infow("connected to room", "room", room.Name())
+
+	signal.Notify(done, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+
+	if cmd.Bool("publish-demo") {
+		if err = publishDemo(room); err != nil {
+			return err
+		}
+	}
+
+	exitAfterPublish := cmd.Bool("exit-after-publish")
+	if publish := cmd.StringSlice("publish"); publish != nil {
+		fps := cmd.Float("fps")
+		for _, pub := range publish {
+			onPublishComplete := func(pub *lksdk.LocalTrackPublication) {
+				if exitAfterPublish {
+					close(done)
+					return
+				}
+				if pub != nil {
+					fmt.Printf("finished writing %s\n", pub.Name())
+					_ = room.LocalParticipant.UnpublishTrack(pub.SID())
+				}
+			}
+			if err = handlePublish(room, pub, fps, onPublishComplete); err != nil {
+				return err
+			}
+		}
+	}
+
+	publishPacket := func(p lksdk.DataPacket) error {
+		if err = room.LocalParticipant.PublishDataPacket(p, lksdk.WithDataPublishReliable(true)); err != nil {
+			return err
+		}
+		if exitAfterPublish {
+			close(done)
+		}
+		return nil
+	}
+	if data := cmd.String("publish-data"); data != "" {
+		if err = publishPacket(&lksdk.UserDataPacket{Payload: []byte(data)}); err != nil {
+			return err
+		}
+	}
+	if dtmf := cmd.String("publish-dtmf"); dtmf != "" {
+		if err = publishPacket(&livekit.SipDTMF{Digit: dtmf}); err != nil {
+			return err
+		}
+	}
+
+	<-done
+	return nil
+}
+
+var roomEventTypes = []string{
+	"participant_joined", "participant_left",
+	"track_published", "track_unpublished", "track_muted", "track_unmuted",
+	"data_received", "metadata_changed", "room_metadata_changed",
+}
+
+// RoomEvent is an event printed by `room watch`.
+type RoomEvent struct {
+	Time        time.Time `json:"time"`
+	Type        string    `json:"type"`
+	Participant string    `json:"participant,omitempty"`
+	TrackSid    string    `json:"track_sid,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	Topic       string    `json:"topic,omitempty"`
+	Data        string    `json:"data,omitempty"`
+}
+
+// roomWatcher prints the room events matching its filters.
+type roomWatcher struct {
+	participant string
+	types       []string
+	json        bool
+	maxEvents   int
+
+	mu       sync.Mutex
+	count    int
+	activity chan struct{} // signaled on each printed event
+	full     chan struct{} // closed once maxEvents have been printed
+}
+
+func (w *roomWatcher) emit(e RoomEvent) {
+	if w.participant != "" && e.Participant != w.participant {
+		return
+	}
+	if len(w.types) > 0 && !slices.Contains(w.types, e.Type) {
+		return
+	}
+	e.Time = time.Now()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.maxEvents > 0 && w.count >= w.maxEvents {
+		return
+	}
+	w.count++
+	defer func() {
+		select {
+		case w.activity <- struct{}{}:
+		default:
+		}
+		if w.count == w.maxEvents {
+			close(w.full)
+		}
+	}()
+	if w.json {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return
+		}
+		fmt.Println(string(b))
+		return
+	}
+	line := fmt.Sprintf("%s  %-22s %s", e.Time.Format("15:04:05.000"), e.Type, e.Participant)
+	for _, v := range []string{e.TrackSid, e.Source, e.Topic, e.Data} {
+		if v != "" {
+			line += " " + v
+		}
+	}
+	fmt.Println(line)
+}
+
+func (w *roomWatcher) callback(onDisconnected func()) *lksdk.RoomCallback {
+	trackEvent := func(typ string, pub lksdk.TrackPublication, p lksdk.Participant) {
+		w.emit(RoomEvent{
+			Type:        typ,
+			Participant: p.Identity(),
+			TrackSid:    pub.SID(),
+			Source:      strings.ToLower(pub.Source().String()),
+		})
+	}
+	return &lksdk.RoomCallback{
+		OnParticipantConnected: func(p *lksdk.RemoteParticipant) {
+			w.emit(RoomEvent{Type: "participant_joined", Participant: p.Identity()})
+		},
+		OnParticipantDisconnected: func(p *lksdk.RemoteParticipant) {
+			w.emit(RoomEvent{Type: "participant_left", Participant: p.Identity()})
+		},
+		OnRoomMetadataChanged: func(metadata string) {
+			w.emit(RoomEvent{Type: "room_metadata_changed", Data: metadata})
+		},
+		ParticipantCallback: lksdk.ParticipantCallback{
+			OnTrackPublished: func(pub *lksdk.RemoteTrackPublication, rp *lksdk.RemoteParticipant) {
+				trackEvent("track_published", pub, rp)
+			},
+			OnTrackUnpublished: func(pub *lksdk.RemoteTrackPublication, rp *lksdk.RemoteParticipant) {
+				trackEvent("track_unpublished", pub, rp)
+			},
+			OnTrackMuted: func(pub lksdk.TrackPublication, p lksdk.Participant) {
+				trackEvent("track_muted", pub, p)
+			},
+			OnTrackUnmuted: func(pub lksdk.TrackPublication, p lksdk.Participant) {
+				trackEvent("track_unmuted", pub, p)
+			},
+			OnMetadataChanged: func(oldMetadata string, p lksdk.Participant) {
+				w.emit(RoomEvent{Type: "metadata_changed", Participant: p.Identity(), Data: p.Metadata()})
+			},
+			OnDataPacket: func(data lksdk.DataPacket, params lksdk.DataReceiveParams) {
+				e := RoomEvent{Type: "data_received", Participant: params.SenderIdentity}
+				switch p := data.(type) {
+				case *lksdk.UserDataPacket:
+					e.Topic = p.Topic
+					e.Data = string(p.Payload)
+				case *livekit.SipDTMF:
+					e.Topic = "dtmf"
+					e.Data = p.Digit
+				}
+				w.emit(e)
+			},
+		},
+		OnDisconnected: onDisconnected,
+	}
+}
+
+func watchRoom(ctx context.Context, cmd *cli.Command) error {
+	pc, err := loadProjectDetails(cmd)
+	if err != nil {
+		return err
+	}
+	roomName, err := extractArg(cmd)
+	if err != nil {
+		return err
+	}
+	types := cmd.StringSlice("filter")
+	for _, t := range types {
+		if !slices.Contains(roomEventTypes, t) {
+			return fmt.Errorf("invalid event type %q, must be one of %s", t, strings.Join(roomEventTypes, ", "))
+		}
+	}
+	identity := cmd.String("identity")
+	if identity == "" {
+		identity = utils.NewGuid("lk-watch-")
+	}
+
+	grant := &auth.VideoGrant{RoomJoin: true, Room: roomName, Hidden: true}
+	grant.SetCanPublish(false)
+	grant.SetCanPublishData(false)
+	token, err := auth.NewAccessToken(pc.APIKey, pc.APISecret).
+		SetIdentity(identity).
+		SetVideoGrant(grant).
+		ToJWT()
+	if err != nil {
+		return err
+	}
+
+	w := &roomWatcher{
+		participant: cmd.String("participant"),
+		types:       types,
+		json:        cmd.Bool("json"),
+		maxEvents:   int(cmd.Int("max-events")),
+		activity:    make(chan struct{}, 1),
+		full:        make(chan struct{}),
+	}
+	done := make(chan struct{})
+	var once sync.Once
+	room, err := lksdk.ConnectToRoomWithToken(pc.URL, token, w.callback(func() {
+		once.Do(func() { close(done) })
+	}), lksdk.WithAutoSubscribe(false))
+	if err != nil {
+		return err
+	}
+	defer room.Disconnect()
+
+	fmt.Fprintln(os.Stderr, "Watching room", room.Name()+", press Ctrl-C to stop")
+	for _, p := range room.GetRemoteParticipants() {
+		w.emit(RoomEvent{Type: "participant_joined", Participant: p.Identity()})
+		for _, pub := range p.TrackPublications() {
+			w.emit(RoomEvent{
+				Type:        "track_published",
+				Participant: p.Identity(),
+				TrackSid:    pub.SID(),
+				Source:      strings.ToLower(pub.Source().String()),
+			})
+		}
+	}
+
+	timeout := cmd.Duration("timeout")
+	var idle <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		idle = timer.C
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			for {
+				select {
+				case <-w.activity:
+					timer.Reset(timeout)
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-done:
+	case <-w.full:
+	case <-idle:
+		if w.maxEvents > 0 {
+			err = fmt.Errorf("no events for %s, captured %d of %d event(s)", timeout, w.captured(), w.maxEvents)
+		}
+	}
+	if w.maxEvents > 0 || timeout > 0 {
+		fmt.Fprintf(os.Stderr, "Captured %d event(s)\n", w.captured())
+	}
+	return err
+}
+
+func (w *roomWatcher) captured() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.count
+}
+
+// newRoomEventLogger returns callbacks that log events happening in a room,
+// calling onDisconnected once the connection is closed.
+func newRoomEventLogger(onDisconnected func()) *lksdk.RoomCallback {
+	return &lksdk.RoomCallback{
 		OnParticipantConnected: func(p *lksdk.RemoteParticipant) {
 			logger.Infow("participant connected",
 				"kind", p.Kind(),
@@ -835,72 +1490,24 @@ func joinRoom(ctx context.Context, cmd *cli.Command) error {
 		},
 		OnDisconnected: func() {
 			logger.Infow("disconnected from room")
-			close(done)
+			onDisconnected()
 		},
 	}
-	room, err := lksdk.ConnectToRoom(pc.URL, lksdk.ConnectInfo{
-		APIKey:              pc.APIKey,
-		APISecret:           pc.APISecret,
-		RoomName:            roomName,
-		ParticipantIdentity: participantIdentity,
-	}, roomCB)
-	if err != nil {
-		return err
-	}
-	defer room.Disconnect()
-
-	logger.Infow("connected to room", "room", room.Name())
-
-	signal.Notify(done, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
-
-	if cmd.Bool("publish-demo") {
-		if err = publishDemo(room); err != nil {
-			return err
-		}
-	}
-
-	exitAfterPublish := cmd.Bool("exit-after-publish")
-	if publish := cmd.StringSlice("publish"); publish != nil {
-		fps := cmd.Float("fps")
-		for _, pub := range publish {
-			onPublishComplete := func(pub *lksdk.LocalTrackPublication) {
-				if exitAfterPublish {
-					close(done)
-					return
-				}
-				if pub != nil {
-					fmt.Printf("finished writing %s\n", pub.Name())
-					_ = room.LocalParticipant.UnpublishTrack(pub.SID())
-				}
-			}
-			if err = handlePublish(room, pub, fps, onPublishComplete); err != nil {
-				return err
-			}
-		}
-	}
-
-	publishPacket := func(p lksdk.DataPacket) error {
-		if err = room.LocalParticipant.PublishDataPacket(p, lksdk.WithDataPublishReliable(true)); err != nil {
-			return err
-		}
-		if exitAfterPublish {
-			close(done)
-		}
-		return nil
-	}
-	if data := cmd.String("publish-data"); data != "" {
-		if err = publishPacket(&lksdk.UserDataPacket{Payload: []byte(data)}); err != nil {
-			return err
-		}
-	}
-	if dtmf := cmd.String("publish-dtmf"); dtmf != "" {
-		if err = publishPacket(&livekit.SipDTMF{Digit: dtmf}); err != nil {
-			return err
-		}
-	}
+}
 
-	<-done
-	return nil
+var listParticipantsFlags = []cli.Flag{
+	listJSONFlag,
+	outputFlag,
+	templateFlag,
+	countOnlyFlag,
+	&cli.DurationFlag{
+		Name:  "changed-since",
+		Usage: "Mark participants that joined within `DURATION`, adding \"changed\" to each participant with --json",
+	},
+	&cli.BoolFlag{
+		Name:  "changed-only",
+		Usage: "Only list participants that joined within --changed-since",
+	},
 }
 
 func listParticipants(ctx context.Context, cmd *cli.Command) error {
@@ -908,6 +1515,15 @@ func listParticipants(ctx context.Context, cmd *cli.Command) error {
 	if err != nil {
 		return err
 	}
+	output, tmpl, err := listOutput(cmd)
+	if err != nil {
+		return err
+	}
+
+	changedSince := cmd.Duration("changed-since")
+	if cmd.Bool("changed-only") && changedSince <= 0 {
+		return validationErrorf("--changed-only requires --changed-since")
+	}
 
 	res, err := roomClient.ListParticipants(ctx, &livekit.ListParticipantsRequest{
 		Room: roomName,
@@ -916,10 +1532,55 @@ func listParticipants(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
+	now := time.Now()
+	var participants []*ParticipantChange
 	for _, p := range res.Participants {
-		fmt.Printf("%s (%s)\t tracks: %d\n", p.Identity, p.State.String(), len(p.Tracks))
+		changed := changedSince > 0 && now.Sub(participantJoinedAt(p)) <= changedSince
+		if changed || !cmd.Bool("changed-only") {
+			participants = append(participants, &ParticipantChange{ParticipantInfo: p, Changed: changed})
+		}
 	}
-	return nil
+
+	if cmd.Bool("count-only") {
+		printCount(cmd, len(participants))
+		return nil
+	}
+	var jsonRes any = res
+	if changedSince > 0 {
+		jsonRes = map[string]any{"participants": participants}
+	}
+	header := []string{"Identity", "State", "Tracks", "Joined"}
+	return printList(output, tmpl, jsonRes, participants, header, func(p *ParticipantChange) []string {
+		var tracks []string
+		for _, t := range p.Tracks {
+			track := fmt.Sprintf("%s %s", t.Sid, strings.ToLower(t.Source.String()))
+			if t.Muted {
+				track += " (muted)"
+			}
+			tracks = append(tracks, track)
+		}
+		var joined string
+		switch {
+		case p.Changed:
+			joined = now.Sub(participantJoinedAt(p.ParticipantInfo)).Round(time.Second).String() + " ago"
+		case p.JoinedAt != 0 || p.JoinedAtMs != 0:
+			joined = participantJoinedAt(p.ParticipantInfo).Local().Format(time.DateTime)
+		}
+		return []string{p.Identity, p.State.String(), strings.Join(tracks, "\n"), joined}
+	})
+}
+
+// ParticipantChange is a participant listed with --changed-since.
+type ParticipantChange struct {
+	*livekit.ParticipantInfo
+	Changed bool `json:"changed"`
+}
+
+func participantJoinedAt(p *livekit.ParticipantInfo) time.Time {
+	if p.JoinedAtMs != 0 {
+		return time.UnixMilli(p.JoinedAtMs)
+	}
+	return time.Unix(p.JoinedAt, 0)
 }
 
 func _deprecatedListParticipants(ctx context.Context, cmd *cli.Command) error {
@@ -938,8 +1599,13 @@ func _deprecatedListParticipants(ctx context.Context, cmd *cli.Command) error {
 }
 
 func getParticipant(ctx context.Context, cmd *cli.Command) error {
-	_ = ctx
-	roomName, identity := participantInfoFromArgOrFlags(cmd)
+	if cmd.Args().Len() == 0 {
+		return cli.ShowSubcommandHelp(cmd)
+	}
+	roomName, identity, err := participantArgs(cmd)
+	if err != nil {
+		return err
+	}
 	res, err := roomClient.GetParticipant(ctx, &livekit.RoomParticipantIdentity{
 		Room:     roomName,
 		Identity: identity,
@@ -948,18 +1614,47 @@ func getParticipant(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
+	if cmd.Bool("raw") {
+		return util.PrintProtoJSON(res)
+	}
 	util.PrintJSON(res)
 
 	return nil
 }
 
 func updateParticipant(ctx context.Context, cmd *cli.Command) error {
-	roomName, identity := participantInfoFromArgOrFlags(cmd)
-	metadata := cmd.String("metadata")
+	var (
+		roomName, identity string
+		err                error
+	)
+	identities := cmd.StringSlice("identities")
+	if len(identities) == 0 {
+		if roomName, identity, err = participantArgs(cmd); err != nil {
+			return err
+		}
+		identities = []string{identity}
+	} else if roomName, err = participantsRoomArg(cmd); err != nil {
+		return err
+	}
+	metadata, err := extractMetadata(cmd)
+	if err != nil {
+		return validationErrorf("%w", err)
+	}
+	if err = validateMetadata(metadata); err != nil {
+		return err
+	}
 	permissions := cmd.String("permissions")
-	if metadata == "" && permissions == "" {
+	permissionsFile := cmd.String("permissions-from-file")
+	if metadata == "" && permissions == "" && permissionsFile == "" {
 		return fmt.Errorf("either metadata or permissions must be set")
 	}
+	if permissions != "" && permissionsFile != "" {
+		return fmt.Errorf("only one of permissions or permissions-from-file can be set")
+	}
+
+	if permissionsFile != "" || cmd.IsSet("identities") {
+		return updateParticipants(ctx, roomName, identities, metadata, permissions, permissionsFile)
+	}
 
 	req := &livekit.UpdateParticipantRequest{
 		Room:     roomName,
@@ -994,10 +1689,71 @@ func updateParticipant(ctx context.Context, cmd *cli.Command) error {
 	return nil
 }
 
+// updateParticipants applies the same update to several participants,
+// reporting the result for each of them.
+func updateParticipants(ctx context.Context, roomName string, identities []string, metadata, permissions, permissionsFile string) error {
+	var filePermission *livekit.ParticipantPermission
+	if permissionsFile != "" {
+		var err error
+		if filePermission, err = ReadRequestFileOrLiteral[livekit.ParticipantPermission](permissionsFile); err != nil {
+			return err
+		}
+	}
+
+	var failed int
+	table := util.CreateTable().Headers("Identity", "Result")
+	for _, identity := range identities {
+		req := &livekit.UpdateParticipantRequest{
+			Room:       roomName,
+			Identity:   identity,
+			Metadata:   metadata,
+			Permission: filePermission,
+		}
+		err := func() error {
+			if permissions == "" {
+				return nil
+			}
+			// merge with the existing permissions of each participant
+			participant, err := roomClient.GetParticipant(ctx, &livekit.RoomParticipantIdentity{
+				Room:     roomName,
+				Identity: identity,
+			})
+			if err != nil {
+				return err
+			}
+			req.Permission = participant.Permission
+			if req.Permission == nil {
+				return nil
+			}
+			return json.Unmarshal([]byte(permissions), req.Permission)
+		}()
+		if err == nil {
+			_, err = roomClient.UpdateParticipant(ctx, req)
+		}
+		if err != nil {
+			failed++
+			table.Row(identity, err.Error())
+		} else {
+			table.Row(identity, "updated")
+		}
+	}
+	fmt.Println(table)
+
+	if failed > 0 {
+		return fmt.Errorf("failed to update %d of %d participants", failed, len(identities))
+	}
+	return nil
+}
+
 func removeParticipant(ctx context.Context, cmd *cli.Command) error {
-	_ = ctx
-	roomName, identity := participantInfoFromArgOrFlags(cmd)
-	_, err := roomClient.RemoveParticipant(ctx, &livekit.RoomParticipantIdentity{
+	if cmd.Args().Len() == 0 {
+		return cli.ShowSubcommandHelp(cmd)
+	}
+	roomName, identity, err := participantArgs(cmd)
+	if err != nil {
+		return err
+	}
+	_, err = roomClient.RemoveParticipant(ctx, &livekit.RoomParticipantIdentity{
 		Room:     roomName,
 		Identity: identity,
 	})
@@ -1017,6 +1773,16 @@ func muteTrack(ctx context.Context, cmd *cli.Command) error {
 	if trackSid == "" {
 		trackSid = cmd.Args().First()
 	}
+	if trackSid == "" {
+		return validationErrorf("track SID is required")
+	}
+	if cmd.Bool("json") {
+		results, err := setTracksMuted(ctx, roomName, identity, []string{trackSid}, livekit.TrackSource_UNKNOWN, muted)
+		if err != nil {
+			return err
+		}
+		return printTrackMuteResults(cmd, results)
+	}
 	_, err := roomClient.MutePublishedTrack(ctx, &livekit.MuteRoomTrackRequest{
 		Room:     roomName,
 		Identity: identity,
@@ -1057,16 +1823,30 @@ func updateSubscriptions(ctx context.Context, cmd *cli.Command) error {
 }
 
 func sendData(ctx context.Context, cmd *cli.Command) error {
-	roomName, _ := participantInfoFromFlags(cmd)
-	identities := cmd.StringSlice("identity")
-	data := cmd.String("data")
-	if data == "" {
-		data = cmd.Args().First()
+	// --room is still accepted in place of the ROOM argument
+	roomName := cmd.String("room")
+	args := cmd.Args().Slice()
+	if roomName == "" {
+		if len(args) == 0 {
+			return cli.ShowSubcommandHelp(cmd)
+		}
+		roomName, args = args[0], args[1:]
+	}
+	data, err := extractData(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	identities := cmd.StringSlice("identities")
+	kind := livekit.DataPacket_RELIABLE
+	if cmd.Bool("lossy") {
+		kind = livekit.DataPacket_LOSSY
 	}
 	topic := cmd.String("topic")
 	req := &livekit.SendDataRequest{
 		Room:                  roomName,
-		Data:                  []byte(data),
+		Data:                  data,
+		Kind:                  kind,
 		DestinationIdentities: identities,
 		// deprecated
 		DestinationSids: cmd.StringSlice("participantID"),
@@ -1074,15 +1854,49 @@ func sendData(ctx context.Context, cmd *cli.Command) error {
 	if topic != "" {
 		req.Topic = &topic
 	}
-	_, err := roomClient.SendData(ctx, req)
-	if err != nil {
+	debugJSON(req)
+	if _, err := roomClient.SendData(ctx, req); err != nil {
 		return err
 	}
 
-	fmt.Println("successfully sent data to room", roomName)
+	to := "all participants"
+	if len(identities) > 0 {
+		to = strings.Join(identities, ", ")
+	}
+	fmt.Printf("sent %d bytes (%s) to %s in room %s\n", len(data), strings.ToLower(kind.String()), to, roomName)
 	return nil
 }
 
+// extractData reads the payload of a data message from exactly one of
+// --data, --data-file or the remaining arguments.
+func extractData(cmd *cli.Command, args []string) ([]byte, error) {
+	var sources int
+	for _, set := range []bool{cmd.IsSet("data"), cmd.IsSet("data-file"), len(args) > 0} {
+		if set {
+			sources++
+		}
+	}
+	switch {
+	case sources == 0:
+		return nil, validationErrorf("DATA, --data or --data-file is required")
+	case sources > 1:
+		return nil, validationErrorf("only one of DATA, --data or --data-file can be specified")
+	case len(args) > 1:
+		return nil, validationErrorf("expected a single DATA argument, got %d", len(args))
+	}
+
+	switch file := cmd.String("data-file"); {
+	case file == "-":
+		return io.ReadAll(os.Stdin)
+	case file != "":
+		return os.ReadFile(file)
+	case len(args) == 1:
+		return []byte(args[0]), nil
+	default:
+		return []byte(cmd.String("data")), nil
+	}
+}
+
 func participantInfoFromFlags(c *cli.Command) (string, string) {
 	return c.String("room"), c.String("identity")
 }
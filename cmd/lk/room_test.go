@@ -0,0 +1,101 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/livekit/protocol/livekit"
+)
+
+func TestSortRooms(t *testing.T) {
+	rooms := func() []*livekit.Room {
+		return []*livekit.Room{
+			{Name: "b", NumParticipants: 1, CreationTime: 300},
+			{Name: "c", NumParticipants: 5, CreationTime: 100},
+			{Name: "a", NumParticipants: 1, CreationTime: 200},
+		}
+	}
+	cases := map[string][]string{
+		"":             {"b", "c", "a"},
+		"name":         {"a", "b", "c"},
+		"participants": {"c", "b", "a"},
+		"age":          {"c", "a", "b"},
+	}
+	for by, expected := range cases {
+		rs := rooms()
+		sortRooms(rs, by)
+		for i, rm := range rs {
+			if rm.Name != expected[i] {
+				t.Errorf("sorting by %q: expected %v, got room %s at %d", by, expected, rm.Name, i)
+				break
+			}
+		}
+	}
+}
+
+func TestParticipantUpdateArgs(t *testing.T) {
+	tests := []struct {
+		args     []string
+		room     string
+		identity string
+		wantErr  bool
+	}{
+		{args: []string{"r", "alice"}, room: "r", identity: "alice"},
+		{args: []string{"--room", "r", "alice"}, room: "r", identity: "alice"},
+		{args: []string{"r"}, wantErr: true},
+		{args: []string{"--room", "r", "x", "alice"}, wantErr: true},
+		{args: []string{"--identities", "a,b", "r"}, room: "r"},
+		{args: []string{"--identities", "a,b", "--room", "r"}, room: "r"},
+		{args: []string{"--identities", "a,b", "r", "alice"}, wantErr: true},
+		{args: []string{"--identities", "a,b", "--room", "r", "alice"}, wantErr: true},
+		{args: []string{"--identities", "a,b"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		var (
+			room, identity string
+			err            error
+		)
+		cmd := &cli.Command{
+			Name:  "update",
+			Flags: []cli.Flag{optional(roomFlag), identitiesFlag},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				if cmd.IsSet("identities") {
+					room, err = participantsRoomArg(cmd)
+				} else {
+					room, identity, err = participantArgs(cmd)
+				}
+				return nil
+			},
+		}
+		if runErr := cmd.Run(context.Background(), append([]string{"update"}, tt.args...)); runErr != nil {
+			t.Fatal(runErr)
+		}
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%v: expected an error", tt.args)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: %v", tt.args, err)
+		} else if room != tt.room || identity != tt.identity {
+			t.Errorf("%v: expected %q %q, got %q %q", tt.args, tt.room, tt.identity, room, identity)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/charmbracelet/huh"
+	"github.com/urfave/cli/v3"
+
+	"github.com/livekit/livekit-cli/pkg/bootstrap"
+	"github.com/livekit/livekit-cli/pkg/util"
+)
+
+var (
+	SandboxCommands = []*cli.Command{
+		{
+			Name:  "sandbox",
+			Usage: "View the sandboxes of your LiveKit Cloud project",
+			Commands: []*cli.Command{
+				{
+					Name:   "list",
+					Usage:  "List sandboxes with the number of templates each offers",
+					Action: listSandboxes,
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:        "server-url",
+							Value:       cloudAPIServerURL,
+							Destination: &serverURL,
+							Hidden:      true,
+						},
+						jsonFlag,
+					},
+				},
+			},
+		},
+	}
+)
+
+func fetchSandboxes(ctx context.Context, cmd *cli.Command) ([]bootstrap.SandboxDetails, error) {
+	token, err := requireToken(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return bootstrap.FetchSandboxes(ctx, token, serverURL)
+}
+
+func listSandboxes(ctx context.Context, cmd *cli.Command) error {
+	sandboxes, err := fetchSandboxes(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	if cmd.Bool("json") {
+		util.PrintJSON(sandboxes)
+		return nil
+	}
+	if len(sandboxes) == 0 {
+		fmt.Println("No sandboxes found, create one at " + bootstrap.SandboxDashboardURL)
+		return nil
+	}
+	table := util.CreateTable().Headers("Name", "ID", "Templates")
+	for _, s := range sandboxes {
+		table.Row(s.Name, s.ID, strconv.Itoa(len(s.ChildTemplates)))
+	}
+	fmt.Println(table)
+	return nil
+}
+
+// selectSandbox asks which of the project's sandboxes to use, returning its ID.
+func selectSandbox(ctx context.Context, cmd *cli.Command) (string, error) {
+	if !isInteractive() {
+		return "", validationErrorf("--choose-sandbox requires a terminal, use --sandbox to name one")
+	}
+	sandboxes, err := fetchSandboxes(ctx, cmd)
+	if err != nil {
+		return "", err
+	}
+	if len(sandboxes) == 0 {
+		return "", errors.New("no sandboxes found, create one at " + bootstrap.SandboxDashboardURL)
+	}
+
+	var id string
+	options := make([]huh.Option[string], 0, len(sandboxes))
+	for _, s := range sandboxes {
+		desc := util.Theme.Help.ShortDesc.Render(fmt.Sprintf("%s, %d templates", s.ID, len(s.ChildTemplates)))
+		options = append(options, huh.NewOption(s.Name+" "+desc, s.ID))
+	}
+	if err := huh.NewSelect[string]().
+		Title("Select Sandbox").
+		Options(options...).
+		Value(&id).
+		WithTheme(util.Theme).
+		Run(); err != nil {
+		return "", err
+	}
+	return id, nil
+}
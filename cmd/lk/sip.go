@@ -16,15 +16,27 @@ package main
 
 import (
 	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"maps"
+	"os"
 	"slices"
 	"strings"
 	"time"
 
+	"github.com/urfave/cli/v3"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/livekit/livekit-cli/pkg/util"
+	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
 	lksdk "github.com/livekit/server-sdk-go/v2"
-	"github.com/urfave/cli/v3"
 )
 
 //lint:file-ignore SA1019 we still support older APIs for compatibility
@@ -44,7 +56,7 @@ var (
 							Name:   "list",
 							Usage:  "List all inbound SIP Trunks",
 							Action: listSipInboundTrunk,
-							Flags:  []cli.Flag{jsonFlag},
+							Flags:  []cli.Flag{listJSONFlag, outputFlag, templateFlag, jsonPathFlag, countOnlyFlag},
 						},
 						{
 							Name:      "create",
@@ -52,6 +64,26 @@ var (
 							Action:    createSIPInboundTrunk,
 							ArgsUsage: RequestDesc[livekit.CreateSIPInboundTrunkRequest](),
 						},
+						{
+							Name:      "update",
+							Usage:     "Update an inbound SIP Trunk in place, changing only the given fields",
+							Action:    updateSIPInboundTrunk,
+							ArgsUsage: "SIPTrunk ID to update",
+							Flags: append([]cli.Flag{
+								&cli.StringFlag{
+									Name:  flagRequest,
+									Usage: "SIPInboundTrunkInfo as JSON file, with the fields to change",
+								},
+								&cli.StringSliceFlag{
+									Name:  "allowed-addresses",
+									Usage: "Replace the IP addresses or CIDR blocks allowed to use the trunk",
+								},
+								&cli.StringSliceFlag{
+									Name:  "allowed-numbers",
+									Usage: "Replace the caller numbers allowed to use the trunk",
+								},
+							}, sipTrunkUpdateFlags...),
+						},
 						{
 							Name:      "delete",
 							Usage:     "Delete a SIP Trunk",
@@ -69,7 +101,7 @@ var (
 							Name:   "list",
 							Usage:  "List all outbound SIP Trunk",
 							Action: listSipOutboundTrunk,
-							Flags:  []cli.Flag{jsonFlag},
+							Flags:  []cli.Flag{listJSONFlag, outputFlag, templateFlag, jsonPathFlag, countOnlyFlag},
 						},
 						{
 							Name:      "create",
@@ -77,6 +109,26 @@ var (
 							Action:    createSIPOutboundTrunk,
 							ArgsUsage: RequestDesc[livekit.CreateSIPOutboundTrunkRequest](),
 						},
+						{
+							Name:      "update",
+							Usage:     "Update an outbound SIP Trunk in place, changing only the given fields",
+							Action:    updateSIPOutboundTrunk,
+							ArgsUsage: "SIPTrunk ID to update",
+							Flags: append([]cli.Flag{
+								&cli.StringFlag{
+									Name:  flagRequest,
+									Usage: "SIPOutboundTrunkInfo as JSON file, with the fields to change",
+								},
+								&cli.StringFlag{
+									Name:  "address",
+									Usage: "Hostname or IP the SIP INVITE is sent to",
+								},
+								&cli.StringFlag{
+									Name:  "transport",
+									Usage: "SIP transport, one of \"auto\", \"udp\", \"tcp\", or \"tls\"",
+								},
+							}, sipTrunkUpdateFlags...),
+						},
 						{
 							Name:      "delete",
 							Usage:     "Delete SIP Trunk",
@@ -94,7 +146,7 @@ var (
 							Name:   "list",
 							Usage:  "List all SIP Dispatch Rule",
 							Action: listSipDispatchRule,
-							Flags:  []cli.Flag{jsonFlag},
+							Flags:  []cli.Flag{listJSONFlag, outputFlag, templateFlag, jsonPathFlag, countOnlyFlag},
 						},
 						{
 							Name:      "create",
@@ -114,6 +166,23 @@ var (
 					Name:  "participant",
 					Usage: "SIP Participant management",
 					Commands: []*cli.Command{
+						{
+							Name:   "list",
+							Usage:  "List active SIP calls, in one room or across all rooms",
+							Before: createRoomClient,
+							Action: listSIPParticipants,
+							Flags: []cli.Flag{
+								&cli.StringFlag{
+									Name:  "room",
+									Usage: "Only list calls in room `NAME`",
+								},
+								&cli.BoolFlag{
+									Name:  "show-numbers",
+									Usage: "Show full phone numbers instead of masking them",
+								},
+								jsonFlag,
+							},
+						},
 						{
 							Name:      "create",
 							Usage:     "Create a SIP Participant",
@@ -145,6 +214,20 @@ var (
 									Usage: "timeout for the call to dial (requires wait flag)",
 									Value: 80 * time.Second,
 								},
+								&cli.StringFlag{
+									Name:      "from-file",
+									Usage:     "CSV `FILE` of destinations to call, with rows of \"call[,room[,identity]]\"",
+									TakesFile: true,
+								},
+								&cli.FloatFlag{
+									Name:  "rate-limit",
+									Usage: "Maximum number of calls to place per `SECOND` with --from-file (0 for no limit)",
+								},
+								&cli.IntFlag{
+									Name:  "concurrency",
+									Usage: "`NUMBER` of calls to place in parallel with --from-file",
+									Value: 1,
+								},
 							},
 						},
 						{
@@ -230,11 +313,36 @@ var (
 	}
 )
 
+var sipTrunkUpdateFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "name",
+		Usage: "Human-readable name of the trunk",
+	},
+	&cli.StringFlag{
+		Name:  "metadata",
+		Usage: "Metadata attached to the trunk",
+	},
+	&cli.StringSliceFlag{
+		Name:  "numbers",
+		Usage: "Replace the phone numbers of the trunk",
+	},
+	&cli.StringFlag{
+		Name:  "auth-user",
+		Usage: "Username used to authenticate calls",
+	},
+	&cli.StringFlag{
+		Name:  "auth-pass",
+		Usage: "Password used to authenticate calls, never printed back",
+	},
+	jsonFlag,
+}
+
 func createSIPClient(cmd *cli.Command) (*lksdk.SIPClient, error) {
 	pc, err := loadProjectDetails(cmd)
 	if err != nil {
 		return nil, err
 	}
+	project = pc
 	return lksdk.NewSIPClient(pc.URL, pc.APIKey, pc.APISecret, withDefaultClientOpts(pc)...), nil
 }
 
@@ -329,23 +437,27 @@ func listSipInboundTrunk(ctx context.Context, cmd *cli.Command) error {
 	if err != nil {
 		return err
 	}
-	return listAndPrint(ctx, cmd, cli.ListSIPInboundTrunk, &livekit.ListSIPInboundTrunkRequest{}, []string{
-		"SipTrunkID", "Name", "Numbers",
-		"AllowedAddresses", "AllowedNumbers",
-		"Authentication",
-		"Encryption",
-		"Headers",
-		"Metadata",
-	}, func(item *livekit.SIPInboundTrunkInfo) []string {
-		return []string{
-			item.SipTrunkId, item.Name, strings.Join(item.Numbers, ","),
-			strings.Join(item.AllowedAddresses, ","), strings.Join(item.AllowedNumbers, ","),
-			userPass(item.AuthUsername, item.AuthPassword != ""),
-			strings.TrimPrefix(item.MediaEncryption.String(), "SIP_MEDIA_ENCRYPT_"),
-			printHeaderMaps(item.Headers, item.HeadersToAttributes),
-			item.Metadata,
-		}
-	})
+	return listAndPrint(ctx, cmd, cli.ListSIPInboundTrunk, &livekit.ListSIPInboundTrunkRequest{}, sipInboundTrunkHeader, sipInboundTrunkRow)
+}
+
+var sipInboundTrunkHeader = []string{
+	"SipTrunkID", "Name", "Numbers",
+	"AllowedAddresses", "AllowedNumbers",
+	"Authentication",
+	"Encryption",
+	"Headers",
+	"Metadata",
+}
+
+func sipInboundTrunkRow(item *livekit.SIPInboundTrunkInfo) []string {
+	return []string{
+		item.SipTrunkId, item.Name, strings.Join(item.Numbers, ","),
+		strings.Join(item.AllowedAddresses, ","), strings.Join(item.AllowedNumbers, ","),
+		userPass(item.AuthUsername, item.AuthPassword != ""),
+		strings.TrimPrefix(item.MediaEncryption.String(), "SIP_MEDIA_ENCRYPT_"),
+		printHeaderMaps(item.Headers, item.HeadersToAttributes),
+		item.Metadata,
+	}
 }
 
 func listSipOutboundTrunk(ctx context.Context, cmd *cli.Command) error {
@@ -353,27 +465,182 @@ func listSipOutboundTrunk(ctx context.Context, cmd *cli.Command) error {
 	if err != nil {
 		return err
 	}
-	return listAndPrint(ctx, cmd, cli.ListSIPOutboundTrunk, &livekit.ListSIPOutboundTrunkRequest{}, []string{
-		"SipTrunkID", "Name",
-		"Address", "Transport",
-		"Numbers",
-		"Authentication",
-		"Encryption",
-		"Headers",
-		"Metadata",
-	}, func(item *livekit.SIPOutboundTrunkInfo) []string {
-		return []string{
-			item.SipTrunkId, item.Name,
-			item.Address, strings.TrimPrefix(item.Transport.String(), "SIP_TRANSPORT_"),
-			strings.Join(item.Numbers, ","),
-			userPass(item.AuthUsername, item.AuthPassword != ""),
-			strings.TrimPrefix(item.MediaEncryption.String(), "SIP_MEDIA_ENCRYPT_"),
-			printHeaderMaps(item.Headers, item.HeadersToAttributes),
-			item.Metadata,
+	return listAndPrint(ctx, cmd, cli.ListSIPOutboundTrunk, &livekit.ListSIPOutboundTrunkRequest{}, sipOutboundTrunkHeader, sipOutboundTrunkRow)
+}
+
+var sipOutboundTrunkHeader = []string{
+	"SipTrunkID", "Name",
+	"Address", "Transport",
+	"Numbers",
+	"Authentication",
+	"Encryption",
+	"Headers",
+	"Metadata",
+}
+
+func sipOutboundTrunkRow(item *livekit.SIPOutboundTrunkInfo) []string {
+	return []string{
+		item.SipTrunkId, item.Name,
+		item.Address, strings.TrimPrefix(item.Transport.String(), "SIP_TRANSPORT_"),
+		strings.Join(item.Numbers, ","),
+		userPass(item.AuthUsername, item.AuthPassword != ""),
+		strings.TrimPrefix(item.MediaEncryption.String(), "SIP_MEDIA_ENCRYPT_"),
+		printHeaderMaps(item.Headers, item.HeadersToAttributes),
+		item.Metadata,
+	}
+}
+
+func updateSIPInboundTrunk(ctx context.Context, cmd *cli.Command) error {
+	id, err := extractArg(cmd)
+	if err != nil {
+		return err
+	}
+	cli, err := createSIPClient(cmd)
+	if err != nil {
+		return err
+	}
+	trunks, err := cli.GetSIPInboundTrunksByIDs(ctx, []string{id})
+	if err != nil {
+		return err
+	}
+	if len(trunks) == 0 || trunks[0] == nil {
+		return fmt.Errorf("inbound SIP Trunk %s not found", id)
+	}
+	info := trunks[0]
+
+	if cmd.IsSet(flagRequest) {
+		req, err := ReadRequest[livekit.SIPInboundTrunkInfo](cmd)
+		if err != nil {
+			return fmt.Errorf("could not read request: %w", err)
 		}
+		overwriteSetFields(info, req)
+	}
+	applySIPTrunkUpdateFlags(cmd, &info.Name, &info.Metadata, &info.Numbers, &info.AuthUsername, &info.AuthPassword)
+	if cmd.IsSet("allowed-addresses") {
+		info.AllowedAddresses = cmd.StringSlice("allowed-addresses")
+	}
+	if cmd.IsSet("allowed-numbers") {
+		info.AllowedNumbers = cmd.StringSlice("allowed-numbers")
+	}
+	info.SipTrunkId = id
+
+	updated := &livekit.SIPInboundTrunkInfo{}
+	if err = updateSIPTrunk(ctx, "UpdateSIPInboundTrunk", id, info, updated); err != nil {
+		return err
+	}
+	if updated.AuthPassword != "" {
+		updated.AuthPassword = "****"
+	}
+	printSIPTrunk(cmd, updated, sipInboundTrunkHeader, sipInboundTrunkRow)
+	return nil
+}
+
+func updateSIPOutboundTrunk(ctx context.Context, cmd *cli.Command) error {
+	id, err := extractArg(cmd)
+	if err != nil {
+		return err
+	}
+	var transport livekit.SIPTransport
+	if t := cmd.String("transport"); t != "" {
+		v, ok := livekit.SIPTransport_value["SIP_TRANSPORT_"+strings.ToUpper(t)]
+		if !ok {
+			return fmt.Errorf("invalid transport: %s", t)
+		}
+		transport = livekit.SIPTransport(v)
+	}
+	cli, err := createSIPClient(cmd)
+	if err != nil {
+		return err
+	}
+	trunks, err := cli.GetSIPOutboundTrunksByIDs(ctx, []string{id})
+	if err != nil {
+		return err
+	}
+	if len(trunks) == 0 || trunks[0] == nil {
+		return fmt.Errorf("outbound SIP Trunk %s not found", id)
+	}
+	info := trunks[0]
+
+	if cmd.IsSet(flagRequest) {
+		req, err := ReadRequest[livekit.SIPOutboundTrunkInfo](cmd)
+		if err != nil {
+			return fmt.Errorf("could not read request: %w", err)
+		}
+		overwriteSetFields(info, req)
+	}
+	applySIPTrunkUpdateFlags(cmd, &info.Name, &info.Metadata, &info.Numbers, &info.AuthUsername, &info.AuthPassword)
+	if cmd.IsSet("address") {
+		info.Address = cmd.String("address")
+	}
+	if cmd.IsSet("transport") {
+		info.Transport = transport
+	}
+	info.SipTrunkId = id
+
+	updated := &livekit.SIPOutboundTrunkInfo{}
+	if err = updateSIPTrunk(ctx, "UpdateSIPOutboundTrunk", id, info, updated); err != nil {
+		return err
+	}
+	if updated.AuthPassword != "" {
+		updated.AuthPassword = "****"
+	}
+	printSIPTrunk(cmd, updated, sipOutboundTrunkHeader, sipOutboundTrunkRow)
+	return nil
+}
+
+// overwriteSetFields copies the fields populated in src onto dst. Unlike
+// proto.Merge, lists and maps are replaced rather than appended to.
+func overwriteSetFields(dst, src proto.Message) {
+	d := dst.ProtoReflect()
+	src.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		d.Set(fd, v)
+		return true
 	})
 }
 
+func applySIPTrunkUpdateFlags(cmd *cli.Command, name, metadata *string, numbers *[]string, user, pass *string) {
+	if cmd.IsSet("name") {
+		*name = cmd.String("name")
+	}
+	if cmd.IsSet("metadata") {
+		*metadata = cmd.String("metadata")
+	}
+	if cmd.IsSet("numbers") {
+		*numbers = cmd.StringSlice("numbers")
+	}
+	if cmd.IsSet("auth-user") {
+		*user = cmd.String("auth-user")
+	}
+	if cmd.IsSet("auth-pass") {
+		*pass = cmd.String("auth-pass")
+	}
+}
+
+// updateSIPTrunk replaces a trunk with info through the SIP service update
+// RPC, which the server SDK in use predates.
+func updateSIPTrunk(ctx context.Context, method, id string, info, res proto.Message) error {
+	replace, err := protojson.Marshal(info)
+	if err != nil {
+		return err
+	}
+	at := auth.NewAccessToken(project.APIKey, project.APISecret).
+		SetSIPGrant(&auth.SIPGrant{Admin: true})
+	return callTwirpJSON(ctx, at, "livekit.SIP", method, map[string]any{
+		"sip_trunk_id": id,
+		"replace":      json.RawMessage(replace),
+	}, res)
+}
+
+func printSIPTrunk[T any](cmd *cli.Command, info *T, header []string, row func(*T) []string) {
+	if cmd.Bool("json") {
+		util.PrintJSON(info)
+		return
+	}
+	table := util.CreateTable().Headers(header...)
+	table.Row(row(info)...)
+	fmt.Println(table)
+}
+
 func deleteSIPTrunk(ctx context.Context, cmd *cli.Command) error {
 	cli, err := createSIPClient(cmd)
 	if err != nil {
@@ -519,36 +786,14 @@ func createSIPParticipant(ctx context.Context, cmd *cli.Command) error {
 	if err != nil {
 		return err
 	}
+	if path := cmd.String("from-file"); path != "" {
+		return createSIPParticipantsFromFile(ctx, cmd, cli, path)
+	}
 	return createAndPrintReqs(ctx, cmd, func(req *livekit.CreateSIPParticipantRequest) error {
-		if v := cmd.String("trunk"); v != "" {
-			req.SipTrunkId = v
-		}
-		if v := cmd.String("number"); v != "" {
-			req.SipNumber = v
-		}
-		if v := cmd.String("call"); v != "" {
-			req.SipCallTo = v
-		}
-		if v := cmd.String("room"); v != "" {
-			req.RoomName = v
-		}
-		if cmd.Bool("wait") {
-			req.WaitUntilAnswered = true
-		}
+		applySIPParticipantFlags(cmd, req)
 		return req.Validate()
 	}, func(ctx context.Context, req *livekit.CreateSIPParticipantRequest) (*livekit.SIPParticipantInfo, error) {
-		// CreateSIPParticipant will wait for LiveKit Participant to be created and that can take some time.
-		// Default deadline is too short, thus, we must set a higher deadline for it.
-		timeout := 30 * time.Second
-		if req.WaitUntilAnswered {
-			if dt := cmd.Duration("timeout"); dt != 0 {
-				timeout = dt
-			}
-		}
-		ctx, cancel := context.WithTimeout(ctx, timeout)
-		defer cancel()
-
-		resp, err := cli.CreateSIPParticipant(ctx, req)
+		resp, err := dialSIPParticipant(ctx, cmd, cli, req)
 		if e := lksdk.SIPStatusFrom(err); e != nil {
 			msg := e.Status
 			if msg == "" {
@@ -561,6 +806,188 @@ func createSIPParticipant(ctx context.Context, cmd *cli.Command) error {
 	}, printSIPParticipantInfo)
 }
 
+func applySIPParticipantFlags(cmd *cli.Command, req *livekit.CreateSIPParticipantRequest) {
+	if v := cmd.String("trunk"); v != "" {
+		req.SipTrunkId = v
+	}
+	if v := cmd.String("number"); v != "" {
+		req.SipNumber = v
+	}
+	if v := cmd.String("call"); v != "" {
+		req.SipCallTo = v
+	}
+	if v := cmd.String("room"); v != "" {
+		req.RoomName = v
+	}
+	if cmd.Bool("wait") {
+		req.WaitUntilAnswered = true
+	}
+}
+
+func dialSIPParticipant(ctx context.Context, cmd *cli.Command, cli *lksdk.SIPClient, req *livekit.CreateSIPParticipantRequest) (*livekit.SIPParticipantInfo, error) {
+	// CreateSIPParticipant will wait for LiveKit Participant to be created and that can take some time.
+	// Default deadline is too short, thus, we must set a higher deadline for it.
+	timeout := 30 * time.Second
+	if req.WaitUntilAnswered {
+		if dt := cmd.Duration("timeout"); dt != 0 {
+			timeout = dt
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return cli.CreateSIPParticipant(ctx, req)
+}
+
+type sipCallTarget struct {
+	call     string
+	room     string
+	identity string
+}
+
+// readSIPCallTargets parses a CSV file with one destination per row. Rows may
+// also name the room and participant identity to use for that call. A header
+// row starting with "call" and lines starting with '#' are skipped.
+func readSIPCallTargets(path string) ([]sipCallTarget, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []sipCallTarget
+	for i, rec := range records {
+		if i == 0 && strings.EqualFold(strings.TrimSpace(rec[0]), "call") {
+			continue
+		}
+		if len(rec) > 3 {
+			return nil, fmt.Errorf("row %d: expected at most 3 columns, got %d", i+1, len(rec))
+		}
+		t := sipCallTarget{call: strings.TrimSpace(rec[0])}
+		if t.call == "" {
+			return nil, fmt.Errorf("row %d: missing number to call", i+1)
+		}
+		if len(rec) > 1 {
+			t.room = strings.TrimSpace(rec[1])
+		}
+		if len(rec) > 2 {
+			t.identity = strings.TrimSpace(rec[2])
+		}
+		targets = append(targets, t)
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("no destinations found in " + path)
+	}
+	return targets, nil
+}
+
+func createSIPParticipantsFromFile(ctx context.Context, cmd *cli.Command, cli *lksdk.SIPClient, path string) error {
+	targets, err := readSIPCallTargets(path)
+	if err != nil {
+		return err
+	}
+
+	// an optional request argument provides defaults for every call
+	base := &livekit.CreateSIPParticipantRequest{}
+	if args := cmd.Args(); args.Len() > 1 {
+		return errors.New("at most one JSON request can be combined with --from-file")
+	} else if args.Present() {
+		if base, err = ReadRequestFileOrLiteral[livekit.CreateSIPParticipantRequest](args.First()); err != nil {
+			return err
+		}
+	}
+	applySIPParticipantFlags(cmd, base)
+
+	concurrency := int(cmd.Int("concurrency"))
+	if concurrency < 1 {
+		return validationErrorf("--concurrency must be at least 1")
+	}
+	limiter := rate.NewLimiter(rate.Inf, 1)
+	if r := cmd.Float("rate-limit"); r > 0 {
+		limiter = rate.NewLimiter(rate.Limit(r), 1)
+	}
+
+	type callResult struct {
+		req    *livekit.CreateSIPParticipantRequest
+		info   *livekit.SIPParticipantInfo
+		status string
+		failed bool
+	}
+	results := make([]callResult, len(targets))
+
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+	for i, t := range targets {
+		req := proto.Clone(base).(*livekit.CreateSIPParticipantRequest)
+		req.SipCallTo = t.call
+		if t.room != "" {
+			req.RoomName = t.room
+		}
+		if t.identity != "" {
+			req.ParticipantIdentity = t.identity
+		}
+		results[i].req = req
+
+		if err := req.Validate(); err != nil {
+			results[i].status, results[i].failed = err.Error(), true
+			continue
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			results[i].status, results[i].failed = "skipped", true
+			continue
+		}
+		g.Go(func() error {
+			info, err := dialSIPParticipant(ctx, cmd, cli, req)
+			results[i].info = info
+			results[i].status, results[i].failed = sipCallStatus(err), err != nil
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	failed := 0
+	table := util.CreateTable().Headers("Call", "Room", "Identity", "Status", "SIPCallID")
+	for _, r := range results {
+		if r.failed {
+			failed++
+		}
+		identity, callID := r.req.ParticipantIdentity, ""
+		if r.info != nil {
+			identity, callID = r.info.ParticipantIdentity, r.info.SipCallId
+		}
+		table.Row(r.req.SipCallTo, r.req.RoomName, identity, r.status, callID)
+	}
+	fmt.Println(table)
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d call(s) failed", failed, len(results))
+	}
+	return nil
+}
+
+func sipCallStatus(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	if e := lksdk.SIPStatusFrom(err); e != nil {
+		msg := e.Status
+		if msg == "" {
+			msg = e.Code.ShortName()
+		}
+		return fmt.Sprintf("%d %s", e.Code, msg)
+	}
+	return err.Error()
+}
+
 func createSIPParticipantLegacy(ctx context.Context, cmd *cli.Command) error {
 	cli, err := createSIPClient(cmd)
 	if err != nil {
@@ -576,6 +1003,101 @@ func createSIPParticipantLegacy(ctx context.Context, cmd *cli.Command) error {
 	}, printSIPParticipantInfo)
 }
 
+// SIPCall is an active SIP participant listed by `sip participant list`.
+type SIPCall struct {
+	Room        string `json:"room"`
+	Identity    string `json:"identity"`
+	PhoneNumber string `json:"phone_number"`
+	TrunkNumber string `json:"trunk_number"`
+	TrunkID     string `json:"trunk_id"`
+	CallID      string `json:"call_id"`
+	State       string `json:"state"`
+	DurationSec int64  `json:"duration_sec"`
+}
+
+func listSIPParticipants(ctx context.Context, cmd *cli.Command) error {
+	rooms := []string{cmd.String("room")}
+	if rooms[0] == "" {
+		res, err := roomClient.ListRooms(ctx, &livekit.ListRoomsRequest{})
+		if err != nil {
+			return err
+		}
+		rooms = rooms[:0]
+		for _, r := range res.Rooms {
+			rooms = append(rooms, r.Name)
+		}
+	}
+
+	now := time.Now()
+	calls := []SIPCall{}
+	for _, room := range rooms {
+		res, err := roomClient.ListParticipants(ctx, &livekit.ListParticipantsRequest{Room: room})
+		if err != nil {
+			return err
+		}
+		for _, p := range res.Participants {
+			if p.Kind != livekit.ParticipantInfo_SIP {
+				continue
+			}
+			call := SIPCall{
+				Room:        room,
+				Identity:    p.Identity,
+				PhoneNumber: p.Attributes[livekit.AttrSIPPhoneNumber],
+				TrunkNumber: p.Attributes[livekit.AttrSIPTrunkNumber],
+				TrunkID:     p.Attributes[livekit.AttrSIPTrunkID],
+				CallID:      p.Attributes[livekit.AttrSIPCallID],
+				State:       p.Attributes[livekit.AttrSIPCallStatus],
+				DurationSec: int64(now.Sub(participantJoinedAt(p)).Seconds()),
+			}
+			if call.State == "" {
+				call.State = strings.ToLower(p.State.String())
+			}
+			if !cmd.Bool("show-numbers") {
+				call.PhoneNumber = maskPhoneNumber(call.PhoneNumber)
+			}
+			calls = append(calls, call)
+		}
+	}
+
+	if cmd.Bool("json") {
+		util.PrintJSON(calls)
+		return nil
+	}
+	if len(calls) == 0 {
+		fmt.Println("No active SIP calls")
+		return nil
+	}
+	table := util.CreateTable().Headers("Room", "Identity", "Number", "TrunkNumber", "SipTrunkID", "State", "Duration")
+	for _, c := range calls {
+		table.Row(c.Room, c.Identity, c.PhoneNumber, c.TrunkNumber, c.TrunkID, c.State,
+			(time.Duration(c.DurationSec) * time.Second).String())
+	}
+	fmt.Println(table)
+	return nil
+}
+
+// maskPhoneNumber hides all digits but the last 4, keeping separators.
+func maskPhoneNumber(number string) string {
+	const visible = 4
+	var out strings.Builder
+	digits := 0
+	for _, r := range number {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	for _, r := range number {
+		if r >= '0' && r <= '9' {
+			if digits > visible {
+				r = '*'
+			}
+			digits--
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
+
 func transferSIPParticipant(ctx context.Context, cmd *cli.Command) error {
 	roomName, identity := participantInfoFromArgOrFlags(cmd)
 	to := cmd.String("to")
@@ -0,0 +1,57 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSIPCallTargets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "numbers.csv")
+	data := "call,room,identity\n" +
+		"+15550001, room-a, caller-a\n" +
+		"# skipped\n" +
+		"+15550002\n"
+	require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+
+	targets, err := readSIPCallTargets(path)
+	require.NoError(t, err)
+	require.Equal(t, []sipCallTarget{
+		{call: "+15550001", room: "room-a", identity: "caller-a"},
+		{call: "+15550002"},
+	}, targets)
+
+	require.NoError(t, os.WriteFile(path, []byte("+15550001,a,b,c\n"), 0600))
+	_, err = readSIPCallTargets(path)
+	require.Error(t, err)
+}
+
+func TestMaskPhoneNumber(t *testing.T) {
+	cases := map[string]string{
+		"+15105550100":    "+*******0100",
+		"+1 510 555 0100": "+* *** *** 0100",
+		"0100":            "0100",
+		"":                "",
+	}
+	for in, expected := range cases {
+		if actual := maskPhoneNumber(in); actual != expected {
+			t.Errorf("maskPhoneNumber(%q) = %q, expected %q", in, actual, expected)
+		}
+	}
+}
@@ -19,15 +19,22 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/huh"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
 	"github.com/urfave/cli/v3"
 
+	"github.com/livekit/livekit-cli/pkg/config"
 	"github.com/livekit/livekit-cli/pkg/util"
 	"github.com/livekit/protocol/auth"
 	"github.com/livekit/protocol/livekit"
+	lksdk "github.com/livekit/server-sdk-go/v2"
 )
 
 const (
@@ -38,6 +45,10 @@ const (
 	usageEgress   = "Ability to interact with Egress services"
 	usageIngress  = "Ability to interact with Ingress services"
 	usageMetadata = "Ability to update their own name and metadata"
+
+	// defaultMaxTokenSize keeps tokens well below the 8KB header limit of
+	// common proxies, leaving room for other headers and URL encoding.
+	defaultMaxTokenSize = 4096
 )
 
 var (
@@ -80,6 +91,18 @@ var (
 							Name:  "allow-update-metadata",
 							Usage: usageMetadata,
 						},
+						&cli.BoolFlag{
+							Name:  "can-publish",
+							Usage: "Allow publishing tracks, implies --join, use --can-publish=false to deny",
+						},
+						&cli.BoolFlag{
+							Name:  "can-subscribe",
+							Usage: "Allow subscribing to tracks, implies --join, use --can-subscribe=false to deny",
+						},
+						&cli.BoolFlag{
+							Name:  "can-publish-data",
+							Usage: "Allow publishing data messages, implies --join, use --can-publish-data=false to deny",
+						},
 						&cli.StringSliceFlag{
 							Name:  "allow-source",
 							Usage: "Restrict publishing to only `SOURCE` types (e.g. --allow-source camera,microphone), defaults to all",
@@ -104,14 +127,64 @@ var (
 							Usage: "`JSON` metadata to encode in the token, will be passed to participant",
 						},
 						&cli.StringFlag{
-							Name:  "valid-for",
-							Usage: "`TIME` that the token is valid for, e.g. \"5m\", \"1h10m\" (s: seconds, m: minutes, h: hours)",
-							Value: "5m",
+							Name:    "valid-for",
+							Aliases: []string{"ttl"},
+							Usage:   "`TIME` that the token is valid for, e.g. \"5m\", \"1h10m\" (s: seconds, m: minutes, h: hours)",
+							Value:   "5m",
 						},
 						&cli.StringFlag{
 							Name:  "grant",
 							Usage: "Additional `VIDEO_GRANT` fields. It'll be merged with other arguments (JSON formatted)",
 						},
+						&cli.StringFlag{
+							Name:      "grant-file",
+							Usage:     "`JSON` file containing the full claims (identity, video, sip, roomConfig, etc.) to sign. Other arguments are merged on top",
+							TakesFile: true,
+						},
+						&cli.StringFlag{
+							Name:  "not-before",
+							Usage: "`TIME` the token becomes valid, as RFC 3339 (e.g. \"2025-01-02T15:04:05Z\") or a duration from now (e.g. \"1h\"). --valid-for counts from this time",
+						},
+						&cli.StringFlag{
+							Name:  "room-join",
+							Usage: "Shorthand for --join --room `ROOM`",
+						},
+						&cli.BoolFlag{
+							Name:  "create-room",
+							Usage: "Create the room to join if it doesn't exist yet",
+						},
+						&cli.IntFlag{
+							Name:  "max-size",
+							Usage: "Warn when the token is larger than `BYTES`, as some clients and proxies reject large headers or URLs",
+							Value: defaultMaxTokenSize,
+						},
+						&cli.BoolFlag{
+							Name:  "strict",
+							Usage: "Fail instead of warning when the token is larger than --max-size",
+						},
+						jsonFlag,
+					},
+				},
+				{
+					Name:      "verify",
+					Usage:     "Verify an access token against the project's secret and report its validity period",
+					UsageText: "lk token verify [OPTIONS] TOKEN",
+					ArgsUsage: "TOKEN",
+					Action:    verifyToken,
+					Flags:     []cli.Flag{jsonFlag},
+				},
+				{
+					Name:      "decode",
+					Usage:     "Print the claims of an access token, without needing the project's secret",
+					UsageText: "lk token decode [OPTIONS] [TOKEN]",
+					ArgsUsage: "[TOKEN] to decode, read from stdin when omitted or \"-\"",
+					Action:    decodeToken,
+					Flags: []cli.Flag{
+						&cli.BoolFlag{
+							Name:  "verify",
+							Usage: "Also verify the signature against the project's secret, failing for invalid or expired tokens",
+						},
+						jsonFlag,
 					},
 				},
 			},
@@ -201,28 +274,75 @@ func createToken(ctx context.Context, c *cli.Command) error {
 	p := c.String("identity") // required only for join
 	name := c.String("name")
 	room := c.String("room")
+	joinRoom := c.Bool("join")
+	if r := c.String("room-join"); r != "" {
+		if room != "" && room != r {
+			return validationErrorf("--room-join and --room name different rooms")
+		}
+		room = r
+		joinRoom = true
+	}
+	// publish and subscribe permissions only apply to participants joining
+	for _, f := range []string{"can-publish", "can-subscribe", "can-publish-data"} {
+		if c.IsSet(f) {
+			joinRoom = true
+		}
+	}
 	metadata := c.String("metadata")
 	validFor := c.String("valid-for")
 	roomPreset := c.String("room-preset")
 
-	grant := &auth.VideoGrant{
-		Room: room,
+	var notBefore time.Time
+	if str := c.String("not-before"); str != "" {
+		var err error
+		if notBefore, err = parseNotBefore(str, time.Now()); err != nil {
+			return err
+		}
 	}
+
+	claims := &auth.ClaimGrants{}
 	hasPerms := false
+	if grantFile := c.String("grant-file"); grantFile != "" {
+		var err error
+		if claims, err = readGrantFile(grantFile); err != nil {
+			return err
+		}
+		if p == "" {
+			p = claims.Identity
+		}
+		hasPerms = true
+	}
+
+	grant := claims.Video
+	if grant == nil {
+		grant = &auth.VideoGrant{}
+	}
+	if room != "" {
+		grant.Room = room
+	}
 	if c.Bool("create") {
 		grant.RoomCreate = true
 		hasPerms = true
 	}
-	if c.Bool("join") {
+	if joinRoom {
 		grant.RoomJoin = true
 		if p == "" {
 			return errors.New("participant identity is required")
 		}
-		if room == "" {
+		if grant.Room == "" {
 			return errors.New("room is required")
 		}
 		hasPerms = true
 	}
+	if c.IsSet("can-publish") {
+		grant.SetCanPublish(c.Bool("can-publish"))
+	}
+	if c.IsSet("can-subscribe") {
+		grant.SetCanSubscribe(c.Bool("can-subscribe"))
+	}
+	if c.IsSet("can-publish-data") {
+		grant.SetCanPublishData(c.Bool("can-publish-data"))
+	}
 	if c.Bool("admin") {
 		grant.RoomAdmin = true
 		hasPerms = true
@@ -323,44 +443,413 @@ func createToken(ctx context.Context, c *cli.Command) error {
 		}
 	}
 
-	pc, err := loadProjectDetails(c, ignoreURL)
+	var opts []loadOption
+	if !c.Bool("create-room") {
+		opts = append(opts, ignoreURL)
+	} else if !grant.RoomJoin || grant.Room == "" {
+		return validationErrorf("--create-room requires a room to join, see --room-join")
+	}
+	pc, err := loadProjectDetails(c, opts...)
 	if err != nil {
 		return err
 	}
 
+	var roomStatus string
+	if c.Bool("create-room") {
+		if roomStatus, err = ensureRoom(ctx, pc, grant.Room); err != nil {
+			return err
+		}
+	}
+
 	at := accessToken(pc.APIKey, pc.APISecret, grant, p)
 
+	if claims.SIP != nil {
+		at.SetSIPGrant(claims.SIP)
+	}
+	if claims.RoomConfig != nil {
+		at.SetRoomConfig((*livekit.RoomConfiguration)(claims.RoomConfig))
+	}
+	if len(claims.Attributes) > 0 {
+		at.SetAttributes(claims.Attributes)
+	}
+	if claims.Kind != "" {
+		at.SetKind(claims.GetParticipantKind())
+	}
+	if metadata == "" {
+		metadata = claims.Metadata
+	}
 	if metadata != "" {
 		at.SetMetadata(metadata)
 	}
+	if roomPreset == "" {
+		roomPreset = claims.RoomPreset
+	}
 	if roomPreset != "" {
 		at.SetRoomPreset(roomPreset)
 	}
+	if name == "" {
+		name = claims.Name
+	}
 	if name == "" {
 		name = p
 	}
 	at.SetName(name)
 	if validFor != "" {
 		if dur, err := time.ParseDuration(validFor); err == nil {
-			fmt.Println("valid for (mins): ", int(dur/time.Minute))
+			if !c.Bool("json") {
+				fmt.Println("valid for (mins): ", int(dur/time.Minute))
+			}
 			at.SetValidFor(dur)
 		} else {
 			return err
 		}
 	}
 
-	token, err := at.ToJWT()
+	var token string
+	if notBefore.IsZero() {
+		token, err = at.ToJWT()
+	} else {
+		if !c.Bool("json") {
+			fmt.Println("not before: ", notBefore.Format(time.RFC3339))
+		}
+		dur, _ := time.ParseDuration(validFor)
+		token, err = signTokenNotBefore(pc.APIKey, pc.APISecret, at.GetGrants(), notBefore, dur)
+	}
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("Token grants:")
-	util.PrintJSON(grant)
+	if err = checkTokenSize(token, int(c.Int("max-size")), c.Bool("strict")); err != nil {
+		return err
+	}
+
+	if c.Bool("json") {
+		out := map[string]any{
+			"token":  token,
+			"size":   len(token),
+			"claims": at.GetGrants(),
+		}
+		if roomStatus != "" {
+			out["room"] = grant.Room
+			out["roomStatus"] = roomStatus
+		}
+		util.PrintJSON(out)
+		return nil
+	}
+
+	if debugEnabled() {
+		fmt.Println("Token claims:")
+		util.PrintJSON(at.GetGrants())
+	} else {
+		fmt.Println("Token grants:")
+		util.PrintJSON(grant)
+	}
 	fmt.Println()
+	if roomStatus != "" {
+		fmt.Printf("Room: %s (%s)\n", grant.Room, roomStatus)
+	}
 	fmt.Println("Access token:", token)
 	return nil
 }
 
+// checkTokenSize warns on stderr when token is larger than maxSize bytes, or
+// fails when strict. A maxSize of 0 disables the check.
+func checkTokenSize(token string, maxSize int, strict bool) error {
+	if maxSize <= 0 || len(token) <= maxSize {
+		return nil
+	}
+	msg := fmt.Sprintf("token is %d bytes, over the %d byte limit, some clients and proxies may reject it. Consider smaller metadata, attributes or grants", len(token), maxSize)
+	if strict {
+		return errors.New(msg)
+	}
+	warnf("WARNING: %s", msg)
+	return nil
+}
+
+// ensureRoom creates a room unless it already exists, reporting which.
+func ensureRoom(ctx context.Context, pc *config.ProjectConfig, name string) (string, error) {
+	client := lksdk.NewRoomServiceClient(pc.URL, pc.APIKey, pc.APISecret, withDefaultClientOpts(pc)...)
+	res, err := client.ListRooms(ctx, &livekit.ListRoomsRequest{Names: []string{name}})
+	if err != nil {
+		return "", err
+	}
+	if len(res.Rooms) > 0 {
+		return "existing", nil
+	}
+	if _, err = client.CreateRoom(ctx, &livekit.CreateRoomRequest{Name: name}); err != nil {
+		return "", err
+	}
+	return "created", nil
+}
+
+// parseNotBefore accepts an absolute RFC 3339 time or a duration from now.
+func parseNotBefore(str string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, str); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(str); err == nil {
+		return now.Add(d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --not-before %q, expected an RFC 3339 time or a duration", str)
+}
+
+// signTokenNotBefore signs claims like AccessToken.ToJWT, which always uses
+// the current time for nbf, but with a validity period starting at notBefore.
+func signTokenNotBefore(apiKey, apiSecret string, claims *auth.ClaimGrants, notBefore time.Time, validFor time.Duration) (string, error) {
+	if validFor <= 0 {
+		validFor = 6 * time.Hour
+	}
+	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte(apiSecret)},
+		(&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return "", err
+	}
+	cl := jwt.Claims{
+		Issuer:    apiKey,
+		NotBefore: jwt.NewNumericDate(notBefore),
+		Expiry:    jwt.NewNumericDate(notBefore.Add(validFor)),
+		Subject:   claims.Identity,
+	}
+	return jwt.Signed(sig).Claims(cl).Claims(claims).CompactSerialize()
+}
+
+type TokenVerification struct {
+	Status    string            `json:"status"`
+	APIKey    string            `json:"api_key"`
+	Identity  string            `json:"identity,omitempty"`
+	NotBefore *time.Time        `json:"not_before,omitempty"`
+	Expires   *time.Time        `json:"expires,omitempty"`
+	Claims    *auth.ClaimGrants `json:"claims,omitempty"`
+}
+
+func verifyToken(ctx context.Context, c *cli.Command) error {
+	raw, err := extractArg(c)
+	if err != nil {
+		return err
+	}
+	pc, err := loadProjectDetails(c, ignoreURL)
+	if err != nil {
+		return err
+	}
+
+	res, err := checkToken(raw, pc.APIKey, pc.APISecret, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("json") {
+		util.PrintJSON(res)
+	} else {
+		fmt.Println("Status:    ", res.Status)
+		fmt.Println("API key:   ", res.APIKey)
+		fmt.Println("Identity:  ", res.Identity)
+		if res.NotBefore != nil {
+			fmt.Println("Not before:", res.NotBefore.Local().Format(time.RFC3339))
+		}
+		if res.Expires != nil {
+			fmt.Println("Expires:   ", res.Expires.Local().Format(time.RFC3339))
+		}
+	}
+	if res.Status != tokenStatusValid {
+		return cli.Exit("", 1)
+	}
+	return nil
+}
+
+const (
+	tokenStatusValid       = "valid"
+	tokenStatusNotYetValid = "not yet valid"
+	tokenStatusExpired     = "expired"
+	tokenStatusBadSig      = "invalid signature"
+	tokenStatusUnverified  = "signature not verified"
+)
+
+// checkToken verifies the signature of a token and classifies its validity
+// period at now. Tokens issued by another key are reported as an error.
+func checkToken(raw, apiKey, apiSecret string, now time.Time) (*TokenVerification, error) {
+	tok, res, err := parseToken(raw)
+	if err != nil {
+		return nil, err
+	}
+	if res.APIKey != apiKey {
+		return nil, fmt.Errorf("token was issued by API key %s, but the project uses %s", res.APIKey, apiKey)
+	}
+	// claims are only reported once the signature is verified
+	res.Claims = nil
+
+	cl := jwt.Claims{}
+	claims := &auth.ClaimGrants{}
+	if err = tok.Claims([]byte(apiSecret), &cl, claims); err != nil {
+		res.Status = tokenStatusBadSig
+		return res, nil
+	}
+	res.Claims = claims
+
+	switch err = cl.ValidateWithLeeway(jwt.Expected{Time: now}, 0); {
+	case err == nil:
+		res.Status = tokenStatusValid
+	case errors.Is(err, jwt.ErrNotValidYet):
+		res.Status = tokenStatusNotYetValid
+	case errors.Is(err, jwt.ErrExpired):
+		res.Status = tokenStatusExpired
+	default:
+		return nil, err
+	}
+	return res, nil
+}
+
+// parseToken decodes the claims of a token without verifying its signature.
+func parseToken(raw string) (*jwt.JSONWebToken, *TokenVerification, error) {
+	tok, err := jwt.ParseSigned(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not parse token: %w", err)
+	}
+
+	cl := jwt.Claims{}
+	claims := &auth.ClaimGrants{}
+	if err = tok.UnsafeClaimsWithoutVerification(&cl, claims); err != nil {
+		return nil, nil, err
+	}
+	claims.Identity = cl.Subject
+
+	res := &TokenVerification{
+		APIKey:   cl.Issuer,
+		Identity: cl.Subject,
+		Claims:   claims,
+	}
+	if cl.NotBefore != nil {
+		nbf := cl.NotBefore.Time()
+		res.NotBefore = &nbf
+	}
+	if cl.Expiry != nil {
+		exp := cl.Expiry.Time()
+		res.Expires = &exp
+	}
+	return tok, res, nil
+}
+
+// inspectToken decodes a token, classifying its validity period at now
+// without verifying its signature.
+func inspectToken(raw string, now time.Time) (*TokenVerification, error) {
+	_, res, err := parseToken(raw)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case res.NotBefore != nil && now.Before(*res.NotBefore):
+		res.Status = tokenStatusNotYetValid
+	case res.Expires != nil && now.After(*res.Expires):
+		res.Status = tokenStatusExpired
+	default:
+		res.Status = tokenStatusUnverified
+	}
+	return res, nil
+}
+
+func decodeToken(ctx context.Context, c *cli.Command) error {
+	raw := c.Args().First()
+	if raw == "" || raw == "-" {
+		if raw == "" && isInteractive() {
+			return errors.New("token is required, pass it as an argument or pipe it to stdin")
+		}
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		raw = string(b)
+	}
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return errors.New("token is required")
+	}
+
+	res, err := inspectToken(raw, time.Now())
+	if err != nil {
+		return err
+	}
+	if c.Bool("verify") {
+		pc, err := loadProjectDetails(c, ignoreURL)
+		if err != nil {
+			return err
+		}
+		verified, err := checkToken(raw, pc.APIKey, pc.APISecret, time.Now())
+		if err != nil {
+			return err
+		}
+		// keep the decoded claims of tokens with a bad signature for debugging
+		verified.Claims = res.Claims
+		res = verified
+	}
+
+	if c.Bool("json") {
+		util.PrintJSON(res)
+	} else {
+		table := util.CreateTable().Headers("Field", "Value")
+		table.Row("Status", res.Status)
+		table.Row("API key", res.APIKey)
+		table.Row("Identity", res.Identity)
+		if res.Claims.Name != "" {
+			table.Row("Name", res.Claims.Name)
+		}
+		if res.Claims.Kind != "" {
+			table.Row("Kind", res.Claims.Kind)
+		}
+		if v := res.Claims.Video; v != nil {
+			if v.Room != "" {
+				table.Row("Room", v.Room)
+			}
+			table.Row("Grants", strings.Join(grantNames(v), ", "))
+		}
+		if res.Claims.SIP != nil {
+			table.Row("SIP grants", strings.Join(grantNames(res.Claims.SIP), ", "))
+		}
+		if res.Claims.Metadata != "" {
+			table.Row("Metadata", res.Claims.Metadata)
+		}
+		if res.NotBefore != nil {
+			table.Row("Not before", res.NotBefore.Local().Format(time.RFC3339))
+		}
+		if res.Expires != nil {
+			table.Row("Expires", fmt.Sprintf("%s (%s)", res.Expires.Local().Format(time.RFC3339), relativeTime(*res.Expires, time.Now())))
+		}
+		fmt.Println(table)
+	}
+	if c.Bool("verify") && res.Status != tokenStatusValid {
+		return cli.Exit("", 1)
+	}
+	return nil
+}
+
+// grantNames lists the JSON names of the permissions granted by a
+// grant, for display.
+func grantNames(grant any) []string {
+	b, err := json.Marshal(grant)
+	if err != nil {
+		return nil
+	}
+	fields := map[string]any{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil
+	}
+	var names []string
+	for k, v := range fields {
+		if v == true {
+			names = append(names, k)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// relativeTime describes t relative to now, e.g. "in 5m0s" or "1h0m0s ago".
+func relativeTime(t, now time.Time) string {
+	d := t.Sub(now).Round(time.Second)
+	if d < 0 {
+		return (-d).String() + " ago"
+	}
+	return "in " + d.String()
+}
+
 func accessToken(apiKey, apiSecret string, grant *auth.VideoGrant, identity string) *auth.AccessToken {
 	if apiKey == "" && apiSecret == "" {
 		// not provided, don't sign request
@@ -371,3 +860,33 @@ func accessToken(apiKey, apiSecret string, grant *auth.VideoGrant, identity stri
 		SetIdentity(identity)
 	return at
 }
+
+// readGrantFile reads the full set of claims to encode in a token, rejecting
+// unknown fields so that typos don't silently produce a weaker grant.
+func readGrantFile(path string) (*auth.ClaimGrants, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// ClaimGrants leaves the identity out of its JSON, as it's the subject
+	file := struct {
+		Identity string `json:"identity"`
+		*auth.ClaimGrants
+	}{ClaimGrants: &auth.ClaimGrants{}}
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid grant file %s: %w", path, err)
+	}
+	claims := file.ClaimGrants
+	claims.Identity = file.Identity
+	if claims.Kind != "" && claims.GetParticipantKind() == livekit.ParticipantInfo_STANDARD && !strings.EqualFold(claims.Kind, "standard") {
+		return nil, fmt.Errorf("invalid grant file %s: unknown participant kind %q", path, claims.Kind)
+	}
+	if claims.Video == nil && claims.SIP == nil {
+		return nil, fmt.Errorf("invalid grant file %s: at least one of video or sip grants is required", path)
+	}
+	return claims, nil
+}
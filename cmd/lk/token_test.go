@@ -0,0 +1,134 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/livekit/protocol/auth"
+)
+
+func TestParseNotBefore(t *testing.T) {
+	now := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+
+	nbf, err := parseNotBefore("2025-01-03T10:00:00Z", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2025, 1, 3, 10, 0, 0, 0, time.UTC), nbf)
+
+	nbf, err = parseNotBefore("90m", now)
+	require.NoError(t, err)
+	require.Equal(t, now.Add(90*time.Minute), nbf)
+
+	_, err = parseNotBefore("tomorrow", now)
+	require.Error(t, err)
+}
+
+func TestCheckTokenNotBefore(t *testing.T) {
+	const key, secret = "APIkey", "a-secret-that-is-long-enough-to-sign"
+	nbf := time.Now().Add(time.Hour)
+	claims := &auth.ClaimGrants{Identity: "me", Video: &auth.VideoGrant{RoomJoin: true, Room: "r"}}
+	token, err := signTokenNotBefore(key, secret, claims, nbf, 10*time.Minute)
+	require.NoError(t, err)
+
+	res, err := checkToken(token, key, secret, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, tokenStatusNotYetValid, res.Status)
+	require.Equal(t, "me", res.Identity)
+	require.Equal(t, nbf.Unix(), res.NotBefore.Unix())
+
+	res, err = checkToken(token, key, secret, nbf.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, tokenStatusValid, res.Status)
+
+	res, err = checkToken(token, key, secret, nbf.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, tokenStatusExpired, res.Status)
+
+	res, err = checkToken(token, key, "another-secret-that-is-long-enough", time.Now())
+	require.NoError(t, err)
+	require.Equal(t, tokenStatusBadSig, res.Status)
+
+	_, err = checkToken(token, "otherKey", secret, time.Now())
+	require.Error(t, err)
+}
+
+func TestInspectToken(t *testing.T) {
+	nbf := time.Now().Add(time.Hour)
+	claims := &auth.ClaimGrants{Identity: "me", Video: &auth.VideoGrant{RoomJoin: true, Room: "r"}}
+	token, err := signTokenNotBefore("APIkey", "a-secret-that-is-long-enough-to-sign", claims, nbf, 10*time.Minute)
+	require.NoError(t, err)
+
+	res, err := inspectToken(token, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, tokenStatusNotYetValid, res.Status)
+	require.Equal(t, "APIkey", res.APIKey)
+	require.Equal(t, "me", res.Claims.Identity)
+	require.Equal(t, "r", res.Claims.Video.Room)
+	require.Equal(t, []string{"roomJoin"}, grantNames(res.Claims.Video))
+
+	res, err = inspectToken(token, nbf.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, tokenStatusUnverified, res.Status)
+
+	res, err = inspectToken(token, nbf.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, tokenStatusExpired, res.Status)
+
+	_, err = inspectToken("not-a-token", time.Now())
+	require.Error(t, err)
+
+	// tokens without nbf or exp leave both out of the JSON
+	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("a-secret-that-is-long-enough-to-sign")}, nil)
+	require.NoError(t, err)
+	token, err = jwt.Signed(sig).Claims(jwt.Claims{Issuer: "APIkey", Subject: "me"}).CompactSerialize()
+	require.NoError(t, err)
+	res, err = inspectToken(token, time.Now())
+	require.NoError(t, err)
+	b, err := json.Marshal(res)
+	require.NoError(t, err)
+	require.NotContains(t, string(b), "not_before")
+	require.NotContains(t, string(b), "expires")
+}
+
+func TestCheckTokenSize(t *testing.T) {
+	token := strings.Repeat("x", 100)
+	require.NoError(t, checkTokenSize(token, 100, true))
+	require.NoError(t, checkTokenSize(token, 0, true))
+	require.NoError(t, checkTokenSize(token, 50, false))
+	require.ErrorContains(t, checkTokenSize(token, 50, true), "100 bytes")
+}
+
+func TestReadGrantFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grants.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"identity": "bob", "name": "Bob", "video": {"roomJoin": true, "room": "r"}}`), 0600))
+	claims, err := readGrantFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "bob", claims.Identity)
+	require.Equal(t, "Bob", claims.Name)
+	require.Equal(t, "r", claims.Video.Room)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"identity": "bob", "roomName": "r"}`), 0600))
+	_, err = readGrantFile(path)
+	require.Error(t, err)
+}
@@ -15,16 +15,29 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"regexp"
 	"strings"
+	"sync"
+	gotemplate "text/template"
+	"time"
 
+	"github.com/charmbracelet/huh/spinner"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 	"github.com/twitchtv/twirp"
 	"github.com/urfave/cli/v3"
+	"golang.org/x/term"
 
 	"github.com/livekit/livekit-cli/pkg/config"
 	"github.com/livekit/livekit-cli/pkg/util"
+	"github.com/livekit/protocol/utils"
 	"github.com/livekit/protocol/utils/interceptors"
 )
 
@@ -44,7 +57,49 @@ var (
 		Aliases: []string{"j"},
 		Usage:   "Output as JSON",
 	}
+	// listJSONFlag is --json on commands taking --output
+	listJSONFlag = &cli.BoolFlag{
+		Name:    "json",
+		Aliases: []string{"j"},
+		Usage:   "Output as JSON (deprecated: use --output json)",
+	}
+	rawFlag = &cli.BoolFlag{
+		Name:  "raw",
+		Usage: "Print the server response as protobuf JSON, exactly as returned",
+	}
+	outputFlag = &cli.StringFlag{
+		Name:    "output",
+		Aliases: []string{"format"},
+		Usage:   "Output `FORMAT`, one of \"table\", \"json\", \"yaml\", \"csv\", or \"template\" (see --template). --json is a deprecated shorthand for --format json",
+	}
+	templateFlag = &cli.StringFlag{
+		Name:  "template",
+		Usage: "Go `TEMPLATE` applied to each result with --output template, e.g. '{{.Id}} {{.Room}}'",
+	}
+	jsonPathFlag = &cli.StringFlag{
+		Name:  "json-path",
+		Usage: "Print only the values at `PATH` in each result, one line per result, e.g. '$.fileResults[0].location'",
+	}
+	countOnlyFlag = &cli.BoolFlag{
+		Name:  "count-only",
+		Usage: "Print only the number of results, as {\"count\": N} with --json",
+	}
+	metadataFileFlag = &cli.StringFlag{
+		Name:      "metadata-file",
+		Usage:     "Read metadata from `FILE`, or from stdin when \"-\"",
+		TakesFile: true,
+	}
+	jsonMetadataFlag = &cli.BoolFlag{
+		Name:  "json-metadata",
+		Usage: "Validate that metadata is well-formed JSON",
+	}
+	roomPrefixFlag = &cli.StringFlag{
+		Name:  "room-prefix",
+		Usage: "`PREFIX` of the room name generated with --new-room",
+		Value: "room-",
+	}
 	printCurl   bool
+	quietOutput bool
 	globalFlags = []cli.Flag{
 		&cli.StringFlag{
 			Name:    "url",
@@ -62,9 +117,14 @@ var (
 			Usage:   "Your `SECRET`",
 			Sources: cli.EnvVars("LIVEKIT_API_SECRET"),
 		},
+		&cli.StringFlag{
+			Name:      "config",
+			Usage:     "Read and write projects in config `FILE` instead of ~/.livekit/cli-config.yaml",
+			TakesFile: true,
+		},
 		&cli.StringFlag{
 			Name:  "project",
-			Usage: "`NAME` of a configured project",
+			Usage: "`NAME` of a configured project to use instead of the default one, without prompting",
 		},
 		&cli.BoolFlag{
 			Name:        "curl",
@@ -72,13 +132,67 @@ var (
 			Destination: &printCurl,
 			Required:    false,
 		},
+		verboseFlag,
+		logLevelFlag,
+		explainFlag,
+		&cli.IntFlag{
+			Name:  "max-col-width",
+			Usage: "Truncate table cells longer than `WIDTH` characters, or 0 for no limit. JSON output is never truncated",
+			Value: int64(util.MaxColumnWidth),
+			Action: func(ctx context.Context, cmd *cli.Command, v int64) error {
+				if !cmd.Bool("no-truncate") {
+					util.MaxColumnWidth = int(v)
+				}
+				return nil
+			},
+		},
 		&cli.BoolFlag{
-			Name:     "verbose",
-			Required: false,
+			Name:  "no-truncate",
+			Usage: "Show full table cell values",
+			Action: func(ctx context.Context, cmd *cli.Command, v bool) error {
+				if v {
+					util.MaxColumnWidth = 0
+				}
+				return nil
+			},
+		},
+		&cli.BoolFlag{
+			Name:  "no-color",
+			Usage: "Disable colors and text styling, the default when stdout is not a terminal or NO_COLOR is set",
+			Action: func(ctx context.Context, cmd *cli.Command, v bool) error {
+				if v {
+					disableColor()
+				}
+				return nil
+			},
+		},
+		&cli.BoolFlag{
+			Name:        "quiet",
+			Aliases:     []string{"q"},
+			Usage:       "Print only results and errors, without progress messages or spinners",
+			Destination: &quietOutput,
 		},
 	}
 )
 
+// stringList is the value of a flag that can be used multiple times. Unlike
+// cli.StringSliceFlag, it doesn't split values on commas, which may be part
+// of environment variables and file names.
+type stringList []string
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Get() any {
+	return []string(*l)
+}
+
 func optional[T any, C any, VC cli.ValueCreator[T, C]](flag *cli.FlagBase[T, C, VC]) *cli.FlagBase[T, C, VC] {
 	newFlag := *flag
 	newFlag.Required = false
@@ -91,6 +205,178 @@ func hidden[T any, C any, VC cli.ValueCreator[T, C]](flag *cli.FlagBase[T, C, VC
 	return &newFlag
 }
 
+// isInteractive reports whether stdin is attached to a terminal, which is
+// required for any of the huh prompts to work.
+func isInteractive() bool {
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// stdoutIsTerminal reports whether stdout is attached to a terminal, rather
+// than redirected to a file or a CI log.
+func stdoutIsTerminal() bool {
+	stat, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// initColor disables styling when output isn't read by a person in a
+// terminal, following https://no-color.org.
+func initColor() {
+	if os.Getenv("NO_COLOR") != "" || !stdoutIsTerminal() {
+		disableColor()
+	}
+}
+
+func disableColor() {
+	lipgloss.SetColorProfile(termenv.Ascii)
+}
+
+// runSpinner runs action while s is shown with title. Spinners are skipped
+// with --quiet, and only the title is printed, to stderr, when stdout is not
+// a terminal, so that redirected output only holds results.
+func runSpinner(s *spinner.Spinner, title string, action func()) error {
+	if quietOutput {
+		action()
+		return nil
+	}
+	if !stdoutIsTerminal() {
+		fmt.Fprintln(os.Stderr, title)
+		action()
+		return nil
+	}
+	return s.Title(title).Action(action).Run()
+}
+
+// number of lines of task output kept to show when a task fails
+const taskProgressTail = 20
+
+var taskProgressFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// taskProgress shows the output of a task while it runs. With debug messages
+// enabled, or when stdout is not a terminal, the output is streamed as is, to stderr in
+// the latter case so redirected output only holds results. Otherwise a
+// spinner is shown with the title and the latest line of output, and the
+// last lines are printed if the task fails.
+type taskProgress struct {
+	title string
+	// where output is streamed, nil when it is summarized
+	out io.Writer
+
+	mu      sync.Mutex
+	partial []byte
+	tail    []string
+}
+
+func newTaskProgress(title string, verbose bool) *taskProgress {
+	p := &taskProgress{title: title}
+	switch {
+	case quietOutput:
+	case verbose:
+		p.out = os.Stdout
+	case !stdoutIsTerminal():
+		p.out = os.Stderr
+	}
+	return p
+}
+
+func (p *taskProgress) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.out != nil {
+		return p.out.Write(b)
+	}
+	p.partial = append(p.partial, b...)
+	for {
+		i := bytes.IndexByte(p.partial, '\n')
+		if i < 0 {
+			break
+		}
+		// progress bars redraw their line with carriage returns
+		line := string(p.partial[:i])
+		line = strings.TrimSpace(line[strings.LastIndexByte(line, '\r')+1:])
+		p.partial = p.partial[i+1:]
+		if line == "" {
+			continue
+		}
+		p.tail = append(p.tail, line)
+		if len(p.tail) > taskProgressTail {
+			p.tail = p.tail[1:]
+		}
+	}
+	return len(b), nil
+}
+
+// Run runs task, created to write its output to p. When ctx is canceled, as
+// with Ctrl-C, it still waits for the task to stop its commands, so that
+// they are not left running after the CLI exits.
+func (p *taskProgress) Run(ctx context.Context, task func() error) error {
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	if p.out != nil {
+		fmt.Fprintln(p.out, p.title)
+	} else if !quietOutput {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.animate(done)
+		}()
+	}
+
+	err := task()
+	close(done)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return errors.New(strings.TrimSuffix(p.title, "...") + " canceled")
+	}
+	if err != nil && p.out == nil {
+		p.mu.Lock()
+		for _, line := range p.tail {
+			fmt.Fprintln(os.Stderr, line)
+		}
+		p.mu.Unlock()
+	}
+	return err
+}
+
+func (p *taskProgress) animate(done <-chan struct{}) {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	width := 80
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		width = w
+	}
+	title := util.Theme.Focused.Title.Render(p.title)
+	for i := 0; ; i++ {
+		select {
+		case <-done:
+			fmt.Print("\r\033[K")
+			return
+		case <-ticker.C:
+		}
+		var last string
+		p.mu.Lock()
+		if len(p.tail) > 0 {
+			last = p.tail[len(p.tail)-1]
+		}
+		p.mu.Unlock()
+		// room for the frame, title and spaces between them
+		if room := width - len(p.title) - 4; room > 3 {
+			last = util.EllipsizeTo(last, room)
+		} else {
+			last = ""
+		}
+		frame := taskProgressFrames[i%len(taskProgressFrames)]
+		fmt.Print("\r\033[K" + frame + " " + title + " " + util.Theme.Help.ShortDesc.Render(last))
+	}
+}
+
 func withDefaultClientOpts(c *config.ProjectConfig) []twirp.ClientOption {
 	var (
 		opts []twirp.ClientOption
@@ -99,12 +385,170 @@ func withDefaultClientOpts(c *config.ProjectConfig) []twirp.ClientOption {
 	if printCurl {
 		ics = append(ics, interceptors.NewCurlPrinter(os.Stdout, c.URL))
 	}
+	if explain {
+		ics = append(ics, explainInterceptor(c.URL))
+	}
 	if len(ics) != 0 {
 		opts = append(opts, twirp.WithClientInterceptors(ics...))
 	}
 	return opts
 }
 
+// printCount prints the number of results for --count-only.
+func printCount(c *cli.Command, n int) {
+	if c.Bool("json") {
+		util.PrintJSON(map[string]int{"count": n})
+	} else {
+		fmt.Println(n)
+	}
+}
+
+// listOutput resolves the output mode of a list command, returning the parsed
+// --template in template mode.
+func listOutput(c *cli.Command) (string, *gotemplate.Template, error) {
+	output := c.String("output")
+	if output == "" {
+		switch {
+		case c.String("template") != "":
+			output = "template"
+		case c.Bool("json"):
+			output = "json"
+		default:
+			output = "table"
+		}
+	}
+
+	switch output {
+	case "table", "json", "yaml", "csv":
+		return output, nil, nil
+	case "template":
+		text := c.String("template")
+		if text == "" {
+			return "", nil, validationErrorf("--output template requires --template")
+		}
+		tmpl, err := gotemplate.New("output").Parse(text)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid template: %w", err)
+		}
+		return output, tmpl, nil
+	default:
+		return "", nil, fmt.Errorf("invalid output format: %s", output)
+	}
+}
+
+// printList prints the items of a list response in the format resolved by
+// listOutput. JSON and YAML print res as a whole, while the other formats
+// print a row per item, skipping items for which row returns nothing.
+func printList[T any](output string, tmpl *gotemplate.Template, res any, items []*T, header []string, row func(item *T) []string) error {
+	switch output {
+	case "json":
+		util.PrintJSON(res)
+		return nil
+	case "yaml":
+		return util.PrintYAML(res)
+	case "template":
+		return printTemplate(tmpl, items)
+	}
+
+	rows := make([][]string, 0, len(items))
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		if r := row(item); len(r) > 0 {
+			rows = append(rows, r)
+		}
+	}
+	if output == "csv" {
+		return util.PrintCSV(header, rows)
+	}
+	fmt.Println(util.CreateTable().Headers(header...).Rows(rows...))
+	return nil
+}
+
+// printTemplate executes tmpl for each item, one per line.
+func printTemplate[T any](tmpl *gotemplate.Template, items []*T) error {
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		if err := tmpl.Execute(os.Stdout, item); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// jsonPathOption parses --json-path, returning nil when it is not set.
+func jsonPathOption(c *cli.Command) (util.JSONPath, error) {
+	if !c.IsSet("json-path") {
+		return nil, nil
+	}
+	return util.ParseJSONPath(c.String("json-path"))
+}
+
+// printJSONPath prints the values matching path in each item, one line per
+// item, with multiple matches separated by spaces.
+func printJSONPath[T any](path util.JSONPath, items []*T) error {
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		values, err := path.Eval(item)
+		if err != nil {
+			return err
+		}
+		out := make([]string, 0, len(values))
+		for _, v := range values {
+			out = append(out, util.FormatJSONValue(v))
+		}
+		fmt.Println(strings.Join(out, " "))
+	}
+	return nil
+}
+
+// printCompletions prints shell completion candidates, with a description
+// for shells that display them.
+func printCompletions(cmd *cli.Command, candidates [][2]string) {
+	zsh := strings.HasSuffix(os.Getenv("SHELL"), "zsh")
+	for _, c := range candidates {
+		if zsh {
+			fmt.Fprintf(cmd.Root().Writer, "%s:%s\n", strings.ReplaceAll(c[0], ":", "\\:"), c[1])
+		} else {
+			fmt.Fprintln(cmd.Root().Writer, c[0])
+		}
+	}
+}
+
+// completingFlagValue reports whether the word being completed is the value
+// of the flag named name.
+func completingFlagValue(name string) bool {
+	// shells append the completion flag after the words typed so far
+	if len(os.Args) < 2 {
+		return false
+	}
+	last := os.Args[len(os.Args)-2]
+	return last == "--"+name || last == "-"+name
+}
+
+// completingFlag reports whether a flag name is being completed.
+func completingFlag() bool {
+	return len(os.Args) >= 2 && strings.HasPrefix(os.Args[len(os.Args)-2], "-")
+}
+
+// progressWriter is where informational output should go, keeping stdout
+// clean for commands printing JSON.
+func progressWriter(c *cli.Command) io.Writer {
+	if quietOutput {
+		return io.Discard
+	}
+	if c.Bool("json") {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
 func extractArg(c *cli.Command) (string, error) {
 	if !c.Args().Present() {
 		return "", errors.New("no argument provided")
@@ -131,8 +575,90 @@ func extractFlagOrArg(c *cli.Command, flag string) (string, error) {
 	return value, nil
 }
 
+// extractMetadata reads metadata from either --metadata or --metadata-file,
+// validating it as JSON when --json-metadata is set.
+func extractMetadata(c *cli.Command) (string, error) {
+	metadata := c.String("metadata")
+	if file := c.String("metadata-file"); file != "" {
+		if c.IsSet("metadata") {
+			return "", errors.New("only one of --metadata or --metadata-file can be specified")
+		}
+		var (
+			b   []byte
+			err error
+		)
+		if file == "-" {
+			b, err = io.ReadAll(os.Stdin)
+		} else {
+			b, err = os.ReadFile(file)
+		}
+		if err != nil {
+			return "", err
+		}
+		metadata = string(b)
+	}
+	if c.Bool("json-metadata") && metadata != "" && !json.Valid([]byte(metadata)) {
+		return "", errors.New("metadata is not valid JSON")
+	}
+	return metadata, nil
+}
+
+// extractUpdatedMetadata reads the metadata to set with an update command,
+// requiring one of --metadata or --metadata-file, and validating it when it
+// looks like JSON.
+func extractUpdatedMetadata(c *cli.Command) (string, error) {
+	if !c.IsSet("metadata") && !c.IsSet("metadata-file") {
+		return "", validationErrorf("--metadata or --metadata-file is required")
+	}
+	metadata, err := extractMetadata(c)
+	if err != nil {
+		return "", validationErrorf("%w", err)
+	}
+	if err = validateMetadata(metadata); err != nil {
+		return "", err
+	}
+	return metadata, nil
+}
+
+// validateMetadata checks that metadata that looks like JSON is valid.
+func validateMetadata(metadata string) error {
+	if trimmed := strings.TrimSpace(metadata); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal([]byte(trimmed), new(any)); err != nil {
+			return validationErrorf("metadata looks like JSON but is invalid: %w", err)
+		}
+	}
+	return nil
+}
+
+// room name prefixes are kept to characters that are safe in URLs and file
+// names, such as egress outputs
+var roomPrefixRegex = regexp.MustCompile(`^[a-zA-Z0-9_\-]*$`)
+
+// newRoomName generates a unique room name for --new-room, starting with
+// --room-prefix.
+func newRoomName(c *cli.Command) (string, error) {
+	prefix := c.String("room-prefix")
+	if !roomPrefixRegex.MatchString(prefix) {
+		return "", validationErrorf("--room-prefix can only contain alphanumeric characters, dashes and underscores")
+	}
+	return utils.NewGuid(prefix), nil
+}
+
+// useConfigFile points the config package at --config, if given. It is
+// called before loading config rather than from a Before hook, as the flag
+// may follow the subcommand, which runs its own hooks first.
+func useConfigFile(c *cli.Command) error {
+	if p := c.String("config"); p != "" {
+		if err := config.SetPath(p); err != nil {
+			return validationErrorf("%w", err)
+		}
+	}
+	return nil
+}
+
 type loadParams struct {
 	requireURL bool
+	quiet      bool
 }
 
 type loadOption func(*loadParams)
@@ -141,17 +667,28 @@ var ignoreURL = func(p *loadParams) {
 	p.requireURL = false
 }
 
+// quietLoad skips the messages about which project is used, for output that
+// is parsed by other programs such as shell completions.
+var quietLoad = func(p *loadParams) {
+	p.quiet = true
+}
+
 // attempt to load connection config, it'll prioritize
-// 1. command line flags (or env var)
-// 2. default project config
+// 1. --project
+// 2. command line flags (or LIVEKIT_URL, LIVEKIT_API_KEY and LIVEKIT_API_SECRET)
+// 3. default project config
 func loadProjectDetails(c *cli.Command, opts ...loadOption) (*config.ProjectConfig, error) {
+	if err := useConfigFile(c); err != nil {
+		return nil, err
+	}
 	p := loadParams{requireURL: true}
 	for _, opt := range opts {
 		opt(&p)
 	}
+	verbose := debugEnabled() && !p.quiet
 	logDetails := func(c *cli.Command, pc *config.ProjectConfig) {
-		if c.Bool("verbose") {
-			fmt.Printf("URL: %s, api-key: %s, api-secret: %s\n",
+		if verbose {
+			fmt.Fprintf(progressWriter(c), "URL: %s, api-key: %s, api-secret: %s\n",
 				pc.URL,
 				pc.APIKey,
 				"************",
@@ -166,7 +703,9 @@ func loadProjectDetails(c *cli.Command, opts ...loadOption) (*config.ProjectConf
 		if err != nil {
 			return nil, err
 		}
-		fmt.Println("Using project [" + util.Theme.Focused.Title.Render(c.String("project")) + "]")
+		if !p.quiet {
+			fmt.Fprintln(progressWriter(c), "Using project ["+util.Theme.Focused.Title.Render(c.String("project"))+"]")
+		}
 		logDetails(c, pc)
 		return pc, nil
 	}
@@ -181,6 +720,14 @@ func loadProjectDetails(c *cli.Command, opts ...loadOption) (*config.ProjectConf
 	if val := c.String("api-secret"); val != "" {
 		pc.APISecret = val
 	}
+	// a key without its secret, or the reverse, is a mistake rather than a
+	// reason to quietly use the credentials of the default project instead
+	if pc.APIKey == "" && pc.APISecret != "" {
+		return nil, authErrorf("api-key is required when api-secret is set, with --api-key or LIVEKIT_API_KEY")
+	}
+	if pc.APIKey != "" && pc.APISecret == "" {
+		return nil, authErrorf("api-secret is required when api-key is set, with --api-secret or LIVEKIT_API_SECRET")
+	}
 	if pc.APIKey != "" && pc.APISecret != "" && (pc.URL != "" || !p.requireURL) {
 		var envVars []string
 		// if it's set via env, we should let users know
@@ -193,8 +740,8 @@ func loadProjectDetails(c *cli.Command, opts ...loadOption) (*config.ProjectConf
 		if os.Getenv("LIVEKIT_API_SECRET") == pc.APISecret {
 			envVars = append(envVars, "api-secret")
 		}
-		if c.Bool("verbose") && len(envVars) > 0 {
-			fmt.Printf("Using %s from environment\n", strings.Join(envVars, ", "))
+		if verbose && len(envVars) > 0 {
+			fmt.Fprintf(progressWriter(c), "Using %s from environment\n", strings.Join(envVars, ", "))
 			logDetails(c, pc)
 		}
 		return pc, nil
@@ -203,21 +750,21 @@ func loadProjectDetails(c *cli.Command, opts ...loadOption) (*config.ProjectConf
 	// load default project
 	dp, err := config.LoadDefaultProject()
 	if err == nil {
-		if c.Bool("verbose") {
-			fmt.Println("Using default project [" + util.Theme.Focused.Title.Render(dp.Name) + "]")
+		if verbose {
+			fmt.Fprintln(progressWriter(c), "Using default project ["+util.Theme.Focused.Title.Render(dp.Name)+"]")
 			logDetails(c, dp)
 		}
 		return dp, nil
 	}
 
 	if p.requireURL && pc.URL == "" {
-		return nil, errors.New("url is required")
+		return nil, validationErrorf("url is required")
 	}
 	if pc.APIKey == "" {
-		return nil, errors.New("api-key is required")
+		return nil, authErrorf("api-key is required")
 	}
 	if pc.APISecret == "" {
-		return nil, errors.New("api-secret is required")
+		return nil, authErrorf("api-secret is required")
 	}
 
 	// cannot happen
@@ -15,8 +15,13 @@
 package main
 
 import (
+	"context"
+	"io"
+	"os"
+	"slices"
 	"testing"
 
+	"github.com/charmbracelet/huh/spinner"
 	"github.com/urfave/cli/v3"
 )
 
@@ -55,3 +60,117 @@ func TestHiddenFlag(t *testing.T) {
 		t.Error("hidden should return a new flag with Hidden set to true")
 	}
 }
+
+func TestStringListFlag(t *testing.T) {
+	var env []string
+	cmd := &cli.Command{
+		Name:  "run",
+		Flags: []cli.Flag{&cli.GenericFlag{Name: "env", Value: &stringList{}}},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			env = cmd.StringSlice("env")
+			return nil
+		},
+	}
+	if err := cmd.Run(context.Background(), []string{"run", "--env", "HOSTS=a,b", "--env", "PORT=80"}); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(env, []string{"HOSTS=a,b", "PORT=80"}) {
+		t.Errorf("unexpected values %q", env)
+	}
+}
+
+func TestListOutput(t *testing.T) {
+	tests := []struct {
+		args    []string
+		output  string
+		wantErr bool
+	}{
+		{args: nil, output: "table"},
+		{args: []string{"--json"}, output: "json"},
+		{args: []string{"--output", "json"}, output: "json"},
+		{args: []string{"--format", "yaml"}, output: "yaml"},
+		{args: []string{"--format", "csv", "--json"}, output: "csv"},
+		{args: []string{"--template", "{{.Id}}"}, output: "template"},
+		{args: []string{"--output", "template", "--template", "{{.Id}} {{.Room}}"}, output: "template"},
+		{args: []string{"--output", "template"}, wantErr: true},
+		{args: []string{"--output", "template", "--template", "{{.Id"}, wantErr: true},
+		{args: []string{"--output", "xml"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		var output string
+		var err error
+		// flags keep their values once applied, so each run gets fresh ones
+		json, out, tmpl := *jsonFlag, *outputFlag, *templateFlag
+		cmd := &cli.Command{
+			Name:  "list",
+			Flags: []cli.Flag{&json, &out, &tmpl},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				output, _, err = listOutput(cmd)
+				return nil
+			},
+		}
+		if runErr := cmd.Run(context.Background(), append([]string{"list"}, tt.args...)); runErr != nil {
+			t.Fatal(runErr)
+		}
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%v: expected error", tt.args)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", tt.args, err)
+		} else if output != tt.output {
+			t.Errorf("%v: expected output %q, got %q", tt.args, tt.output, output)
+		}
+	}
+}
+
+func TestRunSpinnerOffTerminal(t *testing.T) {
+	stdout, stderr := os.Stdout, os.Stderr
+	defer func() { os.Stdout, os.Stderr = stdout, stderr }()
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout, os.Stderr = outW, errW
+
+	ran := false
+	err = runSpinner(spinner.New(), "Working", func() { ran = true })
+	outW.Close()
+	errW.Close()
+	out, _ := io.ReadAll(outR)
+	errOut, _ := io.ReadAll(errR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ran {
+		t.Error("expected the action to run")
+	}
+	if len(out) != 0 {
+		t.Errorf("expected nothing on stdout, got %q", out)
+	}
+	if string(errOut) != "Working\n" {
+		t.Errorf("expected the title on stderr, got %q", errOut)
+	}
+}
+
+func TestTaskProgressTail(t *testing.T) {
+	p := &taskProgress{title: "Installing..."}
+	_, _ = p.Write([]byte("resolving\nfetch 10%\rfetch 100%\n\npart"))
+	_, _ = p.Write([]byte("ial line\n"))
+	if !slices.Equal(p.tail, []string{"resolving", "fetch 100%", "partial line"}) {
+		t.Errorf("unexpected tail %q", p.tail)
+	}
+
+	for i := 0; i < taskProgressTail+5; i++ {
+		_, _ = p.Write([]byte("line\n"))
+	}
+	if len(p.tail) != taskProgressTail {
+		t.Errorf("tail should keep %d lines, got %d", taskProgressTail, len(p.tail))
+	}
+}
@@ -24,17 +24,23 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"maps"
 	"net/http"
 	"os"
 	"os/exec"
 	"path"
+	"regexp"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-task/task/v3"
 	"github.com/go-task/task/v3/taskfile/ast"
 	"github.com/joho/godotenv"
 	"gopkg.in/yaml.v3"
+	"mvdan.cc/sh/v3/syntax"
 
 	authutil "github.com/livekit/livekit-cli/pkg/auth"
 )
@@ -46,8 +52,15 @@ const (
 	TemplateBaseURL         = "https://github.com/livekit-examples"
 	SandboxDashboardURL     = "https://cloud.livekit.io/projects/p_/sandbox"
 	SandboxTemplateEndpoint = "/api/sandbox/template"
+	SandboxesEndpoint       = "/api/sandboxes"
 )
 
+// EnvPackageManager is set in the environment of tasks to the package manager
+// chosen with --package-manager, for templates that would otherwise guess.
+const EnvPackageManager = "PACKAGE_MANAGER"
+
+var PackageManagers = []string{"npm", "yarn", "pnpm", "bun"}
+
 type KnownTask string
 
 const (
@@ -56,12 +69,29 @@ const (
 	TaskDev        KnownTask = "dev"
 )
 
+// Extra task arguments that set variables, e.g. PORT=3000
+var taskVarRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// URLs git can clone templates from
+var templateURLRegex = regexp.MustCompile(`^((https?|ssh|git|file)://[^\s]+|[\w.-]+@[\w.-]+:[^\s]+)$`)
+
+// Full or abbreviated commit SHAs
+var commitSHARegex = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// GitHub repository URLs, capturing the owner and repository
+var githubRepoRegex = regexp.MustCompile(`^(?:https://github\.com/|git@github\.com:)([^/]+)/([^/]+?)(?:\.git)?/?$`)
+
+// Assignments in dotenv files, capturing the key
+var dotEnvKeyRegex = regexp.MustCompile(`^\s*(?:export\s+)?([A-Za-z_][A-Za-z0-9_.]*)\s*=`)
+
 // Files to remove after cloning a template
 var templateIgnoreFiles = []string{
 	".git",
 	"renovate.json",
 	"taskfile.yaml",
 	"TEMPLATE.md",
+	TemplateManifestFile,
+	CreateStateFile,
 }
 
 type Template struct {
@@ -77,24 +107,123 @@ type Template struct {
 }
 
 type SandboxDetails struct {
+	ID             string     `json:"id"`
 	Name           string     `json:"name"`
 	Template       Template   `json:"template"`
 	ChildTemplates []Template `json:"childTemplates"`
 }
 
 func FetchTemplates(ctx context.Context) ([]Template, error) {
-	resp, err := http.Get(TemplateIndexURL + "/" + TemplateIndexFile)
+	content, err := fetchTemplateIndex(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 	var templates []Template
-	if err := yaml.NewDecoder(resp.Body).Decode(&templates); err != nil {
+	if err := yaml.Unmarshal(content, &templates); err != nil {
 		return nil, err
 	}
 	return templates, nil
 }
 
+// templateCacheFile is the name of the cached template index.
+const templateCacheFile = "templates.yaml"
+
+// FetchTemplatesCached returns the template index cached in cacheDir when it
+// was fetched less than ttl ago, and otherwise fetches it and updates the
+// cache. refresh skips reading the cache, and a ttl of 0 disables it.
+func FetchTemplatesCached(ctx context.Context, cacheDir string, ttl time.Duration, refresh bool) ([]Template, error) {
+	cachePath := path.Join(cacheDir, templateCacheFile)
+	if ttl > 0 && !refresh {
+		if info, err := os.Stat(cachePath); err == nil && time.Since(info.ModTime()) < ttl {
+			var templates []Template
+			if content, err := os.ReadFile(cachePath); err == nil && yaml.Unmarshal(content, &templates) == nil {
+				return templates, nil
+			}
+		}
+	}
+
+	content, err := fetchTemplateIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var templates []Template
+	if err := yaml.Unmarshal(content, &templates); err != nil {
+		return nil, err
+	}
+	if ttl > 0 {
+		// the cache only saves time, failing to write it isn't an error
+		_ = writeFileAtomic(cachePath, content)
+	}
+	return templates, nil
+}
+
+func fetchTemplateIndex(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, TemplateIndexURL+"/"+TemplateIndexFile, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch templates: %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// writeFileAtomic replaces the file at name with content through a rename,
+// so that concurrent readers and writers never see a partial file.
+func writeFileAtomic(name string, content []byte) error {
+	if err := os.MkdirAll(path.Dir(name), 0700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(path.Dir(name), "."+path.Base(name)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+// FetchTemplateFile fetches a single file of a template hosted on GitHub at
+// ref, or its default branch when ref is empty, without cloning it. Missing
+// files return an error wrapping fs.ErrNotExist.
+func FetchTemplateFile(ctx context.Context, url, ref, name string) ([]byte, error) {
+	m := githubRepoRegex.FindStringSubmatch(url)
+	if m == nil {
+		return nil, fmt.Errorf("cannot fetch files of templates not hosted on GitHub: %s", url)
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+	rawURL := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s", m[1], m[2], ref, name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch %s: %s", name, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
 func FetchSandboxDetails(ctx context.Context, sid, token, serverURL string) (*SandboxDetails, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", serverURL+SandboxTemplateEndpoint, nil)
 	req.Header = authutil.NewHeaderWithToken(token)
@@ -123,6 +252,33 @@ func FetchSandboxDetails(ctx context.Context, sid, token, serverURL string) (*Sa
 	return &details, nil
 }
 
+// FetchSandboxes lists the sandboxes of the project the token was issued
+// for, along with their child templates.
+func FetchSandboxes(ctx context.Context, token, serverURL string) ([]SandboxDetails, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", serverURL+SandboxesEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = authutil.NewHeaderWithToken(token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, errors.New(resp.Status)
+	}
+
+	var res struct {
+		Sandboxes []SandboxDetails `json:"sandboxes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+	return res.Sandboxes, nil
+}
+
 func ParseTaskfile(rootPath string) (*ast.Taskfile, error) {
 	taskfilePath := path.Join(rootPath, TaskFile)
 
@@ -167,13 +323,29 @@ func NewTaskExecutor(dir string, verbose bool) *task.Executor {
 	}
 }
 
-func NewTask(ctx context.Context, tf *ast.Taskfile, dir, taskName string, verbose bool) (func() error, error) {
+func NewTask(ctx context.Context, tf *ast.Taskfile, dir, taskName string, verbose bool, args ...string) (func() error, error) {
+	return NewTaskWithOutput(ctx, tf, dir, taskName, verbose, os.Stdout, os.Stderr, args...)
+}
+
+// NewTaskWithOutput is NewTask writing the output of the task's commands to
+// stdout and stderr. Canceling ctx interrupts the commands, killing them if
+// they don't exit in time, and the returned function waits for them to stop.
+func NewTaskWithOutput(ctx context.Context, tf *ast.Taskfile, dir, taskName string, verbose bool, stdout, stderr io.Writer, args ...string) (func() error, error) {
 	exe := NewTaskExecutor(dir, verbose)
+	exe.Stdout = stdout
+	exe.Stderr = stderr
 	err := exe.Setup()
 	if err != nil {
 		return nil, err
 	}
 
+	vars, cliArgs, err := TaskArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	vars.Set("CLI_ARGS", ast.Var{Value: cliArgs})
+	exe.Taskfile.Vars.Merge(vars, nil)
+
 	task := &ast.Call{
 		Task:   taskName,
 		Silent: !verbose,
@@ -187,6 +359,92 @@ func NewTask(ctx context.Context, tf *ast.Taskfile, dir, taskName string, verbos
 	}, nil
 }
 
+// TaskArgs splits extra arguments for a task the way the task CLI does for
+// `task NAME KEY=VALUE -- ARGS`: leading KEY=VALUE arguments set taskfile
+// variables, and the rest are shell quoted and joined into CLI_ARGS.
+func TaskArgs(args []string) (*ast.Vars, string, error) {
+	vars := ast.NewVars()
+	i := 0
+	for ; i < len(args); i++ {
+		k, v, ok := strings.Cut(args[i], "=")
+		if !ok || !taskVarRegex.MatchString(k) {
+			break
+		}
+		vars.Set(k, ast.Var{Value: v})
+	}
+	if i < len(args) && args[i] == "--" {
+		i++
+	}
+
+	var quoted []string
+	for _, arg := range args[i:] {
+		q, err := syntax.Quote(arg, syntax.LangBash)
+		if err != nil {
+			return nil, "", err
+		}
+		quoted = append(quoted, q)
+	}
+	return vars, strings.Join(quoted, " "), nil
+}
+
+// Shell builtins and utilities that tasks can run without anything installed
+var taskBuiltins = []string{
+	".", ":", "[", "cd", "echo", "eval", "exec", "exit", "export", "false",
+	"printf", "pwd", "read", "set", "shift", "source", "test", "true", "unset",
+}
+
+// TaskPrograms returns the programs the commands of a taskfile run, such as
+// runtimes and package managers, skipping shell builtins, commands for other
+// platforms, scripts in the app and names set from taskfile variables.
+func TaskPrograms(tf *ast.Taskfile) []string {
+	var programs []string
+	parser := syntax.NewParser()
+	for _, name := range tf.Tasks.Keys() {
+		t, ok := tf.Tasks.Get(name)
+		if !ok || t == nil {
+			continue
+		}
+		for _, c := range t.Cmds {
+			if c == nil || c.Cmd == "" || !matchesPlatform(c.Platforms) {
+				continue
+			}
+			file, err := parser.Parse(strings.NewReader(c.Cmd), "")
+			if err != nil {
+				continue
+			}
+			syntax.Walk(file, func(node syntax.Node) bool {
+				call, ok := node.(*syntax.CallExpr)
+				if !ok || len(call.Args) == 0 {
+					return true
+				}
+				// scripts in the app are found relative to it, not on the PATH
+				name := call.Args[0].Lit()
+				if name == "" || strings.ContainsAny(name, "/{") || slices.Contains(taskBuiltins, name) {
+					return true
+				}
+				if !slices.Contains(programs, name) {
+					programs = append(programs, name)
+				}
+				return true
+			})
+		}
+	}
+	slices.Sort(programs)
+	return programs
+}
+
+func matchesPlatform(platforms []*ast.Platform) bool {
+	if len(platforms) == 0 {
+		return true
+	}
+	for _, p := range platforms {
+		if (p.OS == "" || p.OS == runtime.GOOS) && (p.Arch == "" || p.Arch == runtime.GOARCH) {
+			return true
+		}
+	}
+	return false
+}
+
 type PromptFunc func(key string, value string) (string, error)
 
 // Read .env.example file if present in rootDir, replacing all `substitutions`,
@@ -231,6 +489,74 @@ func InstantiateDotEnv(ctx context.Context, rootDir string, exampleFilePath stri
 	}
 }
 
+// TemplateInput describes a value that instantiating a template will prompt for.
+type TemplateInput struct {
+	Name    string   `json:"name"`
+	Default string   `json:"default,omitempty"`
+	Options []string `json:"options,omitempty"`
+	Source  string   `json:"source"`
+}
+
+// List the inputs a template in rootDir will ask for, including variables
+// from its .env.example which are not covered by `substitutions` and any
+// variables required by the tasks run during creation.
+func ListTemplateInputs(rootDir string, tf *ast.Taskfile, exampleFilePath string, substitutions map[string]string) ([]TemplateInput, error) {
+	var inputs []TemplateInput
+
+	envExamplePath := path.Join(rootDir, exampleFilePath)
+	if _, err := os.Stat(envExamplePath); err == nil {
+		envMap, err := godotenv.Read(envExamplePath)
+		if err != nil {
+			return nil, err
+		}
+		keys := slices.Sorted(maps.Keys(envMap))
+		for _, key := range keys {
+			if _, ok := substitutions[key]; ok {
+				continue
+			}
+			inputs = append(inputs, TemplateInput{
+				Name:    key,
+				Default: envMap[key],
+				Source:  exampleFilePath,
+			})
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	manifest, err := ParseTemplateManifest(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	if manifest != nil {
+		for _, v := range manifest.Variables {
+			inputs = append(inputs, TemplateInput{
+				Name:    v.Name,
+				Default: v.Default,
+				Source:  TemplateManifestFile,
+			})
+		}
+	}
+
+	if tf != nil {
+		for _, taskName := range []KnownTask{TaskPostCreate, TaskInstall} {
+			t, ok := tf.Tasks.Get(string(taskName))
+			if !ok || t.Requires == nil {
+				continue
+			}
+			for _, v := range t.Requires.Vars {
+				inputs = append(inputs, TemplateInput{
+					Name:    v.Name,
+					Options: v.Enum,
+					Source:  TaskFile + ":" + string(taskName),
+				})
+			}
+		}
+	}
+
+	return inputs, nil
+}
+
 func PrintDotEnv(envMap map[string]string) error {
 	envContents, err := godotenv.Marshal(envMap)
 	if err != nil {
@@ -249,19 +575,300 @@ func WriteDotEnv(rootDir string, filePath string, envMap map[string]string) erro
 	return os.WriteFile(envLocalPath, []byte(envContents+"\n"), 0700)
 }
 
-func CloneTemplate(url, dir string) (string, string, error) {
+// ReadDotEnv reads the variables of a dotenv file, along with their keys in
+// the order they appear in the file.
+func ReadDotEnv(filePath string) ([]string, map[string]string, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	env, err := godotenv.UnmarshalBytes(content)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys := slices.DeleteFunc(DotEnvKeys(content), func(k string) bool {
+		_, ok := env[k]
+		return !ok
+	})
+	return keys, env, nil
+}
+
+// DotEnvKeys returns the keys assigned in dotenv content, in order.
+func DotEnvKeys(content []byte) []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, line := range strings.Split(string(content), "\n") {
+		m := dotEnvKeyRegex.FindStringSubmatch(line)
+		if m == nil || seen[m[1]] {
+			continue
+		}
+		seen[m[1]] = true
+		keys = append(keys, m[1])
+	}
+	return keys
+}
+
+// SetDotEnv sets variables in a dotenv file, creating it if needed. Existing
+// variables are replaced in place, keeping comments and the order of other
+// lines, and new ones are appended in the order of keys.
+func SetDotEnv(filePath string, keys []string, values map[string]string) error {
+	var lines []string
+	mode := os.FileMode(0600)
+	if stat, err := os.Stat(filePath); err == nil {
+		mode = stat.Mode().Perm()
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		lines = strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	assignment := func(key string) (string, error) {
+		return godotenv.Marshal(map[string]string{key: values[key]})
+	}
+	written := map[string]bool{}
+	for i, line := range lines {
+		m := dotEnvKeyRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if _, ok := values[m[1]]; !ok {
+			continue
+		}
+		a, err := assignment(m[1])
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "export ") {
+			a = "export " + a
+		}
+		lines[i] = a
+		written[m[1]] = true
+	}
+	for _, key := range keys {
+		if written[key] {
+			continue
+		}
+		a, err := assignment(key)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, a)
+		written[key] = true
+	}
+	return os.WriteFile(filePath, []byte(strings.Join(lines, "\n")+"\n"), mode)
+}
+
+// TaskEnv merges the environment to run tasks with, from lowest to highest
+// precedence: the app's own env file in rootDir, each of envFiles in order,
+// then overrides. Missing app env files are ignored.
+func TaskEnv(rootDir, appEnvFile string, envFiles []string, overrides map[string]string) (map[string]string, error) {
+	env := map[string]string{}
+	if appEnvFile != "" {
+		appEnv, err := godotenv.Read(path.Join(rootDir, appEnvFile))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		maps.Copy(env, appEnv)
+	}
+	fileEnv, err := ReadEnvFiles(envFiles)
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(env, fileEnv)
+	maps.Copy(env, overrides)
+	return env, nil
+}
+
+// ReadEnvFiles merges the variables in envFiles, later files taking
+// precedence.
+func ReadEnvFiles(envFiles []string) (map[string]string, error) {
+	env := map[string]string{}
+	for _, f := range envFiles {
+		fileEnv, err := godotenv.Read(f)
+		if err != nil {
+			return nil, fmt.Errorf("could not read env file %s: %w", f, err)
+		}
+		maps.Copy(env, fileEnv)
+	}
+	return env, nil
+}
+
+// ParseEnvAssignments parses KEY=VALUE pairs, keeping everything after the
+// first "=" as the value.
+func ParseEnvAssignments(assignments []string) (map[string]string, error) {
+	env := make(map[string]string, len(assignments))
+	for _, a := range assignments {
+		k, v, ok := strings.Cut(a, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid env assignment %q, expected KEY=VALUE", a)
+		}
+		env[k] = v
+	}
+	return env, nil
+}
+
+// CloneTemplateDepth clones a template with its last depth commits, or its
+// full history when depth is 0.
+func CloneTemplateDepth(url, dir string, depth int) (string, string, error) {
 	var stdout = strings.Builder{}
 	var stderr = strings.Builder{}
 
-	cmd := exec.Command("git", "clone", "--depth=1", url, dir)
+	args := []string{"clone"}
+	if depth > 0 {
+		args = append(args, "--depth="+strconv.Itoa(depth))
+	}
+	cmd := exec.Command("git", append(args, url, dir)...)
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
-	return stdout.String(), stderr.String(), cmd.Run()
+	err := cmd.Run()
+	return stdout.String(), stderr.String(), err
+}
+
+// ValidateTemplateURL checks that url is a git URL of a reachable repository,
+// having ref when it names a branch or tag and a taskfile.yaml at ref, without
+// cloning it. Git's output is returned for troubleshooting.
+func ValidateTemplateURL(ctx context.Context, url, ref string) (string, error) {
+	if !templateURLRegex.MatchString(url) {
+		return "", fmt.Errorf("invalid template URL %q, expected an http(s), ssh, git or file URL", url)
+	}
+
+	args := []string{"ls-remote", "--exit-code", url}
+	// commits can't be listed, so they are only checked when cloning
+	if ref != "" && !commitSHARegex.MatchString(ref) {
+		args = append(args, ref)
+	}
+	var stderr = strings.Builder{}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stderr = &stderr
+	// fail instead of prompting for credentials of private or missing repos
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return checkTemplateTaskfile(ctx, url, ref)
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 2:
+		if ref != "" {
+			return stderr.String(), fmt.Errorf("ref %s not found in template repository %s", ref, url)
+		}
+		return stderr.String(), fmt.Errorf("template repository %s is empty", url)
+	case errors.As(err, &exitErr):
+		return stderr.String(), fmt.Errorf("could not reach template repository %s", url)
+	default:
+		return stderr.String(), err
+	}
+}
+
+// checkTemplateTaskfile fetches the tree at ref, or the default branch, without
+// any file contents and checks that it has a taskfile.yaml.
+func checkTemplateTaskfile(ctx context.Context, url, ref string) (string, error) {
+	dir, err := os.MkdirTemp("", "lk-template-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	if ref == "" {
+		ref = "HEAD"
+	}
+	var stdout = strings.Builder{}
+	var stderr = strings.Builder{}
+	for _, args := range [][]string{
+		{"init", "--quiet", dir},
+		{"-C", dir, "fetch", "--quiet", "--depth=1", "--filter=blob:none", url, ref},
+		{"-C", dir, "ls-tree", "--name-only", "FETCH_HEAD", "--", TaskFile},
+	} {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		if err := cmd.Run(); err != nil {
+			return stderr.String(), fmt.Errorf("could not fetch %s from template repository %s", ref, url)
+		}
+	}
+	if strings.TrimSpace(stdout.String()) != TaskFile {
+		return stderr.String(), fmt.Errorf("template repository %s has no %s", url, TaskFile)
+	}
+	return stderr.String(), nil
+}
+
+// CloneTemplateRef clones a template at ref, which may be a branch, tag or
+// full commit SHA, with its last depth commits, or its full history when
+// depth is 0. An empty ref clones the default branch.
+func CloneTemplateRef(url, dir, ref string, depth int) (string, string, error) {
+	if ref == "" {
+		return CloneTemplateDepth(url, dir, depth)
+	}
+
+	var stdout = strings.Builder{}
+	var stderr = strings.Builder{}
+	git := func(args ...string) error {
+		cmd := exec.Command("git", args...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		return cmd.Run()
+	}
+
+	if !commitSHARegex.MatchString(ref) {
+		if err := git(CloneTemplateCommands(url, dir, ref, depth)[0]...); err == nil {
+			return stdout.String(), stderr.String(), nil
+		}
+		// --branch only accepts branches and tags, so fetch other refs directly
+		stdout.Reset()
+		stderr.Reset()
+		if err := os.RemoveAll(dir); err != nil {
+			return "", "", err
+		}
+	}
+	for _, args := range fetchTemplateCommands(url, dir, ref, depth) {
+		if err := git(args...); err != nil {
+			return stdout.String(), stderr.String(),
+				fmt.Errorf("could not check out template ref %s: %s", ref, strings.TrimSpace(stderr.String()))
+		}
+	}
+	return stdout.String(), stderr.String(), nil
+}
+
+// CloneTemplateCommands returns the git arguments CloneTemplateRef runs to
+// clone url at ref into dir: a single clone for a branch or tag, or an init,
+// fetch and checkout for a commit SHA, which clone can't check out.
+func CloneTemplateCommands(url, dir, ref string, depth int) [][]string {
+	if commitSHARegex.MatchString(ref) {
+		return fetchTemplateCommands(url, dir, ref, depth)
+	}
+	args := []string{"clone"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	if depth > 0 {
+		args = append(args, "--depth="+strconv.Itoa(depth))
+	}
+	return [][]string{append(args, url, dir)}
 }
 
-func CleanupTemplate(dir string) error {
-	// Remove files that are only needed for template instantiation
+func fetchTemplateCommands(url, dir, ref string, depth int) [][]string {
+	fetch := []string{"-C", dir, "fetch"}
+	if depth > 0 {
+		fetch = append(fetch, "--depth="+strconv.Itoa(depth))
+	}
+	return [][]string{
+		{"init", "--quiet", dir},
+		{"-C", dir, "remote", "add", "origin", url},
+		append(fetch, "origin", ref),
+		{"-C", dir, "checkout", "--quiet", "--detach", "FETCH_HEAD"},
+	}
+}
+
+// CleanupTemplate removes files that are only needed for template
+// instantiation, except for those named in keep.
+func CleanupTemplate(dir string, keep ...string) error {
 	for _, cleanup := range templateIgnoreFiles {
+		if slices.Contains(keep, cleanup) {
+			continue
+		}
 		if err := os.RemoveAll(path.Join(dir, cleanup)); err != nil {
 			return err
 		}
@@ -0,0 +1,139 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-task/task/v3/taskfile/ast"
+	"gopkg.in/yaml.v3"
+)
+
+func TestFetchTemplatesCached(t *testing.T) {
+	dir := t.TempDir()
+	cachePath := path.Join(dir, templateCacheFile)
+	if err := writeFileAtomic(cachePath, []byte("- name: cached\n  url: https://github.com/livekit-examples/cached\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err := FetchTemplatesCached(context.Background(), dir, time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) != 1 || templates[0].Name != "cached" {
+		t.Errorf("fresh cache should be used, got %v", templates)
+	}
+
+	// a stale cache is fetched again, failing here as the context is done
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(cachePath, old, old); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := FetchTemplatesCached(ctx, dir, time.Hour, false); err == nil {
+		t.Error("stale cache should not be used")
+	}
+	if _, err := FetchTemplatesCached(ctx, dir, 0, false); err == nil {
+		t.Error("cache should not be used with a ttl of 0")
+	}
+}
+
+func TestTaskPrograms(t *testing.T) {
+	tf := &ast.Taskfile{}
+	err := yaml.Unmarshal([]byte(`
+version: "3"
+tasks:
+  install:
+    cmds:
+      - cd web && pnpm install
+      - uv sync
+      - cmd: brew install ffmpeg
+        platforms: [plan9]
+  dev:
+    cmds:
+      - task: install
+      - echo starting; ./scripts/dev.sh | tee dev.log
+      - "{{.PACKAGE_MANAGER}} run dev"
+`), tf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	programs := TaskPrograms(tf)
+	if !slices.Equal(programs, []string{"pnpm", "tee", "uv"}) {
+		t.Errorf("unexpected programs %v", programs)
+	}
+}
+
+func TestTemplateRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	newRepo := func(files ...string) string {
+		dir := t.TempDir()
+		for _, f := range files {
+			if err := os.WriteFile(path.Join(dir, f), []byte("version: '3'\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, args := range [][]string{
+			{"init", "--quiet", "--initial-branch=main"},
+			{"add", "."},
+			{"-c", "user.name=lk", "-c", "user.email=lk@example.com", "commit", "--quiet", "-m", "init"},
+		} {
+			if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %s", args, out)
+			}
+		}
+		return "file://" + dir
+	}
+
+	withTaskfile := newRepo(TaskFile)
+	if _, err := ValidateTemplateURL(context.Background(), withTaskfile, ""); err != nil {
+		t.Errorf("ValidateTemplateURL(%s) = %v", withTaskfile, err)
+	}
+	if _, err := ValidateTemplateURL(context.Background(), withTaskfile, "main"); err != nil {
+		t.Errorf("ValidateTemplateURL(%s, main) = %v", withTaskfile, err)
+	}
+	if _, err := ValidateTemplateURL(context.Background(), withTaskfile, "missing"); err == nil {
+		t.Errorf("ValidateTemplateURL(%s, missing) should fail", withTaskfile)
+	}
+
+	// commits can't be cloned with --branch, so they are fetched instead
+	head, err := exec.Command("git", "-C", strings.TrimPrefix(withTaskfile, "file://"), "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sha := strings.TrimSpace(string(head))
+	if cmds := CloneTemplateCommands(withTaskfile, "app", sha, 1); len(cmds) != 4 || cmds[0][0] != "init" {
+		t.Errorf("CloneTemplateCommands(%s) = %v, expected an init and fetch", sha, cmds)
+	}
+	if _, stderr, err := CloneTemplateRef(withTaskfile, path.Join(t.TempDir(), "app"), sha, 1); err != nil {
+		t.Errorf("CloneTemplateRef(%s) = %v: %s", sha, err, stderr)
+	}
+
+	withoutTaskfile := newRepo("README.md")
+	_, err = ValidateTemplateURL(context.Background(), withoutTaskfile, "")
+	if err == nil || !strings.Contains(err.Error(), TaskFile) {
+		t.Errorf("ValidateTemplateURL(%s) = %v, expected an error naming %s", withoutTaskfile, err, TaskFile)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+)
+
+// CreateStateFile records the progress of creating an app from a template in
+// the app directory, so that a failed run can be resumed. It is removed with
+// the other template files once the app is created.
+const CreateStateFile = ".lk-create-state.json"
+
+// CreatePhase is a completed phase of creating an app, in order.
+type CreatePhase string
+
+const (
+	CreatePhaseCloned      CreatePhase = "cloned"
+	CreatePhaseSubstituted CreatePhase = "substituted"
+	CreatePhaseEnv         CreatePhase = "env"
+	CreatePhaseInstalled   CreatePhase = "installed"
+)
+
+var createPhases = []CreatePhase{
+	CreatePhaseCloned,
+	CreatePhaseSubstituted,
+	CreatePhaseEnv,
+	CreatePhaseInstalled,
+}
+
+type CreateState struct {
+	Phase       CreatePhase `json:"phase"`
+	TemplateURL string      `json:"template_url"`
+	TemplateRef string      `json:"template_ref,omitempty"`
+	SandboxID   string      `json:"sandbox_id,omitempty"`
+}
+
+// Done reports whether phase was completed.
+func (s *CreateState) Done(phase CreatePhase) bool {
+	return slices.Index(createPhases, s.Phase) >= slices.Index(createPhases, phase)
+}
+
+// ReadCreateState reads the state of the app in dir, returning nil when it
+// was fully created or never started.
+func ReadCreateState(dir string) (*CreateState, error) {
+	content, err := os.ReadFile(path.Join(dir, CreateStateFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	s := &CreateState{}
+	if err := json.Unmarshal(content, s); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", CreateStateFile, err)
+	}
+	if !slices.Contains(createPhases, s.Phase) {
+		return nil, fmt.Errorf("unknown phase %q in %s", s.Phase, CreateStateFile)
+	}
+	return s, nil
+}
+
+// Complete records phase as completed in the state of the app in dir.
+func (s *CreateState) Complete(dir string, phase CreatePhase) error {
+	s.Phase = phase
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path.Join(dir, CreateStateFile), content, 0600)
+}
@@ -0,0 +1,45 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"testing"
+)
+
+func TestCreateState(t *testing.T) {
+	dir := t.TempDir()
+	if s, err := ReadCreateState(dir); err != nil || s != nil {
+		t.Fatalf("expected no state in a new directory, got %v, %v", s, err)
+	}
+
+	s := &CreateState{TemplateURL: "https://github.com/livekit-examples/agent-starter-python"}
+	if s.Done(CreatePhaseCloned) {
+		t.Error("no phase should be done before cloning")
+	}
+	if err := s.Complete(dir, CreatePhaseSubstituted); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := ReadCreateState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Done(CreatePhaseCloned) || !s.Done(CreatePhaseSubstituted) || s.Done(CreatePhaseEnv) {
+		t.Errorf("phases up to %s should be done, got %s", CreatePhaseSubstituted, s.Phase)
+	}
+	if s.TemplateURL != "https://github.com/livekit-examples/agent-starter-python" {
+		t.Errorf("template URL should be kept, got %s", s.TemplateURL)
+	}
+}
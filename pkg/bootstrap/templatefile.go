@@ -0,0 +1,211 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TemplateManifestFile lists the files of a template containing placeholders
+// to replace after cloning.
+const TemplateManifestFile = "templatefile.yaml"
+
+// Placeholders in template files, e.g. {{APP_NAME}}, capturing the name
+var placeholderRegex = regexp.MustCompile(`\{\{\s*([A-Z_][A-Z0-9_]*)\s*\}\}`)
+
+// TemplateManifest describes the placeholders of a template, for example:
+//
+//	files:
+//	  - README.md
+//	  - src/**/*.ts
+//	ignore:
+//	  - src/generated/**
+//	variables:
+//	  - name: AGENT_NAME
+//	    default: my-agent
+//
+// Patterns are relative to the template root. Those without a "/" match file
+// names in any directory, and "**" matches any number of directories.
+type TemplateManifest struct {
+	Files  []string `yaml:"files"`
+	Ignore []string `yaml:"ignore"`
+	// custom variables to prompt for, in addition to the known values
+	Variables []TemplateVariable `yaml:"variables"`
+}
+
+type TemplateVariable struct {
+	Name    string `yaml:"name"`
+	Default string `yaml:"default"`
+}
+
+// ParseTemplateManifest reads the manifest of a template in rootPath,
+// returning nil when the template has none.
+func ParseTemplateManifest(rootPath string) (*TemplateManifest, error) {
+	content, err := os.ReadFile(path.Join(rootPath, TemplateManifestFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	m := &TemplateManifest{}
+	if err := yaml.Unmarshal(content, m); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", TemplateManifestFile, err)
+	}
+	for _, v := range m.Variables {
+		if !placeholderRegex.MatchString("{{" + v.Name + "}}") {
+			return nil, fmt.Errorf("invalid variable name %q in %s, must be uppercase letters, digits and underscores", v.Name, TemplateManifestFile)
+		}
+	}
+	return m, nil
+}
+
+// Substitute replaces placeholders in the files of the template in rootPath
+// matching the manifest, using values and prompting for custom variables
+// missing from them. Placeholders with unknown names and binary files are
+// left untouched. It returns the paths of the files that changed.
+func (m *TemplateManifest) Substitute(rootPath string, values map[string]string, prompt PromptFunc) ([]string, error) {
+	vars := make(map[string]string, len(values)+len(m.Variables))
+	for k, v := range values {
+		vars[k] = v
+	}
+	for _, v := range m.Variables {
+		if _, ok := vars[v.Name]; ok {
+			continue
+		}
+		value, err := prompt(v.Name, v.Default)
+		if err != nil {
+			return nil, err
+		}
+		vars[v.Name] = value
+	}
+
+	files, err := compileGlobs(m.Files)
+	if err != nil {
+		return nil, err
+	}
+	ignore, err := compileGlobs(append([]string{".git/**", TemplateManifestFile}, m.Ignore...))
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	err = filepath.WalkDir(rootPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(rootPath, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !matchesAny(files, rel) || matchesAny(ignore, rel) {
+			return nil
+		}
+
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if isBinary(content) {
+			return nil
+		}
+		replaced := placeholderRegex.ReplaceAllFunc(content, func(match []byte) []byte {
+			name := placeholderRegex.FindSubmatch(match)[1]
+			if value, ok := vars[string(name)]; ok {
+				return []byte(value)
+			}
+			return match
+		})
+		if bytes.Equal(replaced, content) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(p, replaced, info.Mode().Perm()); err != nil {
+			return err
+		}
+		changed = append(changed, rel)
+		return nil
+	})
+	return changed, err
+}
+
+// isBinary guesses whether content is binary the way git does, by looking
+// for a NUL byte near the start.
+func isBinary(content []byte) bool {
+	const sniffLen = 8000
+	return bytes.IndexByte(content[:min(len(content), sniffLen)], 0) >= 0
+}
+
+// compileGlobs converts file patterns to regular expressions matching
+// slash-separated relative paths.
+func compileGlobs(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		var sb strings.Builder
+		sb.WriteString("^")
+		if !strings.Contains(pattern, "/") {
+			sb.WriteString("(.*/)?")
+		}
+		// as in .gitignore, a leading "/" only anchors to the template root
+		pattern = strings.TrimPrefix(pattern, "/")
+		for i := 0; i < len(pattern); i++ {
+			switch c := pattern[i]; c {
+			case '*':
+				if strings.HasPrefix(pattern[i:], "**/") {
+					sb.WriteString("(.*/)?")
+					i += 2
+				} else if strings.HasPrefix(pattern[i:], "**") {
+					sb.WriteString(".*")
+					i++
+				} else {
+					sb.WriteString("[^/]*")
+				}
+			case '?':
+				sb.WriteString("[^/]")
+			default:
+				sb.WriteString(regexp.QuoteMeta(string(c)))
+			}
+		}
+		sb.WriteString("$")
+		re, err := regexp.Compile(sb.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q in %s: %w", pattern, TemplateManifestFile, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchesAny(patterns []*regexp.Regexp, p string) bool {
+	for _, re := range patterns {
+		if re.MatchString(p) {
+			return true
+		}
+	}
+	return false
+}
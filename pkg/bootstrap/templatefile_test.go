@@ -0,0 +1,50 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"testing"
+)
+
+func TestCompileGlobs(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"README.md", "README.md", true},
+		{"README.md", "docs/README.md", true},
+		{"/README.md", "README.md", true},
+		{"/README.md", "docs/README.md", false},
+		{"src/*.ts", "src/index.ts", true},
+		{"src/*.ts", "src/lib/index.ts", false},
+		{"src/**/*.ts", "src/index.ts", true},
+		{"src/**/*.ts", "src/lib/deep/index.ts", true},
+		{"src/**", "src/lib/index.ts", true},
+		{"*.go", "cmd/main.go", true},
+		{"file?.txt", "file1.txt", true},
+		{"file?.txt", "file10.txt", false},
+		{"a.b", "aXb", false},
+	}
+	for _, c := range cases {
+		patterns, err := compileGlobs([]string{c.pattern})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matchesAny(patterns, c.path) != c.match {
+			t.Errorf("pattern %q matching %q should be %v", c.pattern, c.path, c.match)
+		}
+	}
+}
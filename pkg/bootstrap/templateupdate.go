@@ -0,0 +1,224 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// TemplateOriginFile records the template an app was created from, so that
+// later changes to the template can be pulled into the app.
+const TemplateOriginFile = ".lk-template.json"
+
+type TemplateOrigin struct {
+	URL string `json:"url"`
+	Ref string `json:"ref,omitempty"`
+	// commit the app files currently correspond to
+	Commit string `json:"commit,omitempty"`
+	// values substituted for the placeholders of templatefile.yaml
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// ReadTemplateOrigin reads the origin of the app in dir, returning nil when
+// it wasn't recorded.
+func ReadTemplateOrigin(dir string) (*TemplateOrigin, error) {
+	content, err := os.ReadFile(path.Join(dir, TemplateOriginFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	o := &TemplateOrigin{}
+	if err := json.Unmarshal(content, o); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", TemplateOriginFile, err)
+	}
+	return o, nil
+}
+
+// Write records the origin in the app in dir.
+func (o *TemplateOrigin) Write(dir string) error {
+	content, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path.Join(dir, TemplateOriginFile), append(content, '\n'), 0644)
+}
+
+// GitHeadCommit returns the commit checked out in the repository in dir.
+func GitHeadCommit(dir string) (string, error) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("could not read template commit: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+type TemplateFileStatus string
+
+const (
+	// added to the template, missing from the app
+	TemplateFileAdded TemplateFileStatus = "added"
+	// changed in the template, unchanged in the app
+	TemplateFileUpdated TemplateFileStatus = "updated"
+	// changed in both, or changed in the template without a recorded base
+	TemplateFileDiverged TemplateFileStatus = "diverged"
+	// removed from the template, kept in the app
+	TemplateFileRemoved TemplateFileStatus = "removed"
+	// diverged, with the template changes merged into the app
+	TemplateFileMerged TemplateFileStatus = "merged"
+	// diverged, with conflict markers left in the app
+	TemplateFileConflict TemplateFileStatus = "conflict"
+)
+
+type TemplateFileChange struct {
+	Path   string             `json:"path"`
+	Status TemplateFileStatus `json:"status"`
+}
+
+// DiffTemplate lists the template files changed between the template the app
+// in appDir was created from, checked out in baseDir, and the template in
+// upstreamDir. Without a base, every upstream file that differs from the app
+// is reported as diverged. Files that only changed in the app are ignored.
+func DiffTemplate(appDir, baseDir, upstreamDir string) ([]TemplateFileChange, error) {
+	upstream, err := templateFiles(upstreamDir)
+	if err != nil {
+		return nil, err
+	}
+	var base []string
+	if baseDir != "" {
+		if base, err = templateFiles(baseDir); err != nil {
+			return nil, err
+		}
+	}
+
+	var changes []TemplateFileChange
+	for _, p := range upstream {
+		upstreamContent, err := os.ReadFile(filepath.Join(upstreamDir, p))
+		if err != nil {
+			return nil, err
+		}
+		localContent, err := os.ReadFile(filepath.Join(appDir, p))
+		if errors.Is(err, fs.ErrNotExist) {
+			changes = append(changes, TemplateFileChange{Path: p, Status: TemplateFileAdded})
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(localContent, upstreamContent) {
+			continue
+		}
+		if baseDir == "" || !slices.Contains(base, p) {
+			changes = append(changes, TemplateFileChange{Path: p, Status: TemplateFileDiverged})
+			continue
+		}
+		baseContent, err := os.ReadFile(filepath.Join(baseDir, p))
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case bytes.Equal(baseContent, upstreamContent):
+			// only changed in the app
+		case bytes.Equal(baseContent, localContent):
+			changes = append(changes, TemplateFileChange{Path: p, Status: TemplateFileUpdated})
+		default:
+			changes = append(changes, TemplateFileChange{Path: p, Status: TemplateFileDiverged})
+		}
+	}
+	for _, p := range base {
+		if !slices.Contains(upstream, p) {
+			if _, err := os.Stat(filepath.Join(appDir, p)); err == nil {
+				changes = append(changes, TemplateFileChange{Path: p, Status: TemplateFileRemoved})
+			}
+		}
+	}
+	return changes, nil
+}
+
+// MergeTemplateFile merges the changes between the base and upstream versions
+// of a template file into the app's copy with git merge-file, returning
+// whether conflict markers were left in the file.
+func MergeTemplateFile(appDir, baseDir, upstreamDir, p string) (bool, error) {
+	cmd := exec.Command("git", "merge-file",
+		"-L", "app", "-L", "template base", "-L", "template",
+		filepath.Join(appDir, p), filepath.Join(baseDir, p), filepath.Join(upstreamDir, p))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return false, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() > 0 && stderr.Len() == 0:
+		// the exit code is the number of conflicts
+		return true, nil
+	default:
+		return false, fmt.Errorf("could not merge %s: %s", p, strings.TrimSpace(stderr.String()))
+	}
+}
+
+// CopyTemplateFile copies a file of the template in upstreamDir to the app.
+func CopyTemplateFile(appDir, upstreamDir, p string) error {
+	src := filepath.Join(upstreamDir, p)
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	dst := filepath.Join(appDir, p)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, content, info.Mode().Perm())
+}
+
+// templateFiles lists the files of a template checked out in dir that are
+// copied to apps, as slash-separated relative paths.
+func templateFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel != "." && slices.Contains(templateIgnoreFiles, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDiffTemplate(t *testing.T) {
+	writeFiles := func(files map[string]string) string {
+		dir := t.TempDir()
+		for p, content := range files {
+			if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, p)), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, p), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return dir
+	}
+	base := writeFiles(map[string]string{
+		"same.txt":      "same",
+		"updated.txt":   "v1",
+		"diverged.txt":  "v1",
+		"local.txt":     "v1",
+		"removed.txt":   "v1",
+		"taskfile.yaml": "v1",
+	})
+	upstream := writeFiles(map[string]string{
+		"same.txt":      "same",
+		"updated.txt":   "v2",
+		"diverged.txt":  "v2",
+		"local.txt":     "v1",
+		"src/added.txt": "v2",
+		"taskfile.yaml": "v2",
+	})
+	app := writeFiles(map[string]string{
+		"same.txt":     "same",
+		"updated.txt":  "v1",
+		"diverged.txt": "mine",
+		"local.txt":    "mine",
+		"removed.txt":  "v1",
+	})
+
+	changes, err := DiffTemplate(app, base, upstream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []TemplateFileChange{
+		{Path: "diverged.txt", Status: TemplateFileDiverged},
+		{Path: "src/added.txt", Status: TemplateFileAdded},
+		{Path: "updated.txt", Status: TemplateFileUpdated},
+		{Path: "removed.txt", Status: TemplateFileRemoved},
+	}
+	if !slices.Equal(changes, expected) {
+		t.Errorf("DiffTemplate = %v, expected %v", changes, expected)
+	}
+
+	// without a base, local changes can't be told apart from template ones
+	changes, err = DiffTemplate(app, "", upstream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 4 || changes[2] != (TemplateFileChange{Path: "src/added.txt", Status: TemplateFileAdded}) {
+		t.Errorf("DiffTemplate without base = %v", changes)
+	}
+}
@@ -24,6 +24,9 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// path of the config file given with SetPath, overriding the default location
+var configPath string
+
 type CLIConfig struct {
 	DefaultProject string          `yaml:"default_project"`
 	Projects       []ProjectConfig `yaml:"projects"`
@@ -37,6 +40,8 @@ type ProjectConfig struct {
 	URL       string `yaml:"url"`
 	APIKey    string `yaml:"api_key"`
 	APISecret string `yaml:"api_secret"`
+	// agent dispatched by `lk dispatch create` when --agent-name is not set
+	DefaultAgentName string `yaml:"default_agent_name,omitempty"`
 }
 
 func LoadDefaultProject() (*ProjectConfig, error) {
@@ -69,10 +74,41 @@ func LoadProject(name string) (*ProjectConfig, error) {
 		}
 	}
 
-	return nil, errors.New("project not found")
+	return nil, conf.ProjectNotFoundError(name)
 }
 
-// LoadOrCreate loads config file from ~/.livekit/cli-config.yaml
+// ProjectNotFoundError reports that no project is named name, listing the
+// configured ones.
+func (c *CLIConfig) ProjectNotFoundError(name string) error {
+	if len(c.Projects) == 0 {
+		return fmt.Errorf("project %s not found, no projects are configured, run `lk cloud auth` or `lk project add` to add one", name)
+	}
+	names := make([]string, 0, len(c.Projects))
+	for _, p := range c.Projects {
+		names = append(names, p.Name)
+	}
+	return fmt.Errorf("project %s not found, available projects: %s", name, strings.Join(names, ", "))
+}
+
+// SetPath makes the CLI read and write its config file at p instead of
+// ~/.livekit/cli-config.yaml. The file must exist and be readable.
+func SetPath(p string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return fmt.Errorf("could not read config file: %w", err)
+	}
+	defer f.Close()
+	if s, err := f.Stat(); err != nil {
+		return fmt.Errorf("could not read config file: %w", err)
+	} else if s.IsDir() {
+		return fmt.Errorf("config file %s is a directory", p)
+	}
+	configPath = p
+	return nil
+}
+
+// LoadOrCreate loads config file from ~/.livekit/cli-config.yaml, or the path
+// given with SetPath
 // if it doesn't exist, it'll return an empty config file
 func LoadOrCreate() (*CLIConfig, error) {
 	configPath, err := getConfigLocation()
@@ -105,6 +141,22 @@ func LoadOrCreate() (*CLIConfig, error) {
 	return c, nil
 }
 
+// SameCredentials reports whether p and o have the same URL and credentials,
+// ignoring settings kept only in the local config.
+func (p ProjectConfig) SameCredentials(o ProjectConfig) bool {
+	return p.URL == o.URL && p.APIKey == o.APIKey && p.APISecret == o.APISecret
+}
+
+// MergeCredentials returns p with the URL and credentials of o, keeping the
+// local settings of p, or taking those of o when p has none.
+func (p ProjectConfig) MergeCredentials(o ProjectConfig) ProjectConfig {
+	p.URL, p.APIKey, p.APISecret = o.URL, o.APIKey, o.APISecret
+	if p.DefaultAgentName == "" {
+		p.DefaultAgentName = o.DefaultAgentName
+	}
+	return p
+}
+
 func (c *CLIConfig) ProjectExists(name string) bool {
 	for _, p := range c.Projects {
 		if strings.EqualFold(p.Name, name) {
@@ -163,10 +215,23 @@ func (c *CLIConfig) PersistIfNeeded() error {
 }
 
 func getConfigLocation() (string, error) {
-	dir, err := os.UserHomeDir()
+	if configPath != "" {
+		return configPath, nil
+	}
+	dir, err := Dir()
 	if err != nil {
 		return "", err
 	}
 
-	return path.Join(dir, ".livekit", "cli-config.yaml"), nil
+	return path.Join(dir, "cli-config.yaml"), nil
+}
+
+// Dir returns the directory holding the CLI config, ~/.livekit, along with
+// other files kept between runs such as caches.
+func Dir() (string, error) {
+	dir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(dir, ".livekit"), nil
 }
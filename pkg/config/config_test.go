@@ -0,0 +1,56 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetPath(t *testing.T) {
+	t.Cleanup(func() { configPath = "" })
+	dir := t.TempDir()
+
+	require.Error(t, SetPath(path.Join(dir, "missing.yaml")))
+	require.Error(t, SetPath(dir))
+
+	p := path.Join(dir, "staging.yaml")
+	content := "default_project: staging\nprojects:\n  - name: staging\n    url: wss://staging.livekit.cloud\n"
+	require.NoError(t, os.WriteFile(p, []byte(content), 0600))
+	require.NoError(t, SetPath(p))
+	pc, err := LoadDefaultProject()
+	require.NoError(t, err)
+	require.Equal(t, "wss://staging.livekit.cloud", pc.URL)
+}
+
+func TestMergeCredentials(t *testing.T) {
+	local := ProjectConfig{Name: "a", URL: "wss://a.livekit.cloud", APIKey: "k", APISecret: "s", DefaultAgentName: "agent"}
+	remote := ProjectConfig{Name: "a", URL: "wss://a.livekit.cloud", APIKey: "k", APISecret: "s"}
+	require.True(t, local.SameCredentials(remote))
+
+	remote.APISecret = "s2"
+	require.False(t, local.SameCredentials(remote))
+	merged := local.MergeCredentials(remote)
+	require.Equal(t, "s2", merged.APISecret)
+	require.Equal(t, "agent", merged.DefaultAgentName)
+
+	remote.DefaultAgentName = "other"
+	require.Equal(t, "agent", local.MergeCredentials(remote).DefaultAgentName)
+	local.DefaultAgentName = ""
+	require.Equal(t, "other", local.MergeCredentials(remote).DefaultAgentName)
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MaxEgressRecords is the number of started egresses kept in the history file.
+const MaxEgressRecords = 50
+
+type EgressRecord struct {
+	EgressID  string    `yaml:"egress_id" json:"egress_id"`
+	Type      string    `yaml:"type" json:"type"`
+	Source    string    `yaml:"source,omitempty" json:"source,omitempty"`
+	URL       string    `yaml:"url" json:"url"`
+	StartedAt time.Time `yaml:"started_at" json:"started_at"`
+}
+
+// LoadEgressRecords reads the egresses started from this machine, oldest first.
+func LoadEgressRecords() ([]EgressRecord, error) {
+	historyPath, err := getEgressHistoryLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(historyPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var records []EgressRecord
+	if err = yaml.Unmarshal(content, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// RecordEgress appends r to the history file, dropping the oldest records
+// beyond MaxEgressRecords.
+func RecordEgress(r EgressRecord) error {
+	records, err := LoadEgressRecords()
+	if err != nil {
+		return err
+	}
+	records = append(records, r)
+	if len(records) > MaxEgressRecords {
+		records = records[len(records)-MaxEgressRecords:]
+	}
+	return saveEgressRecords(records)
+}
+
+// RemoveEgressRecord deletes the record of an egress from the history file.
+func RemoveEgressRecord(egressID string) error {
+	records, err := LoadEgressRecords()
+	if err != nil {
+		return err
+	}
+	return saveEgressRecords(slices.DeleteFunc(records, func(r EgressRecord) bool {
+		return r.EgressID == egressID
+	}))
+}
+
+func saveEgressRecords(records []EgressRecord) error {
+	historyPath, err := getEgressHistoryLocation()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(path.Dir(historyPath), 0700); err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(records)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(historyPath, data, 0600)
+}
+
+func getEgressHistoryLocation() (string, error) {
+	dir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, ".livekit", "egress-history.yaml"), nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v3"
+)
+
+const exportVersion = 1
+
+var (
+	ErrPassphraseRequired = errors.New("export is encrypted, a passphrase is required")
+	ErrInvalidPassphrase  = errors.New("could not decrypt export, wrong passphrase?")
+)
+
+// ProjectExport is the portable form of a set of project configs. When
+// encrypted, Projects is empty and Ciphertext holds the sealed projects, with
+// binary fields base64 encoded.
+type ProjectExport struct {
+	Version    int             `yaml:"version"`
+	Projects   []ProjectConfig `yaml:"projects,omitempty"`
+	Salt       string          `yaml:"salt,omitempty"`
+	Nonce      string          `yaml:"nonce,omitempty"`
+	Ciphertext string          `yaml:"ciphertext,omitempty"`
+}
+
+// ExportProjects serializes projects, encrypting them with a key derived
+// from passphrase unless it is empty.
+func ExportProjects(projects []ProjectConfig, passphrase string) ([]byte, error) {
+	export := ProjectExport{Version: exportVersion}
+	if passphrase == "" {
+		export.Projects = projects
+		return yaml.Marshal(export)
+	}
+
+	plaintext, err := yaml.Marshal(projects)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err = rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := exportCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	export.Salt = base64.StdEncoding.EncodeToString(salt)
+	export.Nonce = base64.StdEncoding.EncodeToString(nonce)
+	export.Ciphertext = base64.StdEncoding.EncodeToString(aead.Seal(nil, nonce, plaintext, nil))
+	return yaml.Marshal(export)
+}
+
+// ImportProjects reads projects written by ExportProjects.
+func ImportProjects(data []byte, passphrase string) ([]ProjectConfig, error) {
+	var export ProjectExport
+	if err := yaml.Unmarshal(data, &export); err != nil {
+		return nil, err
+	}
+	if export.Version != exportVersion {
+		return nil, fmt.Errorf("unsupported export version %d", export.Version)
+	}
+	if export.Ciphertext == "" {
+		return export.Projects, nil
+	}
+	if passphrase == "" {
+		return nil, ErrPassphraseRequired
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(export.Salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := base64.StdEncoding.DecodeString(export.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(export.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := exportCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid export nonce")
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrInvalidPassphrase
+	}
+	var projects []ProjectConfig
+	if err = yaml.Unmarshal(plaintext, &projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// IsEncryptedExport reports whether data is an export that needs a passphrase.
+func IsEncryptedExport(data []byte) bool {
+	var export ProjectExport
+	return yaml.Unmarshal(data, &export) == nil && export.Ciphertext != ""
+}
+
+func exportCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
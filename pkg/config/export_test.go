@@ -0,0 +1,54 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportProjects(t *testing.T) {
+	projects := []ProjectConfig{
+		{Name: "prod", URL: "wss://prod.livekit.cloud", APIKey: "APIabc", APISecret: "supersecret"},
+	}
+
+	t.Run("plain", func(t *testing.T) {
+		data, err := ExportProjects(projects, "")
+		require.NoError(t, err)
+		require.False(t, IsEncryptedExport(data))
+
+		imported, err := ImportProjects(data, "")
+		require.NoError(t, err)
+		require.Equal(t, projects, imported)
+	})
+
+	t.Run("encrypted", func(t *testing.T) {
+		data, err := ExportProjects(projects, "hunter2")
+		require.NoError(t, err)
+		require.True(t, IsEncryptedExport(data))
+		require.False(t, bytes.Contains(data, []byte("supersecret")))
+
+		_, err = ImportProjects(data, "")
+		require.ErrorIs(t, err, ErrPassphraseRequired)
+		_, err = ImportProjects(data, "wrong")
+		require.ErrorIs(t, err, ErrInvalidPassphrase)
+
+		imported, err := ImportProjects(data, "hunter2")
+		require.NoError(t, err)
+		require.Equal(t, projects, imported)
+	})
+}
@@ -75,15 +75,9 @@ func NewLoadTest(params Params) *LoadTest {
 }
 
 func (t *LoadTest) Run(ctx context.Context) error {
-	parsedUrl, err := url.Parse(t.Params.URL)
-	if err != nil {
+	if err := t.checkCloudLimits(t.Params.VideoPublishers, t.Params.AudioPublishers, t.Params.Subscribers); err != nil {
 		return err
 	}
-	if strings.HasSuffix(parsedUrl.Hostname(), ".livekit.cloud") {
-		if t.Params.VideoPublishers > 50 || t.Params.Subscribers > 50 || t.Params.AudioPublishers > 50 {
-			return errors.New("Unable to perform load test on LiveKit Cloud. Load testing is prohibited by our acceptable use policy: https://livekit.io/legal/acceptable-use-policy")
-		}
-	}
 
 	stats, err := t.run(ctx, t.Params)
 	if err != nil {
@@ -160,9 +154,9 @@ func (t *LoadTest) Run(ctx context.Context) error {
 				return util.FormHeaderStyle
 			}
 			if row == len(names) {
-				return util.FormBaseStyle.Bold(true).Reverse(true)
+				return util.CellStyle(util.FormBaseStyle.Bold(true).Reverse(true))
 			}
-			return util.FormBaseStyle
+			return util.CellStyle(util.FormBaseStyle)
 		})
 	for _, name := range names {
 		s := summaries[name]
@@ -179,7 +173,7 @@ func (t *LoadTest) Run(ctx context.Context) error {
 			formatBitrate(s.bytes, s.elapsed),
 			formatBitrate(s.bytes/int64(len(summaries)), s.elapsed),
 		)
-		summaryTable.Row("Total", fmt.Sprintf("%d/%d", s.tracks, s.expected), sBitrate, sDropped, string(s.errCount))
+		summaryTable.Row("Total", fmt.Sprintf("%d/%d", s.tracks, s.expected), sBitrate, sDropped, strconv.FormatInt(s.errCount, 10))
 	}
 	fmt.Println("\nSubscriber summaries:")
 	fmt.Println(summaryTable)
@@ -187,6 +181,19 @@ func (t *LoadTest) Run(ctx context.Context) error {
 	return nil
 }
 
+func (t *LoadTest) checkCloudLimits(videoPublishers, audioPublishers, subscribers int) error {
+	parsedUrl, err := url.Parse(t.Params.URL)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(parsedUrl.Hostname(), ".livekit.cloud") {
+		if videoPublishers > 50 || subscribers > 50 || audioPublishers > 50 {
+			return errors.New("Unable to perform load test on LiveKit Cloud. Load testing is prohibited by our acceptable use policy: https://livekit.io/legal/acceptable-use-policy")
+		}
+	}
+	return nil
+}
+
 func (t *LoadTest) RunSuite(ctx context.Context) error {
 	cases := []*struct {
 		publishers  int
@@ -333,31 +340,8 @@ func (t *LoadTest) run(ctx context.Context, params Params) (map[string]*testerSt
 				return nil
 			}
 
-			if isAudioPublisher {
-				audio, err := tester.PublishAudioTrack("audio")
-				if err != nil {
-					errs.Store(testerParams.name, err)
-					return nil
-				}
-				t.lock.Lock()
-				t.trackNames[audio] = fmt.Sprintf("%dA", testerParams.Sequence)
-				t.lock.Unlock()
-			}
-			if isVideoPublisher {
-				var video string
-				var err error
-				if params.Simulcast {
-					video, err = tester.PublishSimulcastTrack("video-simulcast", params.VideoResolution, params.VideoCodec)
-				} else {
-					video, err = tester.PublishVideoTrack("video", params.VideoResolution, params.VideoCodec)
-				}
-				if err != nil {
-					errs.Store(testerParams.name, err)
-					return nil
-				}
-				t.lock.Lock()
-				t.trackNames[video] = fmt.Sprintf("%dV", testerParams.Sequence)
-				t.lock.Unlock()
+			if err := t.publish(tester, isAudioPublisher, isVideoPublisher, params.VideoResolution, params.VideoCodec, params.Simulcast); err != nil {
+				errs.Store(testerParams.name, err)
 			}
 			return nil
 		})
@@ -411,3 +395,33 @@ func (t *LoadTest) run(ctx context.Context, params Params) (map[string]*testerSt
 
 	return stats, nil
 }
+
+// publish publishes the requested tracks of a started tester, recording their
+// names for the track loading table.
+func (t *LoadTest) publish(tester *LoadTester, audio, video bool, resolution, codec string, simulcast bool) error {
+	if audio {
+		sid, err := tester.PublishAudioTrack("audio")
+		if err != nil {
+			return err
+		}
+		t.lock.Lock()
+		t.trackNames[sid] = fmt.Sprintf("%dA", tester.params.Sequence)
+		t.lock.Unlock()
+	}
+	if video {
+		var sid string
+		var err error
+		if simulcast {
+			sid, err = tester.PublishSimulcastTrack("video-simulcast", resolution, codec)
+		} else {
+			sid, err = tester.PublishVideoTrack("video", resolution, codec)
+		}
+		if err != nil {
+			return err
+		}
+		t.lock.Lock()
+		t.trackNames[sid] = fmt.Sprintf("%dV", tester.params.Sequence)
+		t.lock.Unlock()
+	}
+	return nil
+}
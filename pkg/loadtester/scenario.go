@@ -0,0 +1,344 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtester
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+	"gopkg.in/yaml.v3"
+
+	"github.com/livekit/livekit-cli/pkg/util"
+)
+
+const (
+	MediaVideo      = "video"
+	MediaAudio      = "audio"
+	MediaAudioVideo = "audio+video"
+	MediaNone       = "none"
+)
+
+// Scenario describes a load test made of groups of testers that behave
+// differently, e.g. a few presenters publishing video and many viewers.
+type Scenario struct {
+	Groups []ScenarioGroup `yaml:"groups"`
+}
+
+type ScenarioGroup struct {
+	Name  string `yaml:"name"`
+	Count int    `yaml:"count"`
+	// one of "video", "audio", "audio+video" or "none"
+	Media string `yaml:"media"`
+	// subscribe to published tracks, always true for groups that do not publish
+	Subscribe bool `yaml:"subscribe"`
+	// override the video resolution and codec of the load test
+	VideoResolution string `yaml:"video_resolution"`
+	VideoCodec      string `yaml:"video_codec"`
+	// how long after the start of the test the group joins
+	JoinAfter time.Duration `yaml:"join_after"`
+	// how long after joining the group leaves, staying until the end when 0
+	LeaveAfter time.Duration `yaml:"leave_after"`
+}
+
+func (g *ScenarioGroup) publishesAudio() bool {
+	return g.Media == MediaAudio || g.Media == MediaAudioVideo
+}
+
+func (g *ScenarioGroup) publishesVideo() bool {
+	return g.Media == MediaVideo || g.Media == MediaAudioVideo
+}
+
+func (g *ScenarioGroup) tracks() int {
+	n := 0
+	if g.publishesAudio() {
+		n++
+	}
+	if g.publishesVideo() {
+		n++
+	}
+	return n
+}
+
+func LoadScenario(path string) (*Scenario, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Scenario{}
+	if err = yaml.Unmarshal(b, s); err != nil {
+		return nil, errors.Wrap(err, "could not parse scenario")
+	}
+	if err = s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Validate checks the groups of the scenario, filling in default names and
+// marking groups that do not publish as subscribers.
+func (s *Scenario) Validate() error {
+	if len(s.Groups) == 0 {
+		return errors.New("scenario has no groups")
+	}
+	names := make(map[string]bool)
+	for i := range s.Groups {
+		g := &s.Groups[i]
+		if g.Name == "" {
+			g.Name = fmt.Sprintf("group%d", i+1)
+		}
+		if names[g.Name] {
+			return fmt.Errorf("duplicate scenario group %q", g.Name)
+		}
+		names[g.Name] = true
+		if g.Count <= 0 {
+			return fmt.Errorf("scenario group %q: count must be positive", g.Name)
+		}
+		switch g.Media {
+		case "":
+			g.Media = MediaNone
+		case MediaVideo, MediaAudio, MediaAudioVideo, MediaNone:
+		default:
+			return fmt.Errorf("scenario group %q: unknown media %q, must be one of %s, %s, %s, %s",
+				g.Name, g.Media, MediaVideo, MediaAudio, MediaAudioVideo, MediaNone)
+		}
+		if g.Media == MediaNone {
+			g.Subscribe = true
+		}
+		if g.JoinAfter < 0 || g.LeaveAfter < 0 {
+			return fmt.Errorf("scenario group %q: join_after and leave_after cannot be negative", g.Name)
+		}
+	}
+	return nil
+}
+
+func (t *LoadTest) RunScenario(ctx context.Context, scenario *Scenario) error {
+	var videoPublishers, audioPublishers, subscribers, publishedTracks int
+	for _, g := range scenario.Groups {
+		if g.publishesVideo() {
+			videoPublishers += g.Count
+		}
+		if g.publishesAudio() {
+			audioPublishers += g.Count
+		}
+		if g.Subscribe {
+			subscribers += g.Count
+		}
+		publishedTracks += g.Count * g.tracks()
+	}
+	if err := t.checkCloudLimits(videoPublishers, audioPublishers, subscribers); err != nil {
+		return err
+	}
+
+	params := t.Params
+	if params.Room == "" {
+		params.Room = fmt.Sprintf("testroom%d", rand.Int31n(1000))
+	}
+	if params.IdentityPrefix == "" {
+		params.IdentityPrefix = randStringRunes(5)
+	}
+
+	groupStrings := make([]string, 0, len(scenario.Groups))
+	for _, g := range scenario.Groups {
+		groupStrings = append(groupStrings, fmt.Sprintf("%d %s (%s)", g.Count, g.Name, g.Media))
+	}
+	fmt.Printf("Starting scenario load test with %s, room: %s\n",
+		strings.Join(groupStrings, ", "), params.Room)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		testers    []*LoadTester
+		groupNames = make(map[string]string)
+		errs       sync.Map
+		wg         sync.WaitGroup
+		joined     sync.WaitGroup
+		publishers []*LoadTester
+		started    = time.Now()
+		sequence   = 0
+	)
+	limiter := rate.NewLimiter(rate.Limit(params.NumPerSecond), 1)
+
+	for _, g := range scenario.Groups {
+		resolution := g.VideoResolution
+		if resolution == "" {
+			resolution = params.VideoResolution
+		}
+		codec := g.VideoCodec
+		if codec == "" {
+			codec = params.VideoCodec
+		}
+
+		for i := 0; i < g.Count; i++ {
+			testerParams := params.TesterParams
+			testerParams.Sequence = sequence
+			testerParams.IdentityPrefix += "_" + g.Name
+			testerParams.Subscribe = g.Subscribe
+			testerParams.name = fmt.Sprintf("%s %d", g.Name, i)
+			if g.Subscribe {
+				testerParams.expectedTracks = publishedTracks - g.tracks()
+			}
+			sequence++
+
+			tester := NewLoadTester(testerParams)
+			testers = append(testers, tester)
+			groupNames[testerParams.name] = g.Name
+			if g.tracks() > 0 && g.JoinAfter == 0 && g.LeaveAfter == 0 {
+				publishers = append(publishers, tester)
+			}
+
+			join := func() {
+				if err := tester.Start(); err != nil {
+					fmt.Println(errors.Wrapf(err, "could not connect %s", testerParams.name))
+					errs.Store(testerParams.name, err)
+					return
+				}
+				if err := t.publish(tester, g.publishesAudio(), g.publishesVideo(), resolution, codec, params.Simulcast); err != nil {
+					errs.Store(testerParams.name, err)
+				}
+				if g.LeaveAfter > 0 {
+					time.AfterFunc(g.LeaveAfter, func() {
+						fmt.Printf("%s leaving after %s\n", testerParams.name, g.LeaveAfter)
+						tester.Stop()
+					})
+				}
+			}
+
+			wg.Add(1)
+			if g.JoinAfter == 0 {
+				if err := limiter.Wait(ctx); err != nil {
+					wg.Done()
+					cancel()
+					wg.Wait()
+					for _, tester := range testers {
+						tester.Stop()
+					}
+					return err
+				}
+				joined.Add(1)
+				go func() {
+					defer wg.Done()
+					defer joined.Done()
+					join()
+				}()
+				continue
+			}
+			go func() {
+				defer wg.Done()
+				select {
+				case <-runCtx.Done():
+					return
+				case <-time.After(time.Until(started.Add(g.JoinAfter))):
+				}
+				if err := limiter.Wait(runCtx); err != nil {
+					return
+				}
+				join()
+			}()
+		}
+	}
+
+	joined.Wait()
+
+	var speakerSim *SpeakerSimulator
+	if len(publishers) > 0 && params.SimulateSpeakers {
+		speakerSim = NewSpeakerSimulator(SpeakerSimulatorParams{
+			Testers: publishers,
+		})
+		speakerSim.Start()
+	}
+
+	duration := params.Duration
+	if duration == 0 {
+		// a really long time
+		duration = 1000 * time.Hour
+	}
+	fmt.Printf("Finished connecting groups without join_after, running for %s\n", duration.String())
+
+	select {
+	case <-ctx.Done():
+		// canceled
+	case <-time.After(time.Until(started.Add(duration))):
+		// finished
+	}
+
+	// abort groups that have not joined yet, and wait for those joining
+	cancel()
+	wg.Wait()
+	if speakerSim != nil {
+		speakerSim.Stop()
+	}
+
+	stats := make(map[string]*testerStats)
+	for _, tester := range testers {
+		tester.Stop()
+		stats[tester.params.name] = tester.getStats()
+		if e, _ := errs.Load(tester.params.name); e != nil {
+			stats[tester.params.name].err = e.(error)
+		}
+	}
+
+	printScenarioSummary(scenario, groupNames, stats)
+	return nil
+}
+
+func printScenarioSummary(scenario *Scenario, groupNames map[string]string, stats map[string]*testerStats) {
+	groupSummaries := make(map[string]map[string]*summary)
+	for name, testerStats := range stats {
+		group := groupNames[name]
+		if groupSummaries[group] == nil {
+			groupSummaries[group] = make(map[string]*summary)
+		}
+		groupSummaries[group][name] = getTesterSummary(testerStats)
+	}
+
+	summaryTable := util.CreateTable().
+		Headers("Group", "Testers", "Media", "Tracks", "Bitrate", "Total Pkt. Loss", "Errors")
+	for _, g := range scenario.Groups {
+		summaries := groupSummaries[g.Name]
+		if len(summaries) == 0 {
+			continue
+		}
+		s := getTestSummary(summaries)
+		tracks, bitrate, dropped := "-", "-", "-"
+		if g.Subscribe {
+			tracks = fmt.Sprintf("%d/%d", s.tracks, s.expected)
+			bitrate = fmt.Sprintf("%s (%s avg)",
+				formatBitrate(s.bytes, s.elapsed),
+				formatBitrate(s.bytes/int64(len(summaries)), s.elapsed),
+			)
+			dropped = formatLossRate(s.packets, s.dropped)
+		}
+		summaryTable.Row(
+			g.Name,
+			strconv.Itoa(len(summaries)),
+			g.Media,
+			tracks,
+			bitrate,
+			dropped,
+			strconv.FormatInt(s.errCount, 10),
+		)
+	}
+	fmt.Println("\nScenario summary:")
+	fmt.Println(summaryTable)
+}
@@ -15,11 +15,50 @@
 package util
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
 func PrintJSON(obj any) {
-	txt, _ := json.MarshalIndent(obj, "", "  ")
+	txt, _ := MarshalStableJSON(obj)
+	fmt.Println(string(txt))
+}
+
+// PrintProtoJSON prints msg in its canonical protobuf JSON form, including
+// fields left at their zero value.
+func PrintProtoJSON(msg proto.Message) error {
+	txt, err := protojson.MarshalOptions{
+		Multiline:       true,
+		Indent:          "  ",
+		EmitUnpopulated: true,
+	}.Marshal(msg)
+	if err != nil {
+		return err
+	}
 	fmt.Println(string(txt))
+	return nil
+}
+
+// MarshalStableJSON marshals obj as indented JSON with the keys of every
+// object sorted, so that output is byte-stable for the same data. The order
+// of lists is preserved.
+func MarshalStableJSON(obj any) ([]byte, error) {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	// round-trip through generic values, which encoding/json always
+	// marshals with sorted keys, keeping numbers exactly as they were
+	var generic any
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err = dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(generic, "", "  ")
 }
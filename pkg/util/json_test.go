@@ -0,0 +1,57 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+)
+
+func TestMarshalStableJSON(t *testing.T) {
+	type nested struct {
+		Zeta  string `json:"zeta"`
+		Alpha int64  `json:"alpha"`
+	}
+	obj := struct {
+		Name  string   `json:"name"`
+		Items []nested `json:"items"`
+		Big   int64    `json:"big"`
+	}{
+		Name:  "test",
+		Items: []nested{{Zeta: "b", Alpha: 2}, {Zeta: "a", Alpha: 1}},
+		Big:   1739000000123456789,
+	}
+
+	expected := `{
+  "big": 1739000000123456789,
+  "items": [
+    {
+      "alpha": 2,
+      "zeta": "b"
+    },
+    {
+      "alpha": 1,
+      "zeta": "a"
+    }
+  ],
+  "name": "test"
+}`
+	out, err := MarshalStableJSON(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != expected {
+		t.Errorf("MarshalStableJSON should sort keys and preserve list order, got:\n%s", out)
+	}
+}
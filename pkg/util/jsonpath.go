@@ -0,0 +1,170 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// JSONPath is a parsed subset of JSONPath: a root `$` followed by fields
+// (`.name` or `['name']`), indices (`[0]`, negative from the end), and
+// wildcards (`.*` or `[*]`).
+//
+// Field names match regardless of case and underscores, so `fileResults`
+// and `file_results` select the same field.
+type JSONPath []jsonPathStep
+
+type jsonPathStep struct {
+	field    string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func ParseJSONPath(path string) (JSONPath, error) {
+	invalid := func(msg string) error {
+		return fmt.Errorf("invalid JSON path %q: %s", path, msg)
+	}
+	rest, ok := strings.CutPrefix(strings.TrimSpace(path), "$")
+	if !ok {
+		return nil, invalid("must start with $")
+	}
+
+	var p JSONPath
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			rest = rest[end:]
+			switch name {
+			case "":
+				return nil, invalid("empty field name")
+			case "*":
+				p = append(p, jsonPathStep{wildcard: true})
+			default:
+				p = append(p, jsonPathStep{field: name})
+			}
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, invalid("unclosed [")
+			}
+			sel := rest[1:end]
+			rest = rest[end+1:]
+			switch {
+			case sel == "*":
+				p = append(p, jsonPathStep{wildcard: true})
+			case len(sel) >= 2 && (sel[0] == '\'' || sel[0] == '"') && sel[len(sel)-1] == sel[0]:
+				p = append(p, jsonPathStep{field: sel[1 : len(sel)-1]})
+			default:
+				i, err := strconv.Atoi(sel)
+				if err != nil {
+					return nil, invalid(fmt.Sprintf("unsupported selector [%s]", sel))
+				}
+				p = append(p, jsonPathStep{index: i, isIndex: true})
+			}
+		default:
+			return nil, invalid(fmt.Sprintf("unexpected %q", rest[0]))
+		}
+	}
+	return p, nil
+}
+
+// Eval returns the values matched by p in obj, which is first converted to
+// its JSON representation.
+func (p JSONPath) Eval(obj any) ([]any, error) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	if err = json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+
+	matches := []any{v}
+	for _, step := range p {
+		var next []any
+		for _, m := range matches {
+			next = append(next, step.apply(m)...)
+		}
+		matches = next
+	}
+	return matches, nil
+}
+
+func (s jsonPathStep) apply(v any) []any {
+	switch t := v.(type) {
+	case map[string]any:
+		if s.wildcard {
+			keys := slices.Sorted(maps.Keys(t))
+			out := make([]any, 0, len(keys))
+			for _, k := range keys {
+				out = append(out, t[k])
+			}
+			return out
+		}
+		if s.isIndex {
+			return nil
+		}
+		if val, ok := t[s.field]; ok {
+			return []any{val}
+		}
+		for k, val := range t {
+			if normalizeJSONKey(k) == normalizeJSONKey(s.field) {
+				return []any{val}
+			}
+		}
+	case []any:
+		if s.wildcard {
+			return t
+		}
+		if !s.isIndex {
+			return nil
+		}
+		i := s.index
+		if i < 0 {
+			i += len(t)
+		}
+		if i >= 0 && i < len(t) {
+			return []any{t[i]}
+		}
+	}
+	return nil
+}
+
+func normalizeJSONKey(k string) string {
+	return strings.ToLower(strings.ReplaceAll(k, "_", ""))
+}
+
+// FormatJSONValue renders a matched value for printing, with strings
+// unquoted and everything else as compact JSON.
+func FormatJSONValue(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONPath(t *testing.T) {
+	obj := map[string]any{
+		"egress_id": "EG_1",
+		"file_results": []any{
+			map[string]any{"location": "a.mp4", "size": 10},
+			map[string]any{"location": "b.mp4", "size": 20},
+		},
+	}
+	cases := map[string]string{
+		"$.egressId":                  "EG_1",
+		"$.egress_id":                 "EG_1",
+		"$.fileResults[0].location":   "a.mp4",
+		"$.fileResults[-1].location":  "b.mp4",
+		"$['file_results'][1].size":   "20",
+		"$.fileResults[*].location":   "a.mp4,b.mp4",
+		"$.fileResults[5].location":   "",
+		"$.missing":                   "",
+		"$.fileResults[0]":            `{"location":"a.mp4","size":10}`,
+		"$.fileResults.location":      "",
+		"$.egressId.nested[0]":        "",
+		"$.file_results[*].size":      "10,20",
+		"  $.fileResults[1].location": "b.mp4",
+	}
+	for path, expected := range cases {
+		p, err := ParseJSONPath(path)
+		if err != nil {
+			t.Errorf("ParseJSONPath(%q): %v", path, err)
+			continue
+		}
+		values, err := p.Eval(obj)
+		if err != nil {
+			t.Errorf("Eval(%q): %v", path, err)
+			continue
+		}
+		var out []string
+		for _, v := range values {
+			out = append(out, FormatJSONValue(v))
+		}
+		if actual := strings.Join(out, ","); actual != expected {
+			t.Errorf("Eval(%q) = %q, expected %q", path, actual, expected)
+		}
+	}
+
+	for _, path := range []string{"fileResults", "$.", "$[0", "$[abc]", "$x"} {
+		if _, err := ParseJSONPath(path); err == nil {
+			t.Errorf("ParseJSONPath(%q) should fail", path)
+		}
+	}
+}
@@ -15,16 +15,23 @@
 package util
 
 import (
+	"strings"
+
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
+	"github.com/charmbracelet/x/ansi"
 )
 
+// MaxColumnWidth limits the width of table cells, longer values are
+// truncated with an ellipsis. Zero disables truncation.
+var MaxColumnWidth = 40
+
 func CreateTable() *table.Table {
 	styleFunc := func(row, col int) lipgloss.Style {
 		if row == table.HeaderRow {
 			return FormHeaderStyle
 		}
-		return FormBaseStyle
+		return CellStyle(FormBaseStyle)
 	}
 
 	t := table.New().
@@ -34,3 +41,21 @@ func CreateTable() *table.Table {
 
 	return t
 }
+
+// CellStyle applies MaxColumnWidth to a table cell style. Tables that
+// override the default StyleFunc should wrap their body styles with it.
+func CellStyle(style lipgloss.Style) lipgloss.Style {
+	return style.Transform(TruncateCell)
+}
+
+// TruncateCell shortens each line of a cell to MaxColumnWidth.
+func TruncateCell(s string) string {
+	if MaxColumnWidth <= 0 {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, MaxColumnWidth, "…")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,42 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+)
+
+func TestTruncateCell(t *testing.T) {
+	defer func(w int) { MaxColumnWidth = w }(MaxColumnWidth)
+
+	MaxColumnWidth = 5
+	cases := map[string]string{
+		"abc":             "abc",
+		"abcde":           "abcde",
+		"abcdefgh":        "abcd…",
+		"abcdefgh\nxy":    "abcd…\nxy",
+		"{\"key\":\"v\"}": "{\"ke…",
+	}
+	for in, expected := range cases {
+		if actual := TruncateCell(in); actual != expected {
+			t.Errorf("TruncateCell(%q) = %q, expected %q", in, actual, expected)
+		}
+	}
+
+	MaxColumnWidth = 0
+	if actual := TruncateCell("abcdefgh"); actual != "abcdefgh" {
+		t.Errorf("expected no truncation, got %q", actual)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PrintYAML prints obj as YAML, with the same field names as PrintJSON.
+func PrintYAML(obj any) error {
+	txt, err := MarshalStableYAML(obj)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(txt))
+	return nil
+}
+
+// MarshalStableYAML marshals obj as YAML with the fields, key order and
+// number formatting of MarshalStableJSON.
+func MarshalStableYAML(obj any) ([]byte, error) {
+	raw, err := MarshalStableJSON(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is valid YAML, decoding it as nodes keeps the order of keys
+	var node yaml.Node
+	if err = yaml.Unmarshal(raw, &node); err != nil {
+		return nil, err
+	}
+	resetYAMLStyle(&node)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err = enc.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err = enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// resetYAMLStyle drops the flow and quoting styles of JSON input, letting the
+// encoder choose block style and quote only where needed.
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
+
+// PrintCSV prints header and rows as CSV.
+func PrintCSV(header []string, rows [][]string) error {
+	w := csv.NewWriter(os.Stdout)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	return w.Error()
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 LiveKit, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+)
+
+func TestMarshalStableYAML(t *testing.T) {
+	obj := struct {
+		Name     string            `json:"name"`
+		Metadata string            `json:"metadata"`
+		Labels   map[string]string `json:"labels"`
+		Items    []int64           `json:"items"`
+	}{
+		Name:     "test",
+		Metadata: "true",
+		Labels:   map[string]string{"b": "2", "a": "x: y"},
+		Items:    []int64{1739000000123456789, 2},
+	}
+
+	expected := `items:
+  - 1739000000123456789
+  - 2
+labels:
+  a: 'x: y'
+  b: "2"
+metadata: "true"
+name: test
+`
+	out, err := MarshalStableYAML(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != expected {
+		t.Errorf("MarshalStableYAML should sort keys and quote ambiguous strings, got:\n%s", out)
+	}
+}